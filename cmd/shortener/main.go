@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	_ "net/http/pprof"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/Orendev/shortener/internal/app"
 	"github.com/Orendev/shortener/internal/config"
@@ -18,8 +22,14 @@ var (
 
 // Build go build -ldflags "-X main.buildVersion=1.0.1 -X main.buildCommit=1.0.1 -X 'main.buildDate=$(date +'%Y/%m/%d')'" -o shortener  cmd/shortener/main.go
 // Run: ./shortener
+// Migrate: ./shortener migrate up|down|status [steps] -d <dsn>
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
 	cfg, err := config.New()
 	if err != nil {
 		log.Fatal(err)
@@ -33,5 +43,42 @@ func main() {
 	fmt.Printf("Build date: %s\n", buildDate)
 	fmt.Printf("Build commit: %s\n", buildCommit)
 
-	app.Run(cfg)
+	if err := app.Run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// migrate runs the schema migration subcommand, the flags after the command are the usual service flags.
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: shortener migrate up|down|status [steps] [flags]")
+	}
+
+	command := args[0]
+	args = args[1:]
+
+	steps := 0
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		var err error
+		steps, err = strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		args = args[1:]
+	}
+
+	os.Args = append(os.Args[:1], args...)
+
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := logger.NewLogger(cfg.Log.FlagLogLevel); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := app.Migrate(context.Background(), cfg, command, steps, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,23 @@
+package analytics
+
+import "net"
+
+// IPv4 addresses keep the /24 network, IPv6 addresses keep the /48 network.
+var (
+	ipv4Mask = net.CIDRMask(24, 32)
+	ipv6Mask = net.CIDRMask(48, 128)
+)
+
+// AnonymizeIP zeroes the host part of the IP address, an unparsable address becomes an empty string.
+func AnonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(ipv4Mask).String()
+	}
+
+	return parsed.Mask(ipv6Mask).String()
+}
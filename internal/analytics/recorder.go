@@ -0,0 +1,135 @@
+// Package analytics records the redirects through the short links asynchronously, off the request path.
+package analytics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"go.uber.org/zap"
+)
+
+// batchSize the number of clicks after which the buffer is written without waiting for the flush interval.
+const batchSize = 100
+
+// defaultInterval the flush interval used when the configured one is not positive.
+const defaultInterval = time.Second
+
+// flushTimeout limits the time of writing one batch to the storage.
+const flushTimeout = 5 * time.Second
+
+// Recorder buffers the clicks in a channel and writes them to the storage in batches in the background.
+type Recorder struct {
+	store    repository.AnalyticsStorage
+	events   chan models.Click
+	interval time.Duration
+	done     chan struct{}
+	mu       sync.RWMutex
+	closed   bool
+	dropped  atomic.Int64
+}
+
+// NewRecorder constructor creates the Recorder and starts its background writer.
+func NewRecorder(store repository.AnalyticsStorage, bufferSize int, interval time.Duration) *Recorder {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	r := &Recorder{
+		store:    store,
+		events:   make(chan models.Click, bufferSize),
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+
+	go r.run()
+
+	return r
+}
+
+// Store the storage the clicks are written to.
+func (r *Recorder) Store() repository.AnalyticsStorage {
+	return r.store
+}
+
+// Record puts the click into the buffer without blocking, the click is dropped if the buffer is full.
+func (r *Recorder) Record(click models.Click) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return false
+	}
+
+	select {
+	case r.events <- click:
+		return true
+	default:
+		r.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped the number of clicks lost because the buffer was full.
+func (r *Recorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Close stops accepting clicks and waits until the buffered ones are written.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.events)
+	r.mu.Unlock()
+
+	<-r.done
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]models.Click, 0, batchSize)
+
+	for {
+		select {
+		case click, ok := <-r.events:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *Recorder) flush(batch []models.Click) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	err := r.store.SaveClicks(ctx, batch)
+	if err != nil {
+		logger.Log.Error("cannot save clicks", zap.Int("count", len(batch)), zap.Error(err))
+	}
+}
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Close(t *testing.T) {
+	store := memory.NewAnalytics()
+	r := NewRecorder(store, 10, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, r.Record(models.Click{Code: "4rSPg8ap", ClickedAt: time.Now()}))
+	}
+
+	// закрытие дописывает буфер, не дожидаясь интервала
+	r.Close()
+
+	total, err := store.ClicksTotal(context.Background(), "4rSPg8ap")
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+
+	assert.False(t, r.Record(models.Click{Code: "4rSPg8ap"}), "closed recorder must not accept clicks")
+}
+
+func TestRecorder_Flush(t *testing.T) {
+	store := memory.NewAnalytics()
+	r := NewRecorder(store, 10, 10*time.Millisecond)
+	defer r.Close()
+
+	require.True(t, r.Record(models.Click{Code: "4rSPg8ap", ClickedAt: time.Now()}))
+
+	assert.Eventually(t, func() bool {
+		total, err := store.ClicksTotal(context.Background(), "4rSPg8ap")
+		return err == nil && total == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{
+			name: "ipv4",
+			ip:   "192.168.1.42",
+			want: "192.168.1.0",
+		},
+		{
+			name: "ipv6",
+			ip:   "2001:db8:85a3:8d3:1319:8a2e:370:7348",
+			want: "2001:db8:85a3::",
+		},
+		{
+			name: "invalid",
+			ip:   "localhost",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AnonymizeIP(tt.ip))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
@@ -13,57 +14,177 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Orendev/shortener/internal/analytics"
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/codegen"
 	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/deletion"
 	shortenergrpc "github.com/Orendev/shortener/internal/handlers/grpc"
+	shortenerhttp "github.com/Orendev/shortener/internal/handlers/http"
 	"github.com/Orendev/shortener/internal/logger"
 	middlewares "github.com/Orendev/shortener/internal/middlewares/grpc"
+	"github.com/Orendev/shortener/internal/moderation"
 	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
 	"github.com/Orendev/shortener/internal/repository"
+	"github.com/Orendev/shortener/internal/repository/cache"
+	"github.com/Orendev/shortener/internal/repository/kv"
 	"github.com/Orendev/shortener/internal/repository/memory"
 	"github.com/Orendev/shortener/internal/repository/postgres"
 	"github.com/Orendev/shortener/internal/routes"
 	"github.com/Orendev/shortener/internal/tls"
+	"github.com/Orendev/shortener/internal/urlnorm"
+	"github.com/Orendev/shortener/internal/urlpolicy"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
 
 // App - structure describing the application
 type App struct {
-	repo repository.Storage
+	repo    repository.Storage
+	deletes *deletion.Queue
 }
 
 var shutdownTimeout = 10 * time.Second
 
-// Run starts the application.
-func Run(cfg *config.Configs) {
+// Run starts the application, the error is returned when the application fails to start.
+func Run(cfg *config.Configs) error {
 	ctx := gracefulShutdown()
 
+	keys, err := auth.LoadKeyring(cfg.JWT)
+	if err != nil {
+		return fmt.Errorf("error jwt keys init: %w", err)
+	}
+	if len(cfg.JWT.Secret) == 0 && len(cfg.JWT.KeysFile) == 0 {
+		logger.Log.Warn("the JWT secret is not configured, the tokens are signed with a random key")
+	}
+	auth.SetKeyring(keys)
+
 	var repo repository.Storage
+	var clicks repository.AnalyticsStorage
+	var journal repository.DeletionStorage
+	var sessionStore repository.SessionStorage = memory.NewSessions()
+	var keyStore repository.APIKeyStorage
+	var userStore repository.UserStore
+	var banStore repository.BanStore
+	var auditStore repository.AuditStore
+	var counter repository.CodeCounter
 
 	if len(cfg.Database.DatabaseDSN) > 0 {
-		pg, err := postgres.NewPostgres(cfg.Database.DatabaseDSN)
+		pg, err := postgres.NewPostgres(ctx, cfg.Database)
 		if err != nil {
-			logger.Log.Sugar().Errorf("error postgres init: %s", err)
-			return
+			return fmt.Errorf("error postgres init: %w", err)
 		}
 
 		shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
 		defer cancel()
+		// без применённых миграций сервис не запускается
 		err = pg.Bootstrap(shutdownCtx)
 		if err != nil {
-			logger.Log.Sugar().Errorf("error postgres shutdownCtx: %s", err)
+			_ = pg.Close()
+			if errors.Is(err, postgres.ErrSchemaTooNew) {
+				return fmt.Errorf("refusing to start: %w", err)
+			}
+			return fmt.Errorf("error postgres bootstrap: %w", err)
 		}
 
 		repo = pg
+		clicks = pg.Analytics()
+		journal = pg.Deletions()
+		sessionStore = pg.Sessions()
+		keyStore = pg.APIKeys()
+		userStore = pg.Users()
+		banStore = pg.Bans()
+		auditStore = pg.Audit()
+		counter = pg.CodeCounter()
+
+	} else if len(cfg.KV.KVStoragePath) > 0 {
+
+		store, err := kv.NewKV(cfg.KV)
+		if err != nil {
+			return fmt.Errorf("error kv init: %w", err)
+		}
+
+		deletions, err := memory.NewDeletions(cfg.KV.KVStoragePath + ".deletions")
+		if err != nil {
+			return fmt.Errorf("error deletions init: %w", err)
+		}
+		defer deletions.Close()
+
+		users, err := memory.NewUsers(cfg.KV.KVStoragePath + ".users")
+		if err != nil {
+			return fmt.Errorf("error users init: %w", err)
+		}
+
+		apiKeyStore, err := memory.NewAPIKeys(cfg.KV.KVStoragePath + ".apikeys")
+		if err != nil {
+			return fmt.Errorf("error api keys init: %w", err)
+		}
+
+		bans, err := memory.NewBans(cfg.KV.KVStoragePath + ".bans")
+		if err != nil {
+			return fmt.Errorf("error bans init: %w", err)
+		}
+
+		audit, err := memory.NewAudit(cfg.KV.KVStoragePath + ".audit")
+		if err != nil {
+			return fmt.Errorf("error audit init: %w", err)
+		}
+
+		repo = store
+		clicks = store.Analytics()
+		journal = deletions
+		keyStore = apiKeyStore
+		userStore = users
+		banStore = bans
+		auditStore = audit
+		counter = store.CodeCounter()
 
 	} else {
 
-		mem, err := memory.NewMemory(cfg.File.FileStoragePath)
+		mem, err := memory.NewMemory(cfg.File)
+		if err != nil {
+			return fmt.Errorf("error memory init: %w", err)
+		}
+
+		deletions, err := memory.NewDeletions(cfg.File.FileStoragePath + ".deletions")
+		if err != nil {
+			return fmt.Errorf("error deletions init: %w", err)
+		}
+		defer deletions.Close()
+
+		users, err := memory.NewUsers(cfg.File.FileStoragePath + ".users")
+		if err != nil {
+			return fmt.Errorf("error users init: %w", err)
+		}
+
+		codeIDs, err := memory.NewCodeCounter(cfg.File.FileStoragePath + ".counter")
+		if err != nil {
+			return fmt.Errorf("error code counter init: %w", err)
+		}
+
+		apiKeyStore, err := memory.NewAPIKeys(cfg.File.FileStoragePath + ".apikeys")
+		if err != nil {
+			return fmt.Errorf("error api keys init: %w", err)
+		}
+
+		bans, err := memory.NewBans(cfg.File.FileStoragePath + ".bans")
+		if err != nil {
+			return fmt.Errorf("error bans init: %w", err)
+		}
+
+		audit, err := memory.NewAudit(cfg.File.FileStoragePath + ".audit")
 		if err != nil {
-			logger.Log.Sugar().Errorf("error memory init: %s", err)
+			return fmt.Errorf("error audit init: %w", err)
 		}
 
 		repo = mem
+		clicks = memory.NewAnalytics()
+		journal = deletions
+		keyStore = apiKeyStore
+		userStore = users
+		banStore = bans
+		auditStore = audit
+		counter = codeIDs
 	}
 
 	defer func() {
@@ -73,32 +194,109 @@ func Run(cfg *config.Configs) {
 		}
 	}()
 
-	a := NewApp(repo)
+	if len(cfg.Cache.Type) > 0 {
+		c, err := cache.New(cfg.Cache)
+		if err != nil {
+			return fmt.Errorf("error cache init: %w", err)
+		}
+
+		repo = cache.NewStorage(repo, c, cfg.Cache.TTL, cfg.Cache.NegativeTTL)
+	}
 
-	err := tls.New(cfg.Cert.CertFile, cfg.Cert.KeyFile)
+	recorder := analytics.NewRecorder(clicks, cfg.Analytics.BufferSize, cfg.Analytics.FlushInterval)
+	// дописываем накопленные переходы до закрытия хранилища
+	defer recorder.Close()
+
+	deletes, err := deletion.NewQueue(ctx, repo, journal, cfg.Deletion)
+	if err != nil {
+		return fmt.Errorf("error deletion queue init: %w", err)
+	}
+
+	a := NewApp(repo, deletes)
+
+	if cfg.Expiration.SweepInterval > 0 {
+		go a.sweepExpired(ctx, cfg.Expiration.SweepInterval)
+	}
+
+	err = tls.New(cfg.Cert.CertFile, cfg.Cert.KeyFile)
 	if err != nil {
 		logger.Log.Error("error tls init", zap.Error(err))
 	}
 
+	generator, err := codegen.New(cfg.Codes, counter)
+	if err != nil {
+		return fmt.Errorf("error code generator init: %w", err)
+	}
+	if cfg.Codes.Strategy == codegen.StrategySequence && len(cfg.Codes.Salt) == 0 {
+		logger.Log.Warn("the code salt is not configured, the sequential codes are predictable")
+	}
+	codes := codegen.NewIssuer(generator, cfg.Codes.Attempts)
+	urls := urlnorm.NewNormalizer(cfg.URLs)
+
+	policy, err := urlpolicy.New(cfg.URLPolicy)
+	if err != nil {
+		return fmt.Errorf("error url policy init: %w", err)
+	}
+	go policy.Watch(ctx)
+
+	apiKeys := auth.NewAPIKeys(keyStore)
+	sessions := auth.NewSessions(sessionStore, cfg.JWT.RefreshTokenExp)
+
+	moderator := moderation.NewModerator(a.repo, banStore, auditStore, cfg.Admin)
+	if len(cfg.Admin.UserIDs) > 0 && len(cfg.TrustedSubnet) == 0 {
+		logger.Log.Warn("the trusted subnet is not configured, the admin API is not available")
+	}
+
+	router, err := routes.Router(a.repo, cfg.BaseURL, cfg.TrustedSubnet, cfg.TrustedProxy, apiKeys, moderator,
+		shortenerhttp.WithExpiredNotFound(cfg.Expiration.NotFound),
+		shortenerhttp.WithAnalytics(recorder),
+		shortenerhttp.WithDeletionQueue(deletes),
+		shortenerhttp.WithSessions(sessions),
+		shortenerhttp.WithCodes(codes),
+		shortenerhttp.WithURLNormalizer(urls),
+		shortenerhttp.WithURLPolicy(policy),
+		shortenerhttp.WithAccounts(auth.NewAccounts(userStore, a.repo, sessions)),
+	)
+	if err != nil {
+		return fmt.Errorf("error http handler init: %w", err)
+	}
+
+	shortenerGRPC, err := shortenergrpc.NewGRPC(a.repo, cfg.BaseURL,
+		shortenergrpc.WithExpiredNotFound(cfg.Expiration.NotFound),
+		shortenergrpc.WithAnalytics(recorder),
+		shortenergrpc.WithDeletionQueue(deletes),
+		shortenergrpc.WithCodes(codes),
+		shortenergrpc.WithURLNormalizer(urls),
+		shortenergrpc.WithURLPolicy(policy),
+		shortenergrpc.WithModerator(moderator),
+	)
+	if err != nil {
+		return fmt.Errorf("error grpc server init: %w", err)
+	}
+
 	a.startServer(ctx, &http.Server{
 		Addr:    cfg.Server.Addr,
-		Handler: routes.Router(a.repo, cfg.BaseURL, cfg.TrustedSubnet),
+		Handler: router,
 	},
+		shortenerGRPC,
 		cfg.GRPC.Addr,
-		cfg.BaseURL,
 		cfg.TrustedSubnet,
+		cfg.TrustedProxy,
+		apiKeys,
 		cfg.Server.IsHTTPS,
 		cfg.Cert.CertFile,
 		cfg.Cert.KeyFile,
 	)
+
+	return nil
 }
 
 // NewApp constructor for the application.
-func NewApp(repo repository.Storage) *App {
-	return &App{repo: repo}
+func NewApp(repo repository.Storage, deletes *deletion.Queue) *App {
+	return &App{repo: repo, deletes: deletes}
 }
 
-func (a *App) startServer(ctx context.Context, srv *http.Server, grpcAddr, baseURL, trustedSubnet string, isHTTPS bool, certFile, keyFile string) {
+func (a *App) startServer(ctx context.Context, srv *http.Server, shortenerGRPC *shortenergrpc.GRPC, grpcAddr, trustedSubnet, trustedProxy string, apiKeys *auth.APIKeys, isHTTPS bool, certFile, keyFile string) {
 	var err error
 	var wg sync.WaitGroup
 
@@ -125,10 +323,11 @@ func (a *App) startServer(ctx context.Context, srv *http.Server, grpcAddr, baseU
 	var opts []grpc.ServerOption
 
 	opts = middlewares.Logger(opts)
+	opts = middlewares.TrustedSubnet(opts, trustedSubnet, trustedProxy)
+	opts = middlewares.APIKey(opts, apiKeys)
+	opts = middlewares.Auth(opts)
 	srvGRPC := grpc.NewServer(opts...)
 
-	shortenerGRPC := shortenergrpc.NewGRPC(a.repo, baseURL, trustedSubnet)
-
 	pb.RegisterShortenerServiceServer(srvGRPC, shortenerGRPC)
 
 	go func() {
@@ -151,6 +350,33 @@ func (a *App) startServer(ctx context.Context, srv *http.Server, grpcAddr, baseU
 
 	wg.Wait()
 
+	// новых удалений больше не будет, сбрасываем очередь до закрытия хранилища
+	if err = a.deletes.Close(shutdownCtx); err != nil {
+		logger.Log.Error("deletion queue not drained", zap.Int("pending", a.deletes.Len()), zap.Error(err))
+	}
+
+}
+
+// sweepExpired periodically soft-deletes the short links whose lifetime is over.
+func (a *App) sweepExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := a.repo.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				logger.Log.Error("cannot delete expired shortLink", zap.Error(err))
+				continue
+			}
+			if count > 0 {
+				logger.Log.Info("expired short links deleted", zap.Int("count", count))
+			}
+		}
+	}
 }
 
 func gracefulShutdown() context.Context {
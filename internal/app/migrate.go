@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/repository/postgres"
+	"go.uber.org/zap"
+)
+
+// Migration commands.
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateStatus = "status"
+)
+
+// ErrMigrateDSNRequired the migrations are run only against Postgres.
+var ErrMigrateDSNRequired = errors.New("migrations require the database DSN")
+
+// ErrMigrateUnknownCommand the command is not one of up, down or status.
+var ErrMigrateUnknownCommand = errors.New("unknown migrate command, expected up, down or status")
+
+// Migrate runs the schema migration command and writes the report to out.
+func Migrate(ctx context.Context, cfg *config.Configs, command string, steps int, out io.Writer) error {
+	if len(cfg.Database.DatabaseDSN) == 0 {
+		return ErrMigrateDSNRequired
+	}
+
+	pg, err := postgres.NewPostgres(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err := pg.Close()
+		if err != nil {
+			logger.Log.Error("error repo", zap.Error(err))
+		}
+	}()
+
+	migrator, err := pg.Migrator()
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case MigrateUp:
+		applied, err := migrator.Up(ctx, steps)
+		for _, m := range applied {
+			_, _ = fmt.Fprintf(out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case MigrateDown:
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			_, _ = fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case MigrateStatus:
+		statuses, err := migrator.Status(ctx)
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(out, "%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+		return err
+	}
+
+	return fmt.Errorf("%w: %q", ErrMigrateUnknownCommand, command)
+}
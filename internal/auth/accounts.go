@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordCost the bcrypt cost of the password hashes.
+const passwordCost = 12
+
+// Errors of the registration and login.
+var (
+	ErrorCredentialsInvalid = errors.New("invalid email or password")
+	ErrorAccountExists      = errors.New("the account already exists")
+	// ErrorCredentialsRequired the session of the registered account is started only with its credentials.
+	ErrorCredentialsRequired = errors.New("the email and the password are required")
+)
+
+// dummyHash is compared when the email is unknown, so the response time does not reveal the registered emails.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), passwordCost)
+
+// Accounts - structure describing the registered accounts of the users.
+//
+// Every visitor starts as an anonymous user with a random ID. The registration turns
+// the current user into the account keeping its ID and links; the login on another
+// device moves the links of that device's anonymous user into the account.
+type Accounts struct {
+	users    repository.UserStore
+	links    repository.Storage
+	sessions *Sessions
+}
+
+// NewAccounts constructor creates the Accounts storing the accounts in users and the links in links.
+func NewAccounts(users repository.UserStore, links repository.Storage, sessions *Sessions) *Accounts {
+	return &Accounts{users: users, links: links, sessions: sessions}
+}
+
+// Register creates the account of the current user and starts its session.
+func (a *Accounts) Register(ctx context.Context, userID string, req models.CredentialsRequest) (*models.TokenPair, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), passwordCost)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.users.CreateUser(ctx, models.User{
+		ID:           userID,
+		Email:        req.NormalizedEmail(),
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	})
+	// адрес занят или пользователь уже зарегистрирован
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, ErrorAccountExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return a.sessions.Login(ctx, userID)
+}
+
+// Login checks the credentials and starts the session of the account.
+// The links of the anonymous current user are claimed by the account.
+func (a *Accounts) Login(ctx context.Context, currentUserID string, req models.CredentialsRequest) (*models.TokenPair, error) {
+	user, err := a.users.GetUserByEmail(ctx, req.NormalizedEmail())
+	if errors.Is(err, repository.ErrNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(req.Password))
+		return nil, ErrorCredentialsInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrorCredentialsInvalid
+	}
+
+	if len(currentUserID) > 0 && currentUserID != user.ID {
+		if err = a.claim(ctx, currentUserID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.sessions.Login(ctx, user.ID)
+}
+
+// LoginAnonymous starts the session of the anonymous user without the credentials.
+//
+// The access token of the registered account is not exchanged for a session: a leaked
+// token would give a long-lived refresh token, the account logs in with its credentials.
+func (a *Accounts) LoginAnonymous(ctx context.Context, userID string) (*models.TokenPair, error) {
+	_, err := a.users.GetUserByID(ctx, userID)
+	if err == nil {
+		return nil, ErrorCredentialsRequired
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	return a.sessions.Login(ctx, userID)
+}
+
+// claim moves the links of the anonymous user into the account,
+// the links of another account are never taken.
+func (a *Accounts) claim(ctx context.Context, fromUserID, toUserID string) error {
+	_, err := a.users.GetUserByID(ctx, fromUserID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	claimed, err := a.links.ClaimShortLinks(ctx, fromUserID, toUserID)
+	if err != nil {
+		return err
+	}
+
+	if len(claimed) > 0 {
+		logger.Log.Info("links claimed by the account",
+			zap.String("from", fromUserID), zap.String("to", toUserID), zap.Int("count", len(claimed)))
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	links, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "links.json")})
+	require.NoError(t, err)
+	defer links.Close()
+
+	users, err := memory.NewUsers("")
+	require.NoError(t, err)
+
+	a := NewAccounts(users, links, NewSessions(memory.NewSessions(), 0))
+	credentials := models.CredentialsRequest{Email: "User@Example.com", Password: "correct horse"}
+
+	for _, link := range []models.ShortLink{
+		{UUID: "1", Code: "first", OriginalURL: "https://first.example", UserID: "laptop"},
+		{UUID: "2", Code: "second", OriginalURL: "https://second.example", UserID: "phone"},
+		{UUID: "3", Code: "third", OriginalURL: "https://third.example", UserID: "stranger"},
+	} {
+		require.NoError(t, links.Save(ctx, link))
+	}
+
+	pair, err := a.Register(ctx, "laptop", credentials)
+	require.NoError(t, err)
+	userID, err := ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", userID)
+
+	_, err = a.Register(ctx, "phone", models.CredentialsRequest{Email: "user@example.com", Password: "another password"})
+	assert.ErrorIs(t, err, ErrorAccountExists)
+
+	_, err = a.Login(ctx, "phone", models.CredentialsRequest{Email: credentials.Email, Password: "wrong password"})
+	assert.ErrorIs(t, err, ErrorCredentialsInvalid)
+	_, err = a.Login(ctx, "phone", models.CredentialsRequest{Email: "unknown@example.com", Password: "correct horse"})
+	assert.ErrorIs(t, err, ErrorCredentialsInvalid)
+
+	// вход на новом устройстве переносит ссылки анонимного пользователя в учётную запись
+	pair, err = a.Login(ctx, "phone", credentials)
+	require.NoError(t, err)
+	userID, err = ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", userID)
+
+	link, err := links.GetByCode(ctx, "second")
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", link.UserID)
+
+	// ссылки другой учётной записи не переносятся
+	_, err = a.Register(ctx, "stranger", models.CredentialsRequest{Email: "stranger@example.com", Password: "stranger password"})
+	require.NoError(t, err)
+	_, err = a.Login(ctx, "stranger", credentials)
+	require.NoError(t, err)
+
+	link, err = links.GetByCode(ctx, "third")
+	require.NoError(t, err)
+	assert.Equal(t, "stranger", link.UserID)
+
+	// без пароля сессия начинается только у анонимного пользователя
+	_, err = a.LoginAnonymous(ctx, "laptop")
+	assert.ErrorIs(t, err, ErrorCredentialsRequired)
+	pair, err = a.LoginAnonymous(ctx, "tablet")
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.RefreshToken)
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix marks the API keys, so the leaked keys are easy to find.
+const apiKeyPrefix = "shk_"
+
+// apiKeyPrefixLen the number of the characters of the key shown in the listings.
+const apiKeyPrefixLen = len(apiKeyPrefix) + 8
+
+// touchInterval limits the writes of the last use of the key.
+const touchInterval = time.Minute
+
+// HeaderAPIKey the header of the API key.
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyContextKey the context key of the API key authenticating the request.
+const APIKeyContextKey contextKey = "APIKey"
+
+// Errors of the API keys.
+var (
+	// ErrorAPIKeyInvalid the key is unknown or revoked.
+	ErrorAPIKeyInvalid = errors.New("API key is invalid")
+
+	// ErrorAPIKeyNotFound the user has no such active key.
+	ErrorAPIKeyNotFound = errors.New("API key not found")
+)
+
+// APIKeys - structure describing the API keys of the machine clients.
+type APIKeys struct {
+	store repository.APIKeyStorage
+}
+
+// NewAPIKeys constructor creates the APIKeys storing the keys in store.
+func NewAPIKeys(store repository.APIKeyStorage) *APIKeys {
+	return &APIKeys{store: store}
+}
+
+// Create creates the key of the user, the secret is returned only here.
+func (k *APIKeys) Create(ctx context.Context, userID string, req models.APIKeyRequest) (*models.APIKeyResponse, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	key := models.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    secret[:apiKeyPrefixLen],
+		Hash:      hashToken(secret),
+		Scopes:    req.Scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := k.store.SaveAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyResponse{APIKey: key, Key: secret}, nil
+}
+
+// List the keys of the user.
+func (k *APIKeys) List(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return k.store.APIKeysByUserID(ctx, userID)
+}
+
+// Revoke revokes the key of the user.
+func (k *APIKeys) Revoke(ctx context.Context, userID, id string) error {
+	revoked, err := k.store.RevokeAPIKey(ctx, id, userID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrorAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Authenticate finds the active key and remembers its use.
+func (k *APIKeys) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrorAPIKeyInvalid
+	}
+
+	key, err := k.store.GetAPIKeyByHash(ctx, hashToken(secret))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrorAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrorAPIKeyInvalid
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		// ошибка записи времени использования не мешает запросу
+		if err = k.store.TouchAPIKey(ctx, key.ID, now); err == nil {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
+
+// WithAPIKey the context of the request authenticated by the key as its user.
+func WithAPIKey(ctx context.Context, key *models.APIKey) context.Context {
+	ctx = context.WithValue(ctx, APIKeyContextKey, key)
+	return context.WithValue(ctx, JwtUserIDContextKey, key.UserID)
+}
+
+// IsAPIKey reports whether the request is authenticated by the API key.
+func IsAPIKey(ctx context.Context) bool {
+	_, ok := ctx.Value(APIKeyContextKey).(*models.APIKey)
+	return ok
+}
+
+// HasScope reports whether the request may use the scope,
+// the requests of the user sessions are not limited by the scopes.
+func HasScope(ctx context.Context, scope models.APIKeyScope) bool {
+	key, ok := ctx.Value(APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		return true
+	}
+	return key.HasScope(scope)
+}
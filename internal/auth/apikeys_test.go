@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := memory.NewAPIKeys("")
+	require.NoError(t, err)
+	keys := NewAPIKeys(store)
+
+	created, err := keys.Create(ctx, "user", models.APIKeyRequest{Name: " ci ", Scopes: []models.APIKeyScope{models.ScopeCreate}})
+	require.NoError(t, err)
+	assert.Equal(t, "ci", created.Name)
+	assert.Equal(t, created.Key[:len(created.Prefix)], created.Prefix)
+	assert.NotContains(t, created.Hash, created.Key)
+
+	key, err := keys.Authenticate(ctx, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "user", key.UserID)
+	assert.NotNil(t, key.LastUsedAt)
+
+	listed, err := keys.List(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.NotNil(t, listed[0].LastUsedAt)
+
+	// ключ отзывает только его владелец
+	assert.ErrorIs(t, keys.Revoke(ctx, "another", created.ID), ErrorAPIKeyNotFound)
+	require.NoError(t, keys.Revoke(ctx, "user", created.ID))
+	assert.ErrorIs(t, keys.Revoke(ctx, "user", created.ID), ErrorAPIKeyNotFound)
+
+	_, err = keys.Authenticate(ctx, created.Key)
+	assert.ErrorIs(t, err, ErrorAPIKeyInvalid)
+
+	_, err = keys.Authenticate(ctx, "shk_unknown")
+	assert.ErrorIs(t, err, ErrorAPIKeyInvalid)
+}
+
+func TestHasScope(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, HasScope(ctx, models.ScopeDelete))
+	assert.False(t, IsAPIKey(ctx))
+
+	ctx = WithAPIKey(ctx, &models.APIKey{UserID: "user", Scopes: []models.APIKeyScope{models.ScopeRead}})
+	assert.True(t, IsAPIKey(ctx))
+	assert.True(t, HasScope(ctx, models.ScopeRead))
+	assert.False(t, HasScope(ctx, models.ScopeDelete))
+
+	userID, err := GetAuthIdentifier(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user", userID)
+}
@@ -3,7 +3,6 @@ package auth
 import (
 	"context"
 	"errors"
-	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -39,11 +38,10 @@ var (
 
 // JWT Token settings.
 const (
-	TokenExp                          = time.Hour * 3
-	SecretKey                         = "supersecretkey"
 	JwtContextKey          contextKey = "JWTToken"
 	JwtUserIDContextKey    contextKey = "JWTUserID"
 	CookieAccessTokenKey   string     = "access_token"
+	CookieRefreshTokenKey  string     = "refresh_token"
 	HeaderAuthorizationKey string     = "Authorization"
 )
 
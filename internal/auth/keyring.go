@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// defaultTokenExp the lifetime of the tokens when it is not configured.
+const defaultTokenExp = 15 * time.Minute
+
+// defaultAnonymousTokenExp the lifetime of the tokens of the anonymous users when it is not configured.
+const defaultAnonymousTokenExp = 30 * 24 * time.Hour
+
+// defaultKeyID the kid of the secret set by the JWT_SECRET.
+const defaultKeyID = "default"
+
+// ErrorKeyUnknown the token is signed with a key missing from the keyring.
+var ErrorKeyUnknown = errors.New("unknown signing key")
+
+// Key - structure describing the key signing or verifying the tokens.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// NewKey constructor creates the key of the algorithm alg from the HMAC secret,
+// the RSA or Ed25519 private key or, to only verify the tokens, the public key.
+func NewKey(id, alg string, key interface{}) (*Key, error) {
+	method := jwt.GetSigningMethod(alg)
+	if method == nil || method.Alg() == jwt.SigningMethodNone.Alg() {
+		return nil, fmt.Errorf("%w: %s", ErrorUnexpectedSigningMethod, alg)
+	}
+
+	k := &Key{ID: id, Method: method}
+
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("key %q: %s needs a secret", id, alg)
+		}
+		k.sign, k.verify = secret, secret
+	case *jwt.SigningMethodRSA:
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			k.sign, k.verify = key, &key.PublicKey
+		case *rsa.PublicKey:
+			k.verify = key
+		default:
+			return nil, fmt.Errorf("key %q: %s needs an RSA key", id, alg)
+		}
+	case *jwt.SigningMethodEd25519:
+		switch key := key.(type) {
+		case ed25519.PrivateKey:
+			k.sign, k.verify = key, key.Public()
+		case ed25519.PublicKey:
+			k.verify = key
+		default:
+			return nil, fmt.Errorf("key %q: %s needs an Ed25519 key", id, alg)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrorUnexpectedSigningMethod, alg)
+	}
+
+	return k, nil
+}
+
+// Keyring - structure describing the keys of the tokens.
+//
+// The tokens are signed with the active key and verified with the key of their kid header,
+// so the retired keys keep verifying the issued tokens until these expire.
+type Keyring struct {
+	active   *Key
+	keys     map[string]*Key
+	tokenExp time.Duration
+	// anonymousExp the lifetime of the tokens of the anonymous users.
+	anonymousExp time.Duration
+}
+
+// NewKeyring constructor creates the Keyring signing with the active key.
+func NewKeyring(tokenExp time.Duration, active *Key, keys ...*Key) (*Keyring, error) {
+	if active == nil || active.sign == nil {
+		return nil, errors.New("the active key cannot sign the tokens")
+	}
+	if tokenExp <= 0 {
+		tokenExp = defaultTokenExp
+	}
+
+	k := &Keyring{
+		active:       active,
+		keys:         make(map[string]*Key, len(keys)+1),
+		tokenExp:     tokenExp,
+		anonymousExp: defaultAnonymousTokenExp,
+	}
+	for _, key := range append(keys, active) {
+		k.keys[key.ID] = key
+	}
+
+	return k, nil
+}
+
+// keysFile the format of the JWT_KEYS_FILE.
+type keysFile struct {
+	Active string `json:"active"`
+	Keys   []struct {
+		ID             string `json:"kid"`
+		Alg            string `json:"alg"`
+		Secret         string `json:"secret"`
+		PrivateKeyFile string `json:"private_key_file"`
+		PublicKeyFile  string `json:"public_key_file"`
+	} `json:"keys"`
+}
+
+// LoadKeyring creates the Keyring of the configured secret and keys file.
+//
+// The secret is the HS256 key with the kid "default", it is active unless the keys file
+// names the active key. Without both a random secret is generated, so the tokens
+// do not survive the restart.
+func LoadKeyring(cfg config.JWT) (*Keyring, error) {
+	var active *Key
+	var keys []*Key
+
+	if len(cfg.Secret) > 0 {
+		key, err := NewKey(defaultKeyID, jwt.SigningMethodHS256.Alg(), []byte(cfg.Secret))
+		if err != nil {
+			return nil, err
+		}
+		active = key
+		keys = append(keys, key)
+	}
+
+	if len(cfg.KeysFile) > 0 {
+		data, err := os.ReadFile(cfg.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var file keysFile
+		if err = json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+
+		for _, item := range file.Keys {
+			key, err := loadKey(item.ID, item.Alg, item.Secret, item.PrivateKeyFile, item.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+
+			if item.ID == file.Active {
+				active = key
+			}
+		}
+
+		if len(file.Active) > 0 && (active == nil || active.ID != file.Active) {
+			return nil, fmt.Errorf("the active key %q is not in %s", file.Active, cfg.KeysFile)
+		}
+	}
+
+	if active == nil {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+
+		key, err := NewKey(defaultKeyID, jwt.SigningMethodHS256.Alg(), secret)
+		if err != nil {
+			return nil, err
+		}
+		active = key
+	}
+
+	k, err := NewKeyring(cfg.TokenExp, active, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AnonymousTokenExp > 0 {
+		k.anonymousExp = cfg.AnonymousTokenExp
+	}
+
+	return k, nil
+}
+
+func loadKey(id, alg, secret, privateKeyFile, publicKeyFile string) (*Key, error) {
+	if len(id) == 0 {
+		return nil, errors.New("the key has no kid")
+	}
+
+	if len(secret) > 0 {
+		return NewKey(id, alg, []byte(secret))
+	}
+
+	file, private := privateKeyFile, true
+	if len(file) == 0 {
+		file, private = publicKeyFile, false
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	var key interface{}
+	switch method := jwt.GetSigningMethod(alg); {
+	case method == nil:
+		return nil, fmt.Errorf("%w: %s", ErrorUnexpectedSigningMethod, alg)
+	case method.Alg() == jwt.SigningMethodEdDSA.Alg() && private:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(data)
+	case method.Alg() == jwt.SigningMethodEdDSA.Alg():
+		key, err = jwt.ParseEdPublicKeyFromPEM(data)
+	case private:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+	default:
+		key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	return NewKey(id, alg, key)
+}
+
+// sign signs the token with the active key.
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.Method, claims)
+	token.Header["kid"] = k.active.ID
+
+	return token.SignedString(k.active.sign)
+}
+
+// keyFunc finds the key verifying the token.
+func (k *Keyring) keyFunc(t *jwt.Token) (interface{}, error) {
+	key := k.active
+	// токены без kid выпущены до появления ротации ключей
+	if kid, ok := t.Header["kid"].(string); ok {
+		if key, ok = k.keys[kid]; !ok {
+			return nil, ErrorKeyUnknown
+		}
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, ErrorUnexpectedSigningMethod
+	}
+
+	return key.verify, nil
+}
+
+// JWK the public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS the set of the public keys.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS the public keys of the asymmetric algorithms, the HMAC secrets are never published.
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(k.keys))}
+
+	for _, key := range k.keys {
+		jwk := JWK{Kid: key.ID, Alg: key.Method.Alg(), Use: "sig"}
+
+		switch public := key.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
+
+var (
+	keyringMu sync.RWMutex
+	keyring   = mustRandomKeyring()
+)
+
+// SetKeyring replaces the keys of NewToken and ParseToken, it is called at the start of the service.
+func SetKeyring(k *Keyring) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+
+	keyring = k
+}
+
+func currentKeyring() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	return keyring
+}
+
+// TokenExp the lifetime of the tokens.
+func TokenExp() time.Duration {
+	return currentKeyring().tokenExp
+}
+
+// AnonymousTokenExp the lifetime of the tokens of the anonymous users.
+func AnonymousTokenExp() time.Duration {
+	return currentKeyring().anonymousExp
+}
+
+// PublicKeys the JWKS of the keyring.
+func PublicKeys() JWKS {
+	return currentKeyring().JWKS()
+}
+
+func mustRandomKeyring() *Keyring {
+	k, err := LoadKeyring(config.JWT{})
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
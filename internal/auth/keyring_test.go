@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useKeyring replaces the keyring for the test.
+func useKeyring(t *testing.T, k *Keyring) {
+	t.Helper()
+
+	previous := currentKeyring()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(previous) })
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey, err := NewKey("2024-01", "HS256", []byte("old secret"))
+	require.NoError(t, err)
+	newKey, err := NewKey("2024-02", "HS256", []byte("new secret"))
+	require.NoError(t, err)
+
+	old, err := NewKeyring(time.Hour, oldKey)
+	require.NoError(t, err)
+	useKeyring(t, old)
+
+	token, err := NewToken("user")
+	require.NoError(t, err)
+
+	// старый ключ остаётся в связке и проверяет выпущенные им токены
+	rotated, err := NewKeyring(time.Hour, newKey, oldKey)
+	require.NoError(t, err)
+	SetKeyring(rotated)
+
+	userID, err := ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user", userID)
+
+	retired, err := NewKeyring(time.Hour, newKey)
+	require.NoError(t, err)
+	SetKeyring(retired)
+
+	_, err = ParseToken(token)
+	assert.ErrorIs(t, err, ErrorKeyUnknown)
+}
+
+func TestKeyring_WithoutKid(t *testing.T) {
+	key, err := NewKey(defaultKeyID, "HS256", []byte("secret"))
+	require.NoError(t, err)
+	k, err := NewKeyring(time.Hour, key)
+	require.NoError(t, err)
+	useKeyring(t, k)
+
+	// токен, выпущенный до появления kid
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		UserID:           "user",
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	userID, err := ParseToken(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "user", userID)
+}
+
+func TestKeyring_Asymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		alg  string
+		key  interface{}
+		kty  string
+	}{
+		{name: "RS256", alg: "RS256", key: rsaKey, kty: "RSA"},
+		{name: "EdDSA", alg: "EdDSA", key: edKey, kty: "OKP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewKey(tt.name, tt.alg, tt.key)
+			require.NoError(t, err)
+			k, err := NewKeyring(time.Hour, key)
+			require.NoError(t, err)
+			useKeyring(t, k)
+
+			token, err := NewToken("user")
+			require.NoError(t, err)
+
+			userID, err := ParseToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user", userID)
+
+			jwks := PublicKeys()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.name, jwks.Keys[0].Kid)
+		})
+	}
+}
+
+func TestKeyring_AlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	key, err := NewKey("rsa", "RS256", rsaKey)
+	require.NoError(t, err)
+	k, err := NewKeyring(time.Hour, key)
+	require.NoError(t, err)
+	useKeyring(t, k)
+
+	// подпись HS256 открытым ключом не должна проходить проверку
+	public := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: "admin"})
+	forged.Header["kid"] = "rsa"
+	tokenString, err := forged.SignedString(public)
+	require.NoError(t, err)
+
+	_, err = ParseToken(tokenString)
+	assert.ErrorIs(t, err, ErrorUnexpectedSigningMethod)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, "ed25519.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	keysFile := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(keysFile, []byte(`{
+		"active": "2024-02",
+		"keys": [
+			{"kid": "2024-01", "alg": "HS256", "secret": "old secret"},
+			{"kid": "2024-02", "alg": "EdDSA", "private_key_file": "`+keyFile+`"}
+		]
+	}`), 0600))
+
+	k, err := LoadKeyring(config.JWT{Secret: "secret", KeysFile: keysFile, TokenExp: time.Minute})
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-02", k.active.ID)
+	assert.Equal(t, time.Minute, k.tokenExp)
+	assert.Len(t, k.keys, 3)
+
+	// секреты HMAC не публикуются
+	jwks := k.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2024-02", jwks.Keys[0].Kid)
+
+	_, err = LoadKeyring(config.JWT{KeysFile: keysFile + ".missing"})
+	assert.Error(t, err)
+
+	k, err = LoadKeyring(config.JWT{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultKeyID, k.active.ID)
+	assert.Equal(t, defaultTokenExp, k.tokenExp)
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// defaultRefreshTokenExp the lifetime of the refresh tokens when it is not configured.
+const defaultRefreshTokenExp = 30 * 24 * time.Hour
+
+// ErrorRefreshTokenInvalid the refresh token is unknown, expired or revoked.
+var ErrorRefreshTokenInvalid = errors.New("refresh token is invalid")
+
+// Sessions - structure describing the sessions of the users:
+// the short-lived access tokens and the revocable refresh tokens stored server side.
+//
+// Every refresh rotates the refresh token. Presenting a revoked refresh token
+// revokes all the sessions of its user, as the token must have leaked.
+type Sessions struct {
+	store      repository.SessionStorage
+	refreshExp time.Duration
+}
+
+// NewSessions constructor creates the Sessions storing the refresh tokens in store.
+func NewSessions(store repository.SessionStorage, refreshExp time.Duration) *Sessions {
+	if refreshExp <= 0 {
+		refreshExp = defaultRefreshTokenExp
+	}
+
+	return &Sessions{store: store, refreshExp: refreshExp}
+}
+
+// Login starts a new session of the user.
+func (s *Sessions) Login(ctx context.Context, userID string) (*models.TokenPair, error) {
+	return s.issue(ctx, userID)
+}
+
+// Refresh exchanges the refresh token for a new token pair.
+func (s *Sessions) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	hash := hashToken(refreshToken)
+	now := time.Now().UTC()
+
+	token, err := s.store.GetRefreshToken(ctx, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrorRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RevokedAt != nil {
+		// повторное использование отозванного токена: закрываем все сессии пользователя
+		if err = s.store.RevokeUserRefreshTokens(ctx, token.UserID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrorRefreshTokenInvalid
+	}
+
+	if !token.Active(now) {
+		return nil, ErrorRefreshTokenInvalid
+	}
+
+	// токен мог быть обменян параллельным запросом
+	revoked, err := s.store.RevokeRefreshToken(ctx, hash, now)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, ErrorRefreshTokenInvalid
+	}
+
+	return s.issue(ctx, token.UserID)
+}
+
+// Logout revokes the refresh token, the unknown tokens are ignored.
+func (s *Sessions) Logout(ctx context.Context, refreshToken string) error {
+	_, err := s.store.RevokeRefreshToken(ctx, hashToken(refreshToken), time.Now().UTC())
+	return err
+}
+
+func (s *Sessions) issue(ctx context.Context, userID string) (*models.TokenPair, error) {
+	accessToken, err := NewToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 32)
+	if _, err = rand.Read(random); err != nil {
+		return nil, err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(random)
+
+	now := time.Now().UTC()
+	err = s.store.SaveRefreshToken(ctx, models.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshExp),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(TokenExp().Seconds()),
+	}, nil
+}
+
+// RefreshTokenExp the lifetime of the refresh tokens.
+func (s *Sessions) RefreshTokenExp() time.Duration {
+	return s.refreshExp
+}
+
+// hashToken the hash of the refresh token kept in the storage.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
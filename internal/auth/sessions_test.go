@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions_Refresh(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewSessions()
+	s := NewSessions(store, time.Hour)
+
+	pair, err := s.Login(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", pair.TokenType)
+
+	userID, err := ParseToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user", userID)
+
+	// хранится только хеш токена обновления
+	_, err = store.GetRefreshToken(ctx, pair.RefreshToken)
+	assert.Error(t, err)
+
+	rotated, err := s.Refresh(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+
+	// повторный обмен старого токена отзывает и новый
+	_, err = s.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrorRefreshTokenInvalid)
+
+	_, err = s.Refresh(ctx, rotated.RefreshToken)
+	assert.ErrorIs(t, err, ErrorRefreshTokenInvalid)
+
+	_, err = s.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrorRefreshTokenInvalid)
+}
+
+func TestSessions_Logout(t *testing.T) {
+	ctx := context.Background()
+	s := NewSessions(memory.NewSessions(), time.Hour)
+
+	pair, err := s.Login(ctx, "user")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Logout(ctx, pair.RefreshToken))
+	require.NoError(t, s.Logout(ctx, "unknown"))
+
+	_, err = s.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrorRefreshTokenInvalid)
+}
+
+func TestSessions_Expired(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewSessions()
+	s := NewSessions(store, time.Hour)
+
+	now := time.Now().UTC()
+	require.NoError(t, store.SaveRefreshToken(ctx, models.RefreshToken{
+		Hash:      hashToken("expired"),
+		UserID:    "user",
+		CreatedAt: now.Add(-2 * time.Hour),
+		ExpiresAt: now.Add(-time.Hour),
+	}))
+
+	_, err := s.Refresh(ctx, "expired")
+	assert.ErrorIs(t, err, ErrorRefreshTokenInvalid)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// NewToken creates the JWT of the user signed with the active key of the keyring.
+func NewToken(userID string) (string, error) {
+	k := currentKeyring()
+	return k.newToken(userID, k.tokenExp)
+}
+
+// NewAnonymousToken creates the JWT of the new anonymous user. The anonymous user has no
+// refresh token, so its token lives as long as AnonymousTokenExp and the user keeps its links.
+func NewAnonymousToken(userID string) (string, error) {
+	k := currentKeyring()
+	return k.newToken(userID, k.anonymousExp)
+}
+
+func (k *Keyring) newToken(userID string, exp time.Duration) (string, error) {
+	// создаём токен, подписанный активным ключом, с утверждениями — Claims
+	return k.sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			// когда истекает токен
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
+		},
+		// собственное утверждение
+		UserID: userID,
+	})
+}
+
+// ParseToken validates the JWT and returns the user ID of its claims.
+// The user ID is returned with ErrorTokenExpired as well, so the token can be renewed.
+func ParseToken(tokenString string) (string, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, currentKeyring().keyFunc)
+
+	if err != nil {
+		if e, ok := err.(*jwt.ValidationError); ok {
+			switch {
+			case e.Errors&jwt.ValidationErrorMalformed != 0:
+				// Token is malformed
+				return "", ErrorTokenMalformed
+			case e.Errors&jwt.ValidationErrorExpired != 0:
+				// Token is expired
+				return claims.UserID, ErrorTokenExpired
+			case e.Errors&jwt.ValidationErrorNotValidYet != 0:
+				// Token is not active yet
+				return "", ErrorTokenNotActive
+			case e.Inner != nil:
+				// report e.Inner
+				return "", e.Inner
+			}
+		}
+		return "", err
+	}
+
+	if !token.Valid {
+		return "", ErrorTokenInvalid
+	}
+
+	return claims.UserID, nil
+}
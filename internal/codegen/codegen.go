@@ -0,0 +1,140 @@
+// Package codegen generates the codes of the short links.
+package codegen
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// Defaults of the random codes.
+const (
+	DefaultLength   = 8
+	DefaultAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// minLength the shortest random code, shorter codes run out too soon.
+const minLength = 4
+
+// Errors of the generator configuration.
+var (
+	ErrLengthInvalid   = fmt.Errorf("the code length must be %d to %d", minLength, models.AliasMaxLength)
+	ErrAlphabetInvalid = errors.New("the alphabet must have at least 2 distinct characters allowed in an alias")
+)
+
+// Strategies of the code generation.
+const (
+	StrategyRandom   = "random"
+	StrategySequence = "sequence"
+)
+
+// ErrStrategyUnknown the strategy is not one of random or sequence.
+var ErrStrategyUnknown = errors.New("unknown code strategy, expected random or sequence")
+
+// New creates the generator of the configured strategy, the sequence takes its IDs from the counter.
+//
+// The strategies share the namespace of the codes, so they coexist on one storage:
+// the code taken by another strategy or an alias is a collision retried by the Issuer.
+func New(cfg config.Codes, counter repository.CodeCounter) (CodeGenerator, error) {
+	switch cfg.Strategy {
+	case "", StrategyRandom:
+		return NewRandom(cfg.Length, cfg.Alphabet)
+	case StrategySequence:
+		return NewSequence(counter, cfg.MinLength, cfg.Alphabet, cfg.Salt)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrStrategyUnknown, cfg.Strategy)
+	}
+}
+
+// CodeGenerator generates the codes of the new short links.
+type CodeGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// Random - structure describing the generator of the uniformly random codes read from crypto/rand.
+type Random struct {
+	length   int
+	alphabet string
+	// limit the bytes at and above the limit are rejected, so every character is equally likely
+	limit int
+}
+
+// NewRandom constructor creates the Random generating the codes of length characters of the alphabet,
+// the zero length and the empty alphabet are replaced by the defaults.
+func NewRandom(length int, alphabet string) (*Random, error) {
+	if length == 0 {
+		length = DefaultLength
+	}
+	if len(alphabet) == 0 {
+		alphabet = DefaultAlphabet
+	}
+
+	if length < minLength || length > models.AliasMaxLength {
+		return nil, ErrLengthInvalid
+	}
+
+	if err := validateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+
+	return &Random{length: length, alphabet: alphabet, limit: 256 - 256%len(alphabet)}, nil
+}
+
+// Generate the random code, the codes matching the reserved words are skipped.
+func (g *Random) Generate(_ context.Context) (string, error) {
+	for {
+		code, err := g.code()
+		if err != nil {
+			return "", err
+		}
+
+		if models.ValidateAlias(code) == nil {
+			return code, nil
+		}
+	}
+}
+
+// validateAlphabet checks the alphabet of the codes.
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return ErrAlphabetInvalid
+	}
+
+	for i, r := range alphabet {
+		// символы кода должны быть допустимы и в псевдониме, повторы искажают распределение
+		if !strings.ContainsRune(models.AliasAlphabet, r) || strings.IndexRune(alphabet, r) != i {
+			return ErrAlphabetInvalid
+		}
+	}
+
+	return nil
+}
+
+func (g *Random) code() (string, error) {
+	code := make([]byte, 0, g.length)
+	buf := make([]byte, 2*g.length)
+
+	for len(code) < g.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+
+		for _, b := range buf {
+			if int(b) >= g.limit {
+				continue
+			}
+			code = append(code, g.alphabet[int(b)%len(g.alphabet)])
+			if len(code) == g.length {
+				break
+			}
+		}
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRandom(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		alphabet string
+		wantErr  error
+	}{
+		{name: "defaults"},
+		{name: "custom", length: 12, alphabet: "abc123"},
+		{name: "too short", length: 3, wantErr: ErrLengthInvalid},
+		{name: "too long", length: 33, wantErr: ErrLengthInvalid},
+		{name: "one character", alphabet: "a", wantErr: ErrAlphabetInvalid},
+		{name: "repeated character", alphabet: "abca", wantErr: ErrAlphabetInvalid},
+		{name: "not allowed in alias", alphabet: "ab/", wantErr: ErrAlphabetInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRandom(tt.length, tt.alphabet)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRandom_Generate(t *testing.T) {
+	gen, err := NewRandom(0, "")
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		code, err := gen.Generate(context.Background())
+		require.NoError(t, err)
+		require.Len(t, code, DefaultLength)
+
+		for _, r := range code {
+			require.True(t, strings.ContainsRune(DefaultAlphabet, r), "code %s", code)
+		}
+		seen[code] = struct{}{}
+	}
+
+	// 62^8 вариантов: совпадение среди тысячи кодов практически невозможно
+	assert.Len(t, seen, 1000)
+}
+
+func BenchmarkRandom_Generate(b *testing.B) {
+	gen, err := NewRandom(0, "")
+	require.NoError(b, err)
+
+	for i := 0; i < b.N; i++ {
+		_, _ = gen.Generate(context.Background())
+	}
+}
@@ -0,0 +1,25 @@
+package codegen
+
+import (
+	"context"
+	"fmt"
+)
+
+func ExampleRandom_Generate() {
+	gen, err := NewRandom(8, "")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	code, err := gen.Generate(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(len(code))
+
+	// Output:
+	// 8
+}
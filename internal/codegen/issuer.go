@@ -0,0 +1,132 @@
+package codegen
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// DefaultAttempts how many codes are tried before giving up.
+const DefaultAttempts = 5
+
+// ErrCodesExhausted every attempt generated a taken code.
+var ErrCodesExhausted = errors.New("cannot generate a free code")
+
+// Issuer - structure describing the saving of the new short links with the generated codes.
+//
+// When the storage reports a taken code the code is generated again, up to the attempts.
+// The links with the code set by the user (alias) are saved once.
+type Issuer struct {
+	gen        CodeGenerator
+	attempts   int
+	generated  atomic.Uint64
+	collisions atomic.Uint64
+	exhausted  atomic.Uint64
+}
+
+// NewIssuer constructor creates the Issuer generating the codes with gen.
+func NewIssuer(gen CodeGenerator, attempts int) *Issuer {
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+
+	return &Issuer{gen: gen, attempts: attempts}
+}
+
+// Save saves the link, generating its code unless it is set. The short URL of the code is built by shortURL.
+func (i *Issuer) Save(ctx context.Context, repo repository.Storage, link models.ShortLink, shortURL func(code string) string) (*models.ShortLink, error) {
+	if len(link.Code) > 0 {
+		link.ShortURL = shortURL(link.Code)
+		return &link, repo.Save(ctx, link)
+	}
+
+	for attempt := 0; attempt < i.attempts; attempt++ {
+		code, err := i.generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		link.Code, link.ShortURL = code, shortURL(code)
+
+		err = repo.Save(ctx, link)
+		if !errors.Is(err, repository.ErrCodeConflict) {
+			return &link, err
+		}
+		i.collisions.Add(1)
+	}
+
+	i.exhausted.Add(1)
+
+	return nil, ErrCodesExhausted
+}
+
+// InsertBatch inserts the links generating their codes, the codes and short URLs are set in links.
+// The storage inserts the batch at once, so on a taken code all the codes are generated again.
+func (i *Issuer) InsertBatch(ctx context.Context, repo repository.Storage, links []models.ShortLink, shortURL func(code string) string) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	for attempt := 0; attempt < i.attempts; attempt++ {
+		codes := make(map[string]struct{}, len(links))
+		for n := range links {
+			code, err := i.distinct(ctx, codes)
+			if err != nil {
+				return err
+			}
+			links[n].Code, links[n].ShortURL = code, shortURL(code)
+		}
+
+		err := repo.InsertBatch(ctx, links)
+		if !errors.Is(err, repository.ErrCodeConflict) {
+			return err
+		}
+		i.collisions.Add(1)
+	}
+
+	i.exhausted.Add(1)
+
+	return ErrCodesExhausted
+}
+
+// Stats the counters of the generated codes.
+func (i *Issuer) Stats() models.CodeStats {
+	return models.CodeStats{
+		Generated:  i.generated.Load(),
+		Collisions: i.collisions.Load(),
+		Exhausted:  i.exhausted.Load(),
+	}
+}
+
+// distinct generates the code missing from the codes of the batch, the storage may not notice
+// the same code twice in one batch.
+func (i *Issuer) distinct(ctx context.Context, codes map[string]struct{}) (string, error) {
+	for attempt := 0; attempt < i.attempts; attempt++ {
+		code, err := i.generate(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if _, ok := codes[code]; !ok {
+			codes[code] = struct{}{}
+			return code, nil
+		}
+		i.collisions.Add(1)
+	}
+
+	i.exhausted.Add(1)
+
+	return "", ErrCodesExhausted
+}
+
+func (i *Issuer) generate(ctx context.Context) (string, error) {
+	code, err := i.gen.Generate(ctx)
+	if err != nil {
+		return "", err
+	}
+	i.generated.Add(1)
+
+	return code, nil
+}
@@ -0,0 +1,79 @@
+package codegen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	mockStore "github.com/Orendev/shortener/internal/repository/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequence returns the codes in order.
+type sequence []string
+
+func (s *sequence) Generate(_ context.Context) (string, error) {
+	code := (*s)[0]
+	*s = (*s)[1:]
+	return code, nil
+}
+
+func shortURL(code string) string {
+	return "http://localhost/" + code
+}
+
+func TestIssuer_Save(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	gen := &sequence{"taken", "free"}
+	issuer := NewIssuer(gen, 3)
+
+	gomock.InOrder(
+		s.EXPECT().Save(gomock.Any(), gomock.Any()).Return(repository.ErrCodeConflict),
+		s.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	link, err := issuer.Save(ctx, s, models.ShortLink{OriginalURL: "https://practicum.yandex.ru/"}, shortURL)
+	require.NoError(t, err)
+	assert.Equal(t, "free", link.Code)
+	assert.Equal(t, "http://localhost/free", link.ShortURL)
+
+	// псевдоним сохраняется один раз, занятый псевдоним не заменяется кодом
+	s.EXPECT().Save(gomock.Any(), gomock.Any()).Return(repository.ErrCodeConflict)
+	_, err = issuer.Save(ctx, s, models.ShortLink{Code: "alias"}, shortURL)
+	assert.ErrorIs(t, err, repository.ErrCodeConflict)
+
+	*gen = sequence{"a", "b", "c"}
+	s.EXPECT().Save(gomock.Any(), gomock.Any()).Return(repository.ErrCodeConflict).Times(3)
+	_, err = issuer.Save(ctx, s, models.ShortLink{}, shortURL)
+	assert.ErrorIs(t, err, ErrCodesExhausted)
+
+	assert.Equal(t, models.CodeStats{Generated: 5, Collisions: 4, Exhausted: 1}, issuer.Stats())
+}
+
+func TestIssuer_InsertBatch(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	// повтор внутри пачки и занятый в хранилище код генерируются заново
+	gen := &sequence{"a", "a", "b", "c", "d"}
+	issuer := NewIssuer(gen, 3)
+
+	gomock.InOrder(
+		s.EXPECT().InsertBatch(gomock.Any(), gomock.Any()).Return(repository.ErrCodeConflict),
+		s.EXPECT().InsertBatch(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	links := make([]models.ShortLink, 2)
+	require.NoError(t, issuer.InsertBatch(ctx, s, links, shortURL))
+	assert.Equal(t, "c", links[0].Code)
+	assert.Equal(t, "http://localhost/d", links[1].ShortURL)
+
+	assert.Equal(t, models.CodeStats{Generated: 5, Collisions: 2}, issuer.Stats())
+}
@@ -0,0 +1,214 @@
+package codegen
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// feistelRounds the rounds of the permutation of the IDs.
+const feistelRounds = 4
+
+// Errors of the sequential codes.
+var (
+	ErrMinLengthInvalid  = fmt.Errorf("the minimal code length must be 1 to %d", models.AliasMaxLength)
+	ErrSequenceExhausted = errors.New("the ID does not fit into a code")
+	ErrCodeInvalid       = errors.New("the code is not of the sequence")
+)
+
+// Sequence - structure describing the generator turning the increasing IDs of the counter into codes.
+//
+// The IDs are split into blocks by the code length: the first base^minLength IDs get the codes
+// of minLength characters, the next base^(minLength+1) IDs one character longer, and so on.
+// Within the block the ID is permuted by a Feistel network keyed by the salt and written
+// in the alphabet shuffled by the salt. The mapping is a bijection, so the codes do not repeat
+// and grow with the volume, while the neighbouring IDs get unrelated codes.
+type Sequence struct {
+	counter   repository.CodeCounter
+	minLength int
+	alphabet  string
+	key       []byte
+}
+
+// NewSequence constructor creates the Sequence with the codes of at least minLength characters
+// of the alphabet, the empty alphabet is replaced by the default one.
+func NewSequence(counter repository.CodeCounter, minLength int, alphabet, salt string) (*Sequence, error) {
+	if len(alphabet) == 0 {
+		alphabet = DefaultAlphabet
+	}
+
+	if minLength < 1 || minLength > models.AliasMaxLength {
+		return nil, ErrMinLengthInvalid
+	}
+
+	if err := validateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+
+	key := []byte(salt)
+
+	return &Sequence{
+		counter:   counter,
+		minLength: minLength,
+		alphabet:  shuffle(alphabet, key),
+		key:       key,
+	}, nil
+}
+
+// Generate the code of the next ID of the counter, the IDs encoded as the reserved words are skipped.
+func (g *Sequence) Generate(ctx context.Context) (string, error) {
+	for {
+		id, err := g.counter.NextCodeID(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		code, err := g.Encode(id)
+		if err != nil {
+			return "", err
+		}
+
+		if models.ValidateAlias(code) == nil {
+			return code, nil
+		}
+	}
+}
+
+// Encode the code of the ID.
+func (g *Sequence) Encode(id uint64) (string, error) {
+	base := uint64(len(g.alphabet))
+
+	length := g.minLength
+	size, ok := pow(base, length)
+	for ok && id >= size {
+		id -= size
+		length++
+		size, ok = pow(base, length)
+	}
+	if !ok || length > models.AliasMaxLength {
+		return "", ErrSequenceExhausted
+	}
+
+	offset := g.permute(id, size, length, false)
+
+	code := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		code[i] = g.alphabet[offset%base]
+		offset /= base
+	}
+
+	return string(code), nil
+}
+
+// Decode the ID of the code.
+func (g *Sequence) Decode(code string) (uint64, error) {
+	base := uint64(len(g.alphabet))
+
+	length := len(code)
+	size, ok := pow(base, length)
+	if length < g.minLength || !ok {
+		return 0, ErrCodeInvalid
+	}
+
+	var offset uint64
+	for i := 0; i < length; i++ {
+		digit := strings.IndexByte(g.alphabet, code[i])
+		if digit < 0 {
+			return 0, ErrCodeInvalid
+		}
+		offset = offset*base + uint64(digit)
+	}
+
+	id := g.permute(offset, size, length, true)
+
+	// идентификаторы более коротких кодов идут раньше
+	for l := g.minLength; l < length; l++ {
+		below, _ := pow(base, l)
+		id += below
+	}
+
+	return id, nil
+}
+
+// permute the keyed permutation of [0, size) of the codes of the length.
+// The Feistel network permutes the smallest even number of bits covering the size,
+// the values out of the range are permuted again (cycle walking) until they fall into it.
+func (g *Sequence) permute(x, size uint64, length int, inverse bool) uint64 {
+	half := (bits.Len64(size-1) + 1) / 2
+	if half == 0 {
+		half = 1
+	}
+	mask := uint64(1)<<half - 1
+
+	for {
+		left, right := x>>half&mask, x&mask
+
+		for i := 0; i < feistelRounds; i++ {
+			if inverse {
+				round := feistelRounds - 1 - i
+				left, right = right^g.round(length, round, left)&mask, left
+			} else {
+				left, right = right, left^g.round(length, i, right)&mask
+			}
+		}
+
+		x = left<<half | right
+		if x < size {
+			return x
+		}
+	}
+}
+
+// round the round function of the Feistel network.
+func (g *Sequence) round(length, round int, value uint64) uint64 {
+	var msg [10]byte
+	msg[0], msg[1] = byte(length), byte(round)
+	binary.BigEndian.PutUint64(msg[2:], value)
+
+	return prf(g.key, msg[:])
+}
+
+// prf the pseudo random value of the message keyed by the salt.
+func prf(key, msg []byte) uint64 {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg)
+
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+// shuffle the alphabet in the order defined by the salt.
+func shuffle(alphabet string, key []byte) string {
+	chars := []byte(alphabet)
+
+	var msg [9]byte
+	msg[0] = 'a'
+	for i := len(chars) - 1; i > 0; i-- {
+		binary.BigEndian.PutUint64(msg[1:], uint64(i))
+		j := prf(key, msg[:]) % uint64(i+1)
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+
+	return string(chars)
+}
+
+// pow base^exp, false when it overflows.
+func pow(base uint64, exp int) (uint64, bool) {
+	result := uint64(1)
+	for i := 0; i < exp; i++ {
+		if result > math.MaxUint64/base {
+			return 0, false
+		}
+		result *= base
+	}
+
+	return result, true
+}
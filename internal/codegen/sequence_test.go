@@ -0,0 +1,101 @@
+package codegen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence_Encode(t *testing.T) {
+	seq, err := NewSequence(nil, 1, "abc", "salt")
+	require.NoError(t, err)
+
+	// коды длины 1, 2 и 3 занимают идентификаторы 0-2, 3-11 и 12-38
+	seen := make(map[string]struct{})
+	for id := uint64(0); id < 39; id++ {
+		code, err := seq.Encode(id)
+		require.NoError(t, err)
+
+		switch {
+		case id < 3:
+			assert.Len(t, code, 1)
+		case id < 12:
+			assert.Len(t, code, 2)
+		default:
+			assert.Len(t, code, 3)
+		}
+
+		decoded, err := seq.Decode(code)
+		require.NoError(t, err)
+		assert.Equal(t, id, decoded)
+
+		seen[code] = struct{}{}
+	}
+	assert.Len(t, seen, 39)
+
+	_, err = seq.Decode("abz")
+	assert.ErrorIs(t, err, ErrCodeInvalid)
+}
+
+func TestSequence_Salt(t *testing.T) {
+	first, err := NewSequence(nil, 4, "", "first")
+	require.NoError(t, err)
+	second, err := NewSequence(nil, 4, "", "second")
+	require.NoError(t, err)
+
+	differ := 0
+	for id := uint64(1_000_000); id < 1_000_100; id++ {
+		a, err := first.Encode(id)
+		require.NoError(t, err)
+		b, err := second.Encode(id)
+		require.NoError(t, err)
+
+		if a != b {
+			differ++
+		}
+
+		decoded, err := first.Decode(a)
+		require.NoError(t, err)
+		assert.Equal(t, id, decoded)
+	}
+	assert.Greater(t, differ, 90)
+}
+
+func TestSequence_Generate(t *testing.T) {
+	counter, err := memory.NewCodeCounter("")
+	require.NoError(t, err)
+
+	// все 27 кодов длины 3 из этих символов, кроме зарезервированного api
+	seq, err := NewSequence(counter, 3, "aip", "salt")
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 26; i++ {
+		code, err := seq.Generate(context.Background())
+		require.NoError(t, err)
+		require.Len(t, code, 3)
+		seen[code] = struct{}{}
+	}
+	assert.Len(t, seen, 26)
+	assert.NotContains(t, seen, "api")
+}
+
+func TestNew(t *testing.T) {
+	counter, err := memory.NewCodeCounter("")
+	require.NoError(t, err)
+
+	gen, err := New(config.Codes{Strategy: StrategySequence, MinLength: 4}, counter)
+	require.NoError(t, err)
+	assert.IsType(t, &Sequence{}, gen)
+
+	gen, err = New(config.Codes{}, counter)
+	require.NoError(t, err)
+	assert.IsType(t, &Random{}, gen)
+
+	_, err = New(config.Codes{Strategy: "hashids"}, counter)
+	assert.ErrorIs(t, err, ErrStrategyUnknown)
+}
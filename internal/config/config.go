@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"time"
 )
 
 // Server configuration
@@ -19,7 +20,24 @@ type GRPCServer struct {
 
 // File configuration
 type File struct {
-	FileStoragePath string `env:"FILE_STORAGE_PATH"`
+	FileStoragePath string        `env:"FILE_STORAGE_PATH"`
+	SyncPolicy      string        `env:"FILE_SYNC_POLICY"`
+	CompactInterval time.Duration `env:"FILE_COMPACT_INTERVAL"`
+}
+
+// KV configuration of the embedded key-value storage
+type KV struct {
+	KVStoragePath string `env:"KV_STORAGE_PATH"`
+}
+
+// Cache configuration of the storage read cache
+type Cache struct {
+	Type        string        `env:"CACHE_TYPE"`
+	Size        int           `env:"CACHE_SIZE"`
+	Addr        string        `env:"CACHE_ADDRESS"`
+	PoolSize    int           `env:"CACHE_POOL_SIZE"`
+	TTL         time.Duration `env:"CACHE_TTL"`
+	NegativeTTL time.Duration `env:"CACHE_NEGATIVE_TTL"`
 }
 
 // Log configuration
@@ -29,7 +47,70 @@ type Log struct {
 
 // Database configuration
 type Database struct {
-	DatabaseDSN string `env:"DATABASE_DSN"`
+	DatabaseDSN       string        `env:"DATABASE_DSN"`
+	MaxConns          int32         `env:"DATABASE_MAX_CONNS"`
+	MaxConnLifetime   time.Duration `env:"DATABASE_MAX_CONN_LIFETIME"`
+	HealthCheckPeriod time.Duration `env:"DATABASE_HEALTH_CHECK_PERIOD"`
+}
+
+// Expiration configuration of the short links lifetime
+type Expiration struct {
+	NotFound      bool          `env:"EXPIRED_NOT_FOUND"`
+	SweepInterval time.Duration `env:"EXPIRED_SWEEP_INTERVAL"`
+}
+
+// Analytics configuration of the click recording
+type Analytics struct {
+	BufferSize    int           `env:"ANALYTICS_BUFFER_SIZE"`
+	FlushInterval time.Duration `env:"ANALYTICS_FLUSH_INTERVAL"`
+}
+
+// Deletion configuration of the background deletion queue of the user's links
+type Deletion struct {
+	QueueSize     int           `env:"DELETE_QUEUE_SIZE"`
+	BatchSize     int           `env:"DELETE_BATCH_SIZE"`
+	FlushInterval time.Duration `env:"DELETE_FLUSH_INTERVAL"`
+	RetryBackoff  time.Duration `env:"DELETE_RETRY_BACKOFF"`
+}
+
+// JWT configuration of the signing keys of the tokens
+type JWT struct {
+	Secret          string        `env:"JWT_SECRET"`
+	KeysFile        string        `env:"JWT_KEYS_FILE"`
+	TokenExp        time.Duration `env:"JWT_TOKEN_EXP"`
+	RefreshTokenExp time.Duration `env:"JWT_REFRESH_TOKEN_EXP"`
+	// AnonymousTokenExp the lifetime of the tokens issued to the new anonymous users,
+	// they have no refresh token, so their links are kept as long as the token lives.
+	AnonymousTokenExp time.Duration `env:"JWT_ANONYMOUS_TOKEN_EXP"`
+}
+
+// Codes configuration of the generated codes of the short links
+type Codes struct {
+	Strategy  string `env:"CODE_STRATEGY"`
+	Length    int    `env:"CODE_LENGTH"`
+	MinLength int    `env:"CODE_MIN_LENGTH"`
+	Alphabet  string `env:"CODE_ALPHABET"`
+	Salt      string `env:"CODE_SALT"`
+	Attempts  int    `env:"CODE_ATTEMPTS"`
+}
+
+// URLs configuration of the normalization of the original URLs
+type URLs struct {
+	StripTracking bool `env:"URL_STRIP_TRACKING"`
+}
+
+// URLPolicy configuration of the policy of the original URLs
+type URLPolicy struct {
+	File           string        `env:"URL_POLICY_FILE"`
+	ReloadInterval time.Duration `env:"URL_POLICY_RELOAD_INTERVAL"`
+	AllowPrivate   bool          `env:"URL_POLICY_ALLOW_PRIVATE"`
+	Resolve        bool          `env:"URL_POLICY_RESOLVE"`
+}
+
+// Admin configuration of the moderators
+type Admin struct {
+	// UserIDs comma separated IDs of the users allowed to use the admin API.
+	UserIDs string `env:"ADMIN_USER_IDS"`
 }
 
 // Cert configuration
@@ -45,10 +126,21 @@ type Configs struct {
 	GRPC          GRPCServer
 	Cert          Cert
 	File          File
+	KV            KV
+	Cache         Cache
 	Log           Log
+	Expiration    Expiration
+	Analytics     Analytics
+	Deletion      Deletion
+	JWT           JWT
+	Codes         Codes
+	URLs          URLs
+	URLPolicy     URLPolicy
+	Admin         Admin
 	BaseURL       string `env:"BASE_URL"`
 	Config        string `env:"CONFIG"`
 	TrustedSubnet string `env:"TRUSTED_SUBNET"`
+	TrustedProxy  string `env:"TRUSTED_PROXY"`
 }
 
 // FileConfig configuration file
@@ -57,9 +149,16 @@ type FileConfig struct {
 	GRPCAddr        string `json:"grpc_address"`
 	IsHTTPS         bool   `json:"enable_https"`
 	FileStoragePath string `json:"file_storage_path"`
+	KVStoragePath   string `json:"kv_storage_path"`
 	DatabaseDSN     string `json:"database_dsn"`
 	BaseURL         string `json:"base_url"`
 	TrustedSubnet   string `json:"trusted_subnet"`
+	TrustedProxy    string `json:"trusted_proxy"`
+	ExpiredNotFound bool   `json:"expired_not_found"`
+	SweepInterval   string `json:"expired_sweep_interval"`
+	JWTSecret       string `json:"jwt_secret"`
+	JWTKeysFile     string `json:"jwt_keys_file"`
+	JWTTokenExp     string `json:"jwt_token_exp"`
 }
 
 // New constructor a new instance of Configs
@@ -93,12 +192,54 @@ func initFlag(cfg *Configs, fs *flag.FlagSet) error {
 	fs.StringVar(&cfg.BaseURL, "b", "", "Базовый URL localhost:8080")
 	fs.StringVar(&cfg.Log.FlagLogLevel, "ll", "info", "log level")
 	fs.StringVar(&cfg.File.FileStoragePath, "f", "", "Полное имя файла")
+	fs.StringVar(&cfg.File.SyncPolicy, "fsync", "always", "Политика fsync журнала файла: always, interval, never")
+	fs.DurationVar(&cfg.File.CompactInterval, "fci", 5*time.Minute, "Интервал сжатия журнала файла в снимок")
+	fs.StringVar(&cfg.KV.KVStoragePath, "kv", "", "Полное имя файла встроенного key-value хранилища")
+	fs.StringVar(&cfg.Cache.Type, "cache", "", "Кеш хранилища: lru, resp или пусто")
+	fs.IntVar(&cfg.Cache.Size, "cs", 10000, "Максимальное число ключей lru кеша")
+	fs.StringVar(&cfg.Cache.Addr, "ca", "localhost:6379", "Адрес сервера кеша с протоколом RESP")
+	fs.IntVar(&cfg.Cache.PoolSize, "cps", 10, "Размер пула соединений с сервером кеша")
+	fs.DurationVar(&cfg.Cache.TTL, "ct", 5*time.Minute, "Время жизни ссылки в кеше")
+	fs.DurationVar(&cfg.Cache.NegativeTTL, "cnt", 30*time.Second, "Время жизни в кеше отсутствия ссылки")
 	fs.StringVar(&cfg.Cert.KeyFile, "fc", "key.pem", "Закрытый ключ")
 	fs.StringVar(&cfg.Cert.CertFile, "fk", "cert.pem", "Подписанный центром сертификации, файл сертификата")
 	fs.StringVar(&cfg.Database.DatabaseDSN, "d", "", "Строка с адресом подключения")
+	fs.Func("dmc", "Максимальный размер пула соединений с базой данных", func(v string) error {
+		maxConns, err := strconv.ParseInt(v, 10, 32)
+		cfg.Database.MaxConns = int32(maxConns)
+		return err
+	})
+	fs.DurationVar(&cfg.Database.MaxConnLifetime, "dml", 0, "Максимальное время жизни соединения с базой данных")
+	fs.DurationVar(&cfg.Database.HealthCheckPeriod, "dhc", 0, "Период проверки соединений с базой данных")
 	fs.StringVar(&cfg.TrustedSubnet, "t", "", "Строковое представление бесклассовой адресации")
+	fs.StringVar(&cfg.TrustedProxy, "tp", "", "Подсеть прокси, которым доверяется x-real-ip")
 	fs.StringVar(&cfg.Config, "c", "", "Файл конфигурации")
 	fs.BoolVar(&cfg.Server.IsHTTPS, "s", false, "Включения HTTPS в веб-сервере.")
+	fs.BoolVar(&cfg.Expiration.NotFound, "en", false, "Отвечать 404 вместо 410 на истёкшие ссылки")
+	fs.DurationVar(&cfg.Expiration.SweepInterval, "ei", time.Minute, "Интервал фоновой очистки истёкших ссылок")
+	fs.IntVar(&cfg.Analytics.BufferSize, "ab", 1024, "Размер буфера переходов по ссылкам")
+	fs.DurationVar(&cfg.Analytics.FlushInterval, "af", time.Second, "Интервал записи переходов по ссылкам")
+	fs.IntVar(&cfg.Deletion.QueueSize, "dqs", 10000, "Максимальное число кодов в очереди на удаление")
+	fs.IntVar(&cfg.Deletion.BatchSize, "dbs", 100, "Число кодов, после которого очередь удаления сбрасывается не дожидаясь интервала")
+	fs.DurationVar(&cfg.Deletion.FlushInterval, "dfi", 2*time.Second, "Интервал сброса очереди удаления в хранилище")
+	fs.DurationVar(&cfg.Deletion.RetryBackoff, "drb", 100*time.Millisecond, "Начальная пауза перед повтором удаления после ошибки хранилища")
+	fs.StringVar(&cfg.JWT.Secret, "js", "", "Секрет подписи токенов HS256")
+	fs.StringVar(&cfg.JWT.KeysFile, "jk", "", "Файл ключей подписи токенов с kid")
+	fs.DurationVar(&cfg.JWT.TokenExp, "jexp", 15*time.Minute, "Время жизни токенов доступа")
+	fs.DurationVar(&cfg.JWT.RefreshTokenExp, "jrexp", 30*24*time.Hour, "Время жизни токенов обновления")
+	fs.DurationVar(&cfg.JWT.AnonymousTokenExp, "jaexp", 30*24*time.Hour, "Время жизни токенов анонимных пользователей")
+	fs.StringVar(&cfg.Codes.Strategy, "cst", "random", "Способ генерации кодов ссылок: random или sequence")
+	fs.IntVar(&cfg.Codes.Length, "cl", 8, "Длина генерируемых кодов ссылок")
+	fs.IntVar(&cfg.Codes.MinLength, "cml", 4, "Минимальная длина последовательных кодов ссылок")
+	fs.StringVar(&cfg.Codes.Alphabet, "calph", "", "Алфавит генерируемых кодов ссылок, по умолчанию base62")
+	fs.StringVar(&cfg.Codes.Salt, "csalt", "", "Соль перестановки последовательных кодов ссылок")
+	fs.IntVar(&cfg.Codes.Attempts, "cr", 5, "Число попыток сгенерировать свободный код")
+	fs.BoolVar(&cfg.URLs.StripTracking, "ust", false, "Удалять из ссылок параметры отслеживания utm_* и подобные")
+	fs.StringVar(&cfg.URLPolicy.File, "upf", "", "Файл списков разрешённых и запрещённых доменов ссылок")
+	fs.DurationVar(&cfg.URLPolicy.ReloadInterval, "upr", 10*time.Second, "Интервал проверки изменений файла списков доменов")
+	fs.BoolVar(&cfg.URLPolicy.AllowPrivate, "upp", false, "Разрешить ссылки на частные, loopback и link-local адреса")
+	fs.BoolVar(&cfg.URLPolicy.Resolve, "upd", true, "Проверять адреса, в которые разрешается домен ссылки")
+	fs.StringVar(&cfg.Admin.UserIDs, "au", "", "ID пользователей-модераторов через запятую")
 	err := fs.Parse(os.Args[1:])
 	if err != nil {
 		return err
@@ -132,10 +273,83 @@ func initEnv(cfg *Configs) error {
 		cfg.File.FileStoragePath = envFileStoragePath
 	}
 
+	if envSyncPolicy := os.Getenv("FILE_SYNC_POLICY"); len(envSyncPolicy) > 0 {
+		cfg.File.SyncPolicy = envSyncPolicy
+	}
+
+	if envCompactInterval := os.Getenv("FILE_COMPACT_INTERVAL"); len(envCompactInterval) > 0 {
+		cfg.File.CompactInterval, err = time.ParseDuration(envCompactInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envKVStoragePath := os.Getenv("KV_STORAGE_PATH"); len(envKVStoragePath) > 0 {
+		cfg.KV.KVStoragePath = envKVStoragePath
+	}
+
+	if envCacheType := os.Getenv("CACHE_TYPE"); len(envCacheType) > 0 {
+		cfg.Cache.Type = envCacheType
+	}
+
+	if envCacheSize := os.Getenv("CACHE_SIZE"); len(envCacheSize) > 0 {
+		cfg.Cache.Size, err = strconv.Atoi(envCacheSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envCacheAddr := os.Getenv("CACHE_ADDRESS"); len(envCacheAddr) > 0 {
+		cfg.Cache.Addr = envCacheAddr
+	}
+
+	if envCachePoolSize := os.Getenv("CACHE_POOL_SIZE"); len(envCachePoolSize) > 0 {
+		cfg.Cache.PoolSize, err = strconv.Atoi(envCachePoolSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envCacheTTL := os.Getenv("CACHE_TTL"); len(envCacheTTL) > 0 {
+		cfg.Cache.TTL, err = time.ParseDuration(envCacheTTL)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envCacheNegativeTTL := os.Getenv("CACHE_NEGATIVE_TTL"); len(envCacheNegativeTTL) > 0 {
+		cfg.Cache.NegativeTTL, err = time.ParseDuration(envCacheNegativeTTL)
+		if err != nil {
+			return err
+		}
+	}
+
 	if envDatabaseDSN := os.Getenv("DATABASE_DSN"); len(envDatabaseDSN) > 0 {
 		cfg.Database.DatabaseDSN = envDatabaseDSN
 	}
 
+	if envMaxConns := os.Getenv("DATABASE_MAX_CONNS"); len(envMaxConns) > 0 {
+		maxConns, err := strconv.ParseInt(envMaxConns, 10, 32)
+		if err != nil {
+			return err
+		}
+		cfg.Database.MaxConns = int32(maxConns)
+	}
+
+	if envMaxConnLifetime := os.Getenv("DATABASE_MAX_CONN_LIFETIME"); len(envMaxConnLifetime) > 0 {
+		cfg.Database.MaxConnLifetime, err = time.ParseDuration(envMaxConnLifetime)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envHealthCheckPeriod := os.Getenv("DATABASE_HEALTH_CHECK_PERIOD"); len(envHealthCheckPeriod) > 0 {
+		cfg.Database.HealthCheckPeriod, err = time.ParseDuration(envHealthCheckPeriod)
+		if err != nil {
+			return err
+		}
+	}
+
 	if envCertFile := os.Getenv("FILE_CERT"); len(envCertFile) > 0 {
 		cfg.Cert.CertFile = envCertFile
 	}
@@ -156,6 +370,164 @@ func initEnv(cfg *Configs) error {
 		cfg.TrustedSubnet = envTrustedSubnet
 	}
 
+	if envTrustedProxy := os.Getenv("TRUSTED_PROXY"); len(envTrustedProxy) > 0 {
+		cfg.TrustedProxy = envTrustedProxy
+	}
+
+	if envExpiredNotFound := os.Getenv("EXPIRED_NOT_FOUND"); len(envExpiredNotFound) > 0 {
+		cfg.Expiration.NotFound, err = strconv.ParseBool(envExpiredNotFound)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envSweepInterval := os.Getenv("EXPIRED_SWEEP_INTERVAL"); len(envSweepInterval) > 0 {
+		cfg.Expiration.SweepInterval, err = time.ParseDuration(envSweepInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envBufferSize := os.Getenv("ANALYTICS_BUFFER_SIZE"); len(envBufferSize) > 0 {
+		cfg.Analytics.BufferSize, err = strconv.Atoi(envBufferSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envFlushInterval := os.Getenv("ANALYTICS_FLUSH_INTERVAL"); len(envFlushInterval) > 0 {
+		cfg.Analytics.FlushInterval, err = time.ParseDuration(envFlushInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envQueueSize := os.Getenv("DELETE_QUEUE_SIZE"); len(envQueueSize) > 0 {
+		cfg.Deletion.QueueSize, err = strconv.Atoi(envQueueSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envBatchSize := os.Getenv("DELETE_BATCH_SIZE"); len(envBatchSize) > 0 {
+		cfg.Deletion.BatchSize, err = strconv.Atoi(envBatchSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envDeleteFlushInterval := os.Getenv("DELETE_FLUSH_INTERVAL"); len(envDeleteFlushInterval) > 0 {
+		cfg.Deletion.FlushInterval, err = time.ParseDuration(envDeleteFlushInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envRetryBackoff := os.Getenv("DELETE_RETRY_BACKOFF"); len(envRetryBackoff) > 0 {
+		cfg.Deletion.RetryBackoff, err = time.ParseDuration(envRetryBackoff)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envJWTSecret := os.Getenv("JWT_SECRET"); len(envJWTSecret) > 0 {
+		cfg.JWT.Secret = envJWTSecret
+	}
+
+	if envJWTKeysFile := os.Getenv("JWT_KEYS_FILE"); len(envJWTKeysFile) > 0 {
+		cfg.JWT.KeysFile = envJWTKeysFile
+	}
+
+	if envJWTTokenExp := os.Getenv("JWT_TOKEN_EXP"); len(envJWTTokenExp) > 0 {
+		cfg.JWT.TokenExp, err = time.ParseDuration(envJWTTokenExp)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envJWTRefreshTokenExp := os.Getenv("JWT_REFRESH_TOKEN_EXP"); len(envJWTRefreshTokenExp) > 0 {
+		cfg.JWT.RefreshTokenExp, err = time.ParseDuration(envJWTRefreshTokenExp)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envJWTAnonymousTokenExp := os.Getenv("JWT_ANONYMOUS_TOKEN_EXP"); len(envJWTAnonymousTokenExp) > 0 {
+		cfg.JWT.AnonymousTokenExp, err = time.ParseDuration(envJWTAnonymousTokenExp)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envCodeStrategy := os.Getenv("CODE_STRATEGY"); len(envCodeStrategy) > 0 {
+		cfg.Codes.Strategy = envCodeStrategy
+	}
+
+	if envCodeLength := os.Getenv("CODE_LENGTH"); len(envCodeLength) > 0 {
+		cfg.Codes.Length, err = strconv.Atoi(envCodeLength)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envCodeMinLength := os.Getenv("CODE_MIN_LENGTH"); len(envCodeMinLength) > 0 {
+		cfg.Codes.MinLength, err = strconv.Atoi(envCodeMinLength)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envCodeAlphabet := os.Getenv("CODE_ALPHABET"); len(envCodeAlphabet) > 0 {
+		cfg.Codes.Alphabet = envCodeAlphabet
+	}
+
+	if envCodeSalt := os.Getenv("CODE_SALT"); len(envCodeSalt) > 0 {
+		cfg.Codes.Salt = envCodeSalt
+	}
+
+	if envCodeAttempts := os.Getenv("CODE_ATTEMPTS"); len(envCodeAttempts) > 0 {
+		cfg.Codes.Attempts, err = strconv.Atoi(envCodeAttempts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envURLStripTracking := os.Getenv("URL_STRIP_TRACKING"); len(envURLStripTracking) > 0 {
+		cfg.URLs.StripTracking, err = strconv.ParseBool(envURLStripTracking)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envURLPolicyFile := os.Getenv("URL_POLICY_FILE"); len(envURLPolicyFile) > 0 {
+		cfg.URLPolicy.File = envURLPolicyFile
+	}
+
+	if envURLPolicyReloadInterval := os.Getenv("URL_POLICY_RELOAD_INTERVAL"); len(envURLPolicyReloadInterval) > 0 {
+		cfg.URLPolicy.ReloadInterval, err = time.ParseDuration(envURLPolicyReloadInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envURLPolicyAllowPrivate := os.Getenv("URL_POLICY_ALLOW_PRIVATE"); len(envURLPolicyAllowPrivate) > 0 {
+		cfg.URLPolicy.AllowPrivate, err = strconv.ParseBool(envURLPolicyAllowPrivate)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envURLPolicyResolve := os.Getenv("URL_POLICY_RESOLVE"); len(envURLPolicyResolve) > 0 {
+		cfg.URLPolicy.Resolve, err = strconv.ParseBool(envURLPolicyResolve)
+		if err != nil {
+			return err
+		}
+	}
+
+	if envAdminUserIDs := os.Getenv("ADMIN_USER_IDS"); len(envAdminUserIDs) > 0 {
+		cfg.Admin.UserIDs = envAdminUserIDs
+	}
+
 	return nil
 }
 
@@ -194,6 +566,9 @@ func initFile(cfg *Configs, fs *flag.FlagSet) error {
 		if len(cfg.TrustedSubnet) == 0 {
 			cfg.TrustedSubnet = fileConfig.TrustedSubnet
 		}
+		if len(cfg.TrustedProxy) == 0 {
+			cfg.TrustedProxy = fileConfig.TrustedProxy
+		}
 
 		enabled := false
 		fs.Visit(func(f *flag.Flag) {
@@ -210,6 +585,34 @@ func initFile(cfg *Configs, fs *flag.FlagSet) error {
 		if len(cfg.File.FileStoragePath) == 0 {
 			cfg.File.FileStoragePath = fileConfig.FileStoragePath
 		}
+
+		if len(cfg.KV.KVStoragePath) == 0 {
+			cfg.KV.KVStoragePath = fileConfig.KVStoragePath
+		}
+
+		if !cfg.Expiration.NotFound && len(os.Getenv("EXPIRED_NOT_FOUND")) == 0 {
+			cfg.Expiration.NotFound = fileConfig.ExpiredNotFound
+		}
+
+		if len(cfg.JWT.Secret) == 0 {
+			cfg.JWT.Secret = fileConfig.JWTSecret
+		}
+		if len(cfg.JWT.KeysFile) == 0 {
+			cfg.JWT.KeysFile = fileConfig.JWTKeysFile
+		}
+		if len(fileConfig.JWTTokenExp) > 0 && !isFlagPassed(fs, "jexp") && len(os.Getenv("JWT_TOKEN_EXP")) == 0 {
+			cfg.JWT.TokenExp, err = time.ParseDuration(fileConfig.JWTTokenExp)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(fileConfig.SweepInterval) > 0 && !isFlagPassed(fs, "ei") && len(os.Getenv("EXPIRED_SWEEP_INTERVAL")) == 0 {
+			cfg.Expiration.SweepInterval, err = time.ParseDuration(fileConfig.SweepInterval)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -227,6 +630,16 @@ func initDefaultValue(cfg *Configs) {
 	cfg.File.FileStoragePath = setValueString(cfg.File.FileStoragePath, "/tmp/short-url-db.json")
 }
 
+func isFlagPassed(fs *flag.FlagSet, name string) bool {
+	passed := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
 func setValueString(value, defaultValue string) string {
 	if len(value) > 0 {
 		return value
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -275,12 +276,30 @@ func TestNew(t *testing.T) {
 			IsHTTPS: true,
 		},
 		BaseURL: "World",
-		File:    File{FileStoragePath: "/tmp/short-url-db.json"},
+		File:    File{FileStoragePath: "/tmp/short-url-db.json", SyncPolicy: "always", CompactInterval: 5 * time.Minute},
 		Cert: Cert{
 			CertFile: "cert.pem",
 			KeyFile:  "key.pem",
 		},
-		Log: Log{FlagLogLevel: "info"},
+		Log:        Log{FlagLogLevel: "info"},
+		Expiration: Expiration{SweepInterval: time.Minute},
+		Analytics:  Analytics{BufferSize: 1024, FlushInterval: time.Second},
+		Deletion: Deletion{
+			QueueSize:     10000,
+			BatchSize:     100,
+			FlushInterval: 2 * time.Second,
+			RetryBackoff:  100 * time.Millisecond,
+		},
+		JWT:       JWT{TokenExp: 15 * time.Minute, RefreshTokenExp: 30 * 24 * time.Hour, AnonymousTokenExp: 30 * 24 * time.Hour},
+		Codes:     Codes{Strategy: "random", Length: 8, MinLength: 4, Attempts: 5},
+		URLPolicy: URLPolicy{ReloadInterval: 10 * time.Second, Resolve: true},
+		Cache: Cache{
+			Size:        10000,
+			Addr:        "localhost:6379",
+			PoolSize:    10,
+			TTL:         5 * time.Minute,
+			NegativeTTL: 30 * time.Second,
+		},
 		Database: Database{
 			DatabaseDSN: "host=localhost user=shortener password=secret dbname=shortener sslmode=disable",
 		},
@@ -0,0 +1,79 @@
+package deletion
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// jobsTTL how long the finished jobs stay available for the status requests.
+const jobsTTL = time.Hour
+
+// jobs - structure describing the registry of the deletion jobs.
+type jobs struct {
+	mu   sync.Mutex
+	jobs map[string]*models.DeleteJob
+}
+
+func newJobs() *jobs {
+	return &jobs{jobs: make(map[string]*models.DeleteJob)}
+}
+
+// add registers the new job, dropping the jobs finished longer than jobsTTL ago.
+func (j *jobs) add(job *models.DeleteJob) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for id, old := range j.jobs {
+		if old.FinishedAt != nil && job.CreatedAt.Sub(*old.FinishedAt) > jobsTTL {
+			delete(j.jobs, id)
+		}
+	}
+
+	j.jobs[job.ID] = job
+}
+
+// get returns a copy of the user's job.
+func (j *jobs) get(id, userID string) (models.DeleteJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok || job.UserID != userID {
+		return models.DeleteJob{}, false
+	}
+
+	return *job, true
+}
+
+// record counts the results of the flushed messages in their jobs.
+func (j *jobs) record(messages []models.Message, deleted []string) {
+	isDeleted := make(map[string]bool, len(deleted))
+	for _, code := range deleted {
+		isDeleted[code] = true
+	}
+
+	now := time.Now().UTC()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, message := range messages {
+		if job, ok := j.jobs[message.JobID]; ok {
+			job.Record(isDeleted[message.Code], now)
+		}
+	}
+}
+
+// retry remembers the storage error in the jobs of the messages left in the queue.
+func (j *jobs) retry(messages []models.Message, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, message := range messages {
+		if job, ok := j.jobs[message.JobID]; ok {
+			job.Retry(err)
+		}
+	}
+}
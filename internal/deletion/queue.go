@@ -0,0 +1,365 @@
+// Package deletion queues the deletions of the user's short links and flushes them to the storage in the background.
+package deletion
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/dedupe"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Defaults used when the configured values are not positive.
+const (
+	defaultQueueSize     = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = 2 * time.Second
+	defaultRetryBackoff  = 100 * time.Millisecond
+)
+
+// flushTimeout limits the time of one attempt to write a batch to the storage.
+const flushTimeout = 5 * time.Second
+
+// maxAttempts the number of attempts to write a batch before it is left for the next flush.
+const maxAttempts = 5
+
+// maxRetryBackoff the limit of the pause between the attempts.
+const maxRetryBackoff = 5 * time.Second
+
+var (
+	// ErrQueueFull the queue has no room for the codes of the request.
+	ErrQueueFull = errors.New("the deletion queue is full")
+	// ErrQueueClosed the queue is draining before the shutdown.
+	ErrQueueClosed = errors.New("the deletion queue is closed")
+)
+
+// Queue - structure describing the bounded queue of the deletions of the user's links.
+//
+// The codes are written to the journal, if any, before Enqueue returns and acked once
+// they are flushed to the storage, so the unflushed codes are queued again after a restart.
+// A batch failing with a storage error is retried with exponential backoff and then
+// left at the head of the queue until the next flush.
+type Queue struct {
+	store         repository.Storage
+	journal       repository.DeletionStorage
+	capacity      int
+	batchSize     int
+	flushInterval time.Duration
+	retryBackoff  time.Duration
+	jobs          *jobs
+
+	mu      sync.Mutex
+	pending []models.Message
+	closed  bool
+	// reserved the places in the queue of the codes being appended to the journal
+	reserved int
+	// appending the enqueues appending to the journal, Close waits for them
+	appending sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+// NewQueue constructor creates the Queue, restores the unflushed codes from the journal
+// and starts its background writer. The journal may be nil.
+func NewQueue(ctx context.Context, store repository.Storage, journal repository.DeletionStorage, cfg config.Deletion) (*Queue, error) {
+	q := &Queue{
+		store:         store,
+		journal:       journal,
+		capacity:      positive(cfg.QueueSize, defaultQueueSize),
+		batchSize:     positive(cfg.BatchSize, defaultBatchSize),
+		flushInterval: positiveDuration(cfg.FlushInterval, defaultFlushInterval),
+		retryBackoff:  positiveDuration(cfg.RetryBackoff, defaultRetryBackoff),
+		jobs:          newJobs(),
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+
+	if journal != nil {
+		pending, err := journal.PendingDeletions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		q.restore(pending)
+	}
+
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+
+	go q.run()
+
+	return q, nil
+}
+
+// Enqueue queues the deletion of the user's codes and returns the job tracking it.
+func (q *Queue) Enqueue(ctx context.Context, userID string, codes []string) (*models.DeleteJob, error) {
+	codes = dedupe.DedupeStrings(codes)
+
+	job := &models.DeleteJob{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    models.DeleteJobPending,
+		Requested: len(codes),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	messages := make([]models.Message, 0, len(codes))
+	for _, code := range codes {
+		messages = append(messages, models.Message{JobID: job.ID, UserID: userID, Code: code})
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil, ErrQueueClosed
+	}
+
+	if len(q.pending)+q.reserved+len(messages) > q.capacity {
+		q.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	// резервируем место и пишем журнал без блокировки, чтобы не задерживать другие запросы и сброс
+	q.reserved += len(messages)
+	q.appending.Add(1)
+	q.mu.Unlock()
+	defer q.appending.Done()
+
+	var err error
+	if q.journal != nil {
+		err = q.journal.AppendDeletions(ctx, messages)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reserved -= len(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(codes) == 0 {
+		job.Record(false, job.CreatedAt)
+	}
+	q.jobs.add(job)
+
+	q.pending = append(q.pending, messages...)
+
+	if len(q.pending) >= q.batchSize {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	result := *job
+
+	return &result, nil
+}
+
+// Job the status of the user's job.
+func (q *Queue) Job(id, userID string) (models.DeleteJob, bool) {
+	return q.jobs.get(id, userID)
+}
+
+// Len the number of the codes waiting to be flushed.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// Close stops accepting codes and flushes the queued ones until ctx is done;
+// the codes left unflushed stay in the journal.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	// дожидаемся кодов, которые уже пишутся в журнал, они сбрасываются вместе с остальными
+	q.appending.Wait()
+
+	// прерываем текущие повторы фонового сброса и дожидаемся его остановки
+	q.cancel()
+	<-q.done
+
+	for q.Len() > 0 {
+		if err := q.flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+
+		for q.Len() > 0 {
+			if err := q.flush(q.ctx); err != nil {
+				break
+			}
+		}
+	}
+}
+
+// flush writes one batch from the head of the queue to the storage.
+func (q *Queue) flush(ctx context.Context) error {
+	q.mu.Lock()
+	n := len(q.pending)
+	if n > q.batchSize {
+		n = q.batchSize
+	}
+	batch := make([]models.Message, n)
+	copy(batch, q.pending[:n])
+	q.mu.Unlock()
+
+	byUser := make(map[string][]models.Message)
+	for _, message := range batch {
+		byUser[message.UserID] = append(byUser[message.UserID], message)
+	}
+
+	var errFlush error
+	flushed := make(map[models.Message]struct{}, len(batch))
+
+	for userID, messages := range byUser {
+		codes := make([]string, 0, len(messages))
+		for _, message := range messages {
+			codes = append(codes, message.Code)
+		}
+
+		deleted, err := q.deleteWithRetry(ctx, codes, userID)
+		if err != nil {
+			logger.Log.Error("cannot delete shortLink", zap.String("userID", userID), zap.Int("count", len(codes)), zap.Error(err))
+			q.jobs.retry(messages, err)
+			errFlush = err
+			continue
+		}
+
+		q.jobs.record(messages, deleted)
+		for _, message := range messages {
+			flushed[message] = struct{}{}
+		}
+	}
+
+	q.remove(flushed)
+
+	if q.journal != nil && len(flushed) > 0 {
+		acked := make([]models.Message, 0, len(flushed))
+		for message := range flushed {
+			acked = append(acked, message)
+		}
+
+		// неотмеченные коды повторно удалятся после перезапуска, удаление идемпотентно
+		if err := q.journal.AckDeletions(ctx, acked); err != nil {
+			logger.Log.Error("cannot ack deletions", zap.Int("count", len(acked)), zap.Error(err))
+		}
+	}
+
+	return errFlush
+}
+
+// deleteWithRetry deletes the codes, retrying on the storage errors with exponential backoff.
+func (q *Queue) deleteWithRetry(ctx context.Context, codes []string, userID string) ([]string, error) {
+	backoff := q.retryBackoff
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, flushTimeout)
+		deleted, err := q.store.DeleteFlagBatch(attemptCtx, codes, userID)
+		cancel()
+
+		if err == nil || attempt == maxAttempts {
+			return deleted, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// remove drops the flushed messages from the queue.
+func (q *Queue) remove(flushed map[models.Message]struct{}) {
+	if len(flushed) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := q.pending[:0]
+	for _, message := range q.pending {
+		if _, ok := flushed[message]; !ok {
+			pending = append(pending, message)
+		}
+	}
+	q.pending = pending
+}
+
+// restore queues the codes read from the journal and recreates their jobs.
+func (q *Queue) restore(messages []models.Message) {
+	now := time.Now().UTC()
+
+	for _, message := range messages {
+		if job, ok := q.jobs.jobs[message.JobID]; ok {
+			job.Requested++
+			continue
+		}
+
+		q.jobs.jobs[message.JobID] = &models.DeleteJob{
+			ID:        message.JobID,
+			UserID:    message.UserID,
+			Status:    models.DeleteJobPending,
+			Requested: 1,
+			CreatedAt: now,
+		}
+	}
+
+	q.pending = append(q.pending, messages...)
+}
+
+func positive(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+func positiveDuration(value, defaultValue time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
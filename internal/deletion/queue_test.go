@@ -0,0 +1,160 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	mockStore "github.com/Orendev/shortener/internal/repository/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnavailable = errors.New("storage unavailable")
+
+func TestQueue_Enqueue(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	store := mockStore.NewMockStorage(ctrl)
+
+	// первая попытка падает, повтор удаляет только свою ссылку пользователя
+	gomock.InOrder(
+		store.EXPECT().DeleteFlagBatch(gomock.Any(), []string{"own", "foreign"}, "user").Return(nil, errUnavailable),
+		store.EXPECT().DeleteFlagBatch(gomock.Any(), []string{"own", "foreign"}, "user").Return([]string{"own"}, nil),
+	)
+
+	q, err := NewQueue(ctx, store, nil, config.Deletion{QueueSize: 3, BatchSize: 2, FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = q.Enqueue(ctx, "user", []string{"a", "b", "c", "d"})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	// набранная пачка сбрасывается, не дожидаясь интервала
+	job, err := q.Enqueue(ctx, "user", []string{"own", "foreign", "own"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Requested)
+
+	require.Eventually(t, func() bool {
+		got, ok := q.Job(job.ID, "user")
+		return ok && got.Status == models.DeleteJobDone
+	}, time.Second, 10*time.Millisecond)
+
+	got, _ := q.Job(job.ID, "user")
+	assert.Equal(t, 1, got.Deleted)
+	assert.Equal(t, 1, got.Rejected)
+	assert.Empty(t, got.Error)
+
+	_, ok := q.Job(job.ID, "another")
+	assert.False(t, ok)
+
+	require.NoError(t, q.Close(ctx))
+
+	_, err = q.Enqueue(ctx, "user", []string{"own"})
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
+
+func TestQueue_Durable(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "short-url-db.json.deletions")
+	cfg := config.Deletion{FlushInterval: time.Hour, RetryBackoff: time.Millisecond}
+
+	ctrl := gomock.NewController(t)
+	failing := mockStore.NewMockStorage(ctrl)
+	failing.EXPECT().DeleteFlagBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errUnavailable).AnyTimes()
+
+	journal, err := memory.NewDeletions(path)
+	require.NoError(t, err)
+
+	q, err := NewQueue(ctx, failing, journal, cfg)
+	require.NoError(t, err)
+
+	job, err := q.Enqueue(ctx, "user", []string{"a", "b"})
+	require.NoError(t, err)
+
+	// хранилище недоступно, коды остаются в журнале
+	closeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Close(closeCtx), errUnavailable)
+	assert.Equal(t, 2, q.Len())
+	require.NoError(t, journal.Close())
+
+	store := mockStore.NewMockStorage(ctrl)
+	store.EXPECT().DeleteFlagBatch(gomock.Any(), gomock.Any(), "user").Return([]string{"a", "b"}, nil)
+
+	journal, err = memory.NewDeletions(path)
+	require.NoError(t, err)
+	defer journal.Close()
+
+	q, err = NewQueue(ctx, store, journal, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, q.Len())
+
+	// задание восстановлено из журнала и завершается при сбросе очереди
+	require.NoError(t, q.Close(ctx))
+
+	got, ok := q.Job(job.ID, "user")
+	require.True(t, ok)
+	assert.Equal(t, models.DeleteJobDone, got.Status)
+	assert.Equal(t, 2, got.Deleted)
+
+	pending, err := journal.PendingDeletions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+// blockingJournal the journal holding the appends until released.
+type blockingJournal struct {
+	appending chan struct{}
+	release   chan struct{}
+}
+
+func (j *blockingJournal) AppendDeletions(_ context.Context, _ []models.Message) error {
+	j.appending <- struct{}{}
+	<-j.release
+	return nil
+}
+
+func (j *blockingJournal) AckDeletions(_ context.Context, _ []models.Message) error {
+	return nil
+}
+
+func (j *blockingJournal) PendingDeletions(_ context.Context) ([]models.Message, error) {
+	return nil, nil
+}
+
+func TestQueue_EnqueueAppendsOutsideLock(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	store := mockStore.NewMockStorage(ctrl)
+	store.EXPECT().DeleteFlagBatch(gomock.Any(), []string{"a", "b"}, "user").Return([]string{"a", "b"}, nil)
+
+	journal := &blockingJournal{appending: make(chan struct{}, 1), release: make(chan struct{})}
+	q, err := NewQueue(ctx, store, journal, config.Deletion{QueueSize: 3, FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.Enqueue(ctx, "user", []string{"a", "b"})
+		done <- err
+	}()
+	<-journal.appending
+
+	// пока журнал пишется, очередь доступна, а место кодов зарезервировано
+	assert.Equal(t, 0, q.Len())
+	_, err = q.Enqueue(ctx, "user", []string{"c", "d"})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	close(journal.release)
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, q.Len())
+
+	require.NoError(t, q.Close(ctx))
+}
@@ -4,48 +4,169 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"time"
 
+	"github.com/Orendev/shortener/internal/analytics"
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/codegen"
+	"github.com/Orendev/shortener/internal/deletion"
 	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/moderation"
 	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
-	"github.com/Orendev/shortener/internal/random"
 	"github.com/Orendev/shortener/internal/repository"
+	"github.com/Orendev/shortener/internal/urlnorm"
+	"github.com/Orendev/shortener/internal/urlpolicy"
 	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
+// ErrDependencyRequired the required dependency of the GRPC server is not passed.
+var ErrDependencyRequired = errors.New("the grpc server dependency is required")
+
 type GRPC struct {
 	pb.UnimplementedShortenerServiceServer
-	repo          repository.Storage
-	baseURL       string
-	trustedSubnet string
+	repo            repository.Storage
+	baseURL         string
+	expiredNotFound bool
+	clicks          *analytics.Recorder
+	deletes         *deletion.Queue
+	codes           *codegen.Issuer
+	urls            *urlnorm.Normalizer
+	policy          *urlpolicy.Policy
+	moderator       *moderation.Moderator
+}
+
+// Option configures the optional behaviour of the GRPC.
+type Option func(g *GRPC)
+
+// WithExpiredNotFound resolve the expired short links with NotFound instead of FailedPrecondition.
+func WithExpiredNotFound(notFound bool) Option {
+	return func(g *GRPC) {
+		g.expiredNotFound = notFound
+	}
+}
+
+// WithAnalytics record the resolved short links as clicks.
+func WithAnalytics(clicks *analytics.Recorder) Option {
+	return func(g *GRPC) {
+		g.clicks = clicks
+	}
+}
+
+// WithDeletionQueue queue the deletions of the user's links to the queue.
+func WithDeletionQueue(deletes *deletion.Queue) Option {
+	return func(g *GRPC) {
+		g.deletes = deletes
+	}
+}
+
+// WithCodes generate the codes of the new short links with the codes.
+func WithCodes(codes *codegen.Issuer) Option {
+	return func(g *GRPC) {
+		g.codes = codes
+	}
+}
+
+// WithURLNormalizer normalize the original URLs of the new short links with the urls.
+func WithURLNormalizer(urls *urlnorm.Normalizer) Option {
+	return func(g *GRPC) {
+		g.urls = urls
+	}
+}
+
+// WithURLPolicy reject the original URLs of the new short links denied by the policy.
+func WithURLPolicy(policy *urlpolicy.Policy) Option {
+	return func(g *GRPC) {
+		g.policy = policy
+	}
+}
+
+// WithModerator reject the new short links of the banned users with the moderator.
+func WithModerator(moderator *moderation.Moderator) Option {
+	return func(g *GRPC) {
+		g.moderator = moderator
+	}
 }
 
-func NewGRPC(repo repository.Storage, baseURL, trustedSubnet string) *GRPC {
-	return &GRPC{repo: repo, baseURL: baseURL, trustedSubnet: trustedSubnet}
+// NewGRPC constructor creates the GRPC server.
+//
+// The deletion queue, code issuer, URL normalizer, URL policy and moderator are required,
+// without them ErrDependencyRequired is returned.
+func NewGRPC(repo repository.Storage, baseURL string, opts ...Option) (*GRPC, error) {
+	g := &GRPC{repo: repo, baseURL: baseURL}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if g.deletes == nil {
+		return nil, fmt.Errorf("%w: deletion queue", ErrDependencyRequired)
+	}
+
+	if g.codes == nil {
+		return nil, fmt.Errorf("%w: code issuer", ErrDependencyRequired)
+	}
+
+	if g.urls == nil {
+		return nil, fmt.Errorf("%w: URL normalizer", ErrDependencyRequired)
+	}
+
+	if g.policy == nil {
+		return nil, fmt.Errorf("%w: URL policy", ErrDependencyRequired)
+	}
+
+	if g.moderator == nil {
+		return nil, fmt.Errorf("%w: moderator", ErrDependencyRequired)
+	}
+
+	return g, nil
 }
 
 func (g *GRPC) GetAPIUserUrls(ctx context.Context, reg *pb.APIUserUrlsRequest) (*pb.APIUserUrlsResponse, error) {
 	var response pb.APIUserUrlsResponse
 
-	shortLinks, err := g.repo.ShortLinksByUserID(ctx, reg.UserID, 100)
+	userID, err := userIdentifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.NewShortLinksQuery(userID)
+	query.Cursor = reg.Cursor
+	query.Search = reg.Q
+	if reg.Limit != 0 {
+		query.Limit = int(reg.Limit)
+	}
+	if reg.Sort != "" {
+		query.Sort = models.ShortLinkSort(reg.Sort)
+	}
+
+	if err = query.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, err := g.repo.ShortLinksByUserID(ctx, query)
 	if err != nil {
 		return nil, status.Error(codes.NotFound, "shorten url not found")
 	}
 
-	if len(shortLinks) == 0 {
+	if len(page.Links) == 0 {
 		return nil, status.Error(codes.NotFound, "no content")
 	}
 
-	userUrls := make([]*pb.UserUrl, len(shortLinks))
-	for _, model := range shortLinks {
+	userUrls := make([]*pb.UserUrl, 0, len(page.Links))
+	for _, model := range page.Links {
 		userUrls = append(userUrls, &pb.UserUrl{
 			OriginalUrl: model.OriginalURL,
 			ShortUrl:    model.ShortURL,
 		})
 	}
 
+	response.NextCursor = page.NextCursor
 	response.UserUrls = userUrls
 
 	return &response, nil
@@ -71,35 +192,351 @@ func (g *GRPC) GetAPIStats(ctx context.Context, _ *pb.APIStatsRequest) (*pb.APIS
 }
 
 func (g *GRPC) SaveAPIShorten(ctx context.Context, reg *pb.APIShortenRequest) (*pb.APIShortenResponse, error) {
-	var response pb.APIShortenResponse
+	userID, err := userIdentifier(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	code := random.Strn(8)
-	shortLink := &models.ShortLink{
-		UUID:        uuid.New().String(),
-		UserID:      uuid.New().String(),
-		Code:        code,
-		OriginalURL: reg.URL,
-		ShortURL:    fmt.Sprintf("%s/%s", strings.TrimPrefix(g.baseURL, "/"), code),
-		DeletedFlag: false,
+	shortURL, err := g.shorten(ctx, userID, models.ShortLinkRequest{URL: reg.URL, Alias: reg.Alias, TTLSeconds: reg.TtlSeconds}, reg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.APIShortenResponse{Result: shortURL}, nil
+}
+
+// Shorten the equivalent of the plain text POST /.
+func (g *GRPC) Shorten(ctx context.Context, reg *pb.ShortenRequest) (*pb.ShortenResponse, error) {
+	userID, err := userIdentifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shortURL, err := g.shorten(ctx, userID, models.ShortLinkRequest{URL: reg.Url, Alias: reg.Alias, TTLSeconds: reg.TtlSeconds}, reg.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ShortenResponse{ShortUrl: shortURL}, nil
+}
+
+// ShortenBatch the equivalent of POST /api/shorten/batch.
+func (g *GRPC) ShortenBatch(ctx context.Context, reg *pb.ShortenBatchRequest) (*pb.ShortenBatchResponse, error) {
+	userID, err := userIdentifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = g.checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if len(reg.Urls) > models.BatchMaxSize {
+		return nil, invalidArgument("urls", models.ErrBatchTooLarge)
+	}
+
+	reqData := make([]models.ShortLinkBatchRequest, 0, len(reg.Urls))
+	originalURLs := make([]string, 0, len(reg.Urls))
+	for _, in := range reg.Urls {
+		req := models.ShortLinkBatchRequest{
+			CorrelationID: in.CorrelationId,
+			OriginalURL:   in.OriginalUrl,
+			TTLSeconds:    in.TtlSeconds,
+		}
+
+		expiresAt, err := parseExpiresAt(in.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		req.ExpiresAt = expiresAt
+
+		if err = req.Validate(); err != nil {
+			return nil, invalidArgument(fmt.Sprintf("urls[%d]", len(reqData)), err)
+		}
+
+		if req.OriginalURL, err = g.urls.Normalize(req.OriginalURL); err != nil {
+			return nil, invalidArgument(fmt.Sprintf("urls[%d].original_url", len(reqData)), err)
+		}
+
+		reqData = append(reqData, req)
+		originalURLs = append(originalURLs, req.OriginalURL)
+	}
+
+	// домены всей пачки проверяются за одно общее время разрешения
+	if i, err := g.policy.CheckBatch(ctx, userID, originalURLs); err != nil {
+		return nil, invalidArgument(fmt.Sprintf("urls[%d].original_url", i), err)
+	}
+
+	shortLinksInsert := make([]models.ShortLink, 0, len(reqData))
+	shortLinksUpdate := make([]models.ShortLink, 0, len(reqData))
+	urls := make([]*pb.ShortenBatchOut, 0, len(reqData))
+
+	// позиции ответа, короткий URL которых известен только после вставки
+	inserted := make([]int, 0, len(reqData))
+
+	now := time.Now()
+	for i, req := range reqData {
+		model, err := g.repo.GetByID(ctx, req.CorrelationID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, storageError(err)
+		}
+
+		// обновлять по correlation_id можно только свою ссылку
+		if err == nil && model.UserID != userID {
+			return nil, status.Errorf(codes.AlreadyExists, "urls[%d].correlation_id: the correlation_id is taken by another user", i)
+		}
+
+		if err != nil {
+			model = &models.ShortLink{
+				UUID:        req.CorrelationID,
+				UserID:      userID,
+				OriginalURL: req.OriginalURL,
+				ExpiresAt:   req.Expiration(now),
+			}
+
+			inserted = append(inserted, len(urls))
+			shortLinksInsert = append(shortLinksInsert, *model)
+		} else {
+			model.OriginalURL = req.OriginalURL
+			model.DeletedFlag = false
+			model.ExpiresAt = req.Expiration(now)
+			shortLinksUpdate = append(shortLinksUpdate, *model)
+		}
+
+		urls = append(urls, &pb.ShortenBatchOut{
+			CorrelationId: model.UUID,
+			ShortUrl:      model.ShortURL,
+		})
+	}
+
+	if err := g.codes.InsertBatch(ctx, g.repo, shortLinksInsert, g.shortURL); err != nil {
+		return nil, storageError(err)
+	}
+
+	for i, n := range inserted {
+		urls[n].ShortUrl = shortLinksInsert[i].ShortURL
+	}
+
+	if err := g.repo.UpdateBatch(ctx, shortLinksUpdate); err != nil {
+		return nil, storageError(err)
+	}
+
+	return &pb.ShortenBatchResponse{Urls: urls}, nil
+}
+
+// DeleteUserUrls the equivalent of DELETE /api/user/urls, the codes are deleted in the background.
+func (g *GRPC) DeleteUserUrls(ctx context.Context, reg *pb.DeleteUserUrlsRequest) (*pb.DeleteUserUrlsResponse, error) {
+	userID, err := userIdentifier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := g.deletes.Enqueue(ctx, userID, reg.Codes)
+	if errors.Is(err, deletion.ErrQueueFull) || errors.Is(err, deletion.ErrQueueClosed) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "something went wrong")
+	}
+
+	return &pb.DeleteUserUrlsResponse{JobId: job.ID}, nil
+}
+
+// ResolveCode the equivalent of the GET /{id} redirect: the original URL of the short link.
+func (g *GRPC) ResolveCode(ctx context.Context, reg *pb.ResolveCodeRequest) (*pb.ResolveCodeResponse, error) {
+	shortLink, err := g.repo.GetByCode(ctx, reg.Code)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "shorten url not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "something went wrong")
+	}
+
+	if shortLink.IsDisabled() {
+		// Ссылку отключил модератор
+		return nil, status.Error(codes.FailedPrecondition, "the short link is disabled: "+shortLink.DisabledReason)
+	}
+
+	if shortLink.IsExpired(time.Now()) {
+		// Срок жизни ссылки истёк
+		if g.expiredNotFound {
+			return nil, status.Error(codes.NotFound, "shorten url not found")
+		}
+		return nil, status.Error(codes.FailedPrecondition, "the short link is gone")
+	}
+
+	if shortLink.DeletedFlag {
+		// Целевой запрос больше не доступен
+		return nil, status.Error(codes.FailedPrecondition, "the short link is gone")
+	}
+
+	if g.clicks != nil {
+		g.clicks.Record(models.Click{
+			Code:      reg.Code,
+			ClickedAt: time.Now().UTC(),
+			UserAgent: firstMetadata(ctx, "user-agent"),
+			IP:        analytics.AnonymizeIP(peerIP(ctx)),
+		})
+	}
+
+	return &pb.ResolveCodeResponse{OriginalUrl: shortLink.OriginalURL}, nil
+}
+
+// shorten saves the short link like PostShorten and PostAPIShorten do.
+func (g *GRPC) shorten(ctx context.Context, userID string, req models.ShortLinkRequest, expiresAt string) (string, error) {
+	err := g.checkUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if req.ExpiresAt, err = parseExpiresAt(expiresAt); err != nil {
+		return "", err
+	}
+
+	if err = req.Validate(); err != nil {
+		return "", invalidArgument("", err)
+	}
+
+	// совпадающие ссылки сохраняются и ищутся в нормальной форме
+	if req.URL, err = g.urls.Normalize(req.URL); err != nil {
+		return "", invalidArgument("url", err)
+	}
+
+	if err = g.policy.Check(ctx, userID, req.URL); err != nil {
+		return "", invalidArgument("url", err)
 	}
 
-	response.Result = shortLink.ShortURL
 	// Сохраним модель
-	err := g.repo.Save(ctx, *shortLink)
+	shortLink, err := g.codes.Save(ctx, g.repo, models.ShortLink{
+		UUID:        uuid.New().String(),
+		UserID:      userID,
+		Code:        req.Alias,
+		OriginalURL: req.URL,
+		DeletedFlag: false,
+		ExpiresAt:   req.Expiration(time.Now()),
+	}, g.shortURL)
+
+	if errors.Is(err, repository.ErrCodeConflict) {
+		return "", status.Error(codes.AlreadyExists, "the alias is already taken")
+	}
 
 	if err != nil && !errors.Is(err, repository.ErrConflict) {
-		return nil, status.Error(codes.Internal, "something went wrong")
+		return "", status.Error(codes.Internal, "something went wrong")
 	}
 
 	if errors.Is(err, repository.ErrConflict) {
-		shortLink, err = g.repo.GetByOriginalURL(ctx, reg.URL)
+		shortLink, err = g.repo.GetByOriginalURL(ctx, req.URL)
 		if err != nil {
-			return nil, status.Error(codes.Internal, "something went wrong")
+			return "", status.Error(codes.Internal, "something went wrong")
 		}
-		return nil, status.Error(codes.AlreadyExists, shortLink.ShortURL)
+		return "", status.Error(codes.AlreadyExists, shortLink.ShortURL)
 	}
 
-	return &response, nil
+	return shortLink.ShortURL, nil
+}
+
+// shortURL the short URL of the code.
+func (g *GRPC) shortURL(code string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimPrefix(g.baseURL, "/"), code)
+}
+
+// parseExpiresAt parses the RFC 3339 expiration time, nil if it is empty.
+func parseExpiresAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &expiresAt, nil
+}
+
+// userIdentifier the user authenticated by the Auth interceptor.
+func userIdentifier(ctx context.Context) (string, error) {
+	userID, err := auth.GetAuthIdentifier(ctx)
+	if err != nil || userID == "" {
+		return "", status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return userID, nil
+}
+
+// invalidArgument the InvalidArgument status of the rejected field, the details carry
+// the reason code and the field like the structured 400 responses of the HTTP API.
+func invalidArgument(field string, err error) error {
+	reason := "invalid_request"
+	var urlErr *urlnorm.Error
+	if errors.As(err, &urlErr) {
+		reason = urlErr.Code
+	}
+
+	st, detailErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(
+		&errdetails.ErrorInfo{Reason: reason, Domain: "shortener"},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: err.Error()},
+		}},
+	)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return st.Err()
+}
+
+// checkUser the PermissionDenied status of the banned user, the details carry the reason code.
+func (g *GRPC) checkUser(ctx context.Context, userID string) error {
+	err := g.moderator.CheckUser(ctx, userID)
+	if errors.Is(err, moderation.ErrorUserBanned) {
+		st, detailErr := status.New(codes.PermissionDenied, err.Error()).WithDetails(
+			&errdetails.ErrorInfo{Reason: "user_banned", Domain: "shortener"},
+		)
+		if detailErr != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+		return st.Err()
+	}
+	if err != nil {
+		return status.Error(codes.Internal, "something went wrong")
+	}
+
+	return nil
+}
+
+// storageError maps the errors of the batch writes to the status codes.
+func storageError(err error) error {
+	if errors.Is(err, repository.ErrConflict) || errors.Is(err, repository.ErrCodeConflict) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return status.Error(codes.Internal, "something went wrong")
+}
+
+// firstMetadata the first value of the incoming metadata key.
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerIP the IP address of the caller.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
 }
 
 func (g *GRPC) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
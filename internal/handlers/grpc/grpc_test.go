@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/codegen"
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/deletion"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/moderation"
+	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/Orendev/shortener/internal/urlnorm"
+	"github.com/Orendev/shortener/internal/urlpolicy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestGRPC(t *testing.T) *GRPC {
+	t.Helper()
+
+	store, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")})
+	require.NoError(t, err)
+
+	deletes, err := deletion.NewQueue(context.Background(), store, nil, config.Deletion{FlushInterval: time.Hour})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, deletes.Close(context.Background()))
+		require.NoError(t, store.Close())
+	})
+
+	random, err := codegen.NewRandom(0, "")
+	require.NoError(t, err)
+
+	policy, err := urlpolicy.New(config.URLPolicy{})
+	require.NoError(t, err)
+
+	bans, err := memory.NewBans("")
+	require.NoError(t, err)
+	audit, err := memory.NewAudit("")
+	require.NoError(t, err)
+
+	g, err := NewGRPC(store, "http://localhost",
+		WithDeletionQueue(deletes),
+		WithCodes(codegen.NewIssuer(random, 0)),
+		WithURLNormalizer(urlnorm.NewNormalizer(config.URLs{})),
+		WithURLPolicy(policy),
+		WithModerator(moderation.NewModerator(store, bans, audit, config.Admin{})),
+	)
+	require.NoError(t, err)
+
+	return g
+}
+
+func TestNewGRPC_DependencyRequired(t *testing.T) {
+	_, err := NewGRPC(nil, "http://localhost")
+	assert.ErrorIs(t, err, ErrDependencyRequired)
+}
+
+// userContext the context of the call authenticated as userID.
+func userContext(userID string) context.Context {
+	return context.WithValue(context.Background(), auth.JwtUserIDContextKey, userID)
+}
+
+func TestGRPC_Shorten(t *testing.T) {
+	ctx := userContext("user")
+	g := newTestGRPC(t)
+
+	resp, err := g.Shorten(ctx, &pb.ShortenRequest{Url: "https://practicum.yandex.ru/", Alias: "practicum"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/practicum", resp.ShortUrl)
+
+	// повторное сокращение возвращает существующую ссылку
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "https://practicum.yandex.ru/"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Equal(t, "http://localhost/practicum", status.Convert(err).Message())
+
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "https://ya.ru/", TtlSeconds: 60, ExpiresAt: "2030-01-01T00:00:00Z"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// адрес сохраняется в нормальной форме, поэтому повтор в другой записи тоже находится
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "HTTPS://Practicum.Yandex.ru:443"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "javascript:alert(1)"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 2)
+	assert.Equal(t, urlnorm.ErrSchemeNotAllowed.Code, details[0].(*errdetails.ErrorInfo).Reason)
+	assert.Equal(t, "url", details[1].(*errdetails.BadRequest).FieldViolations[0].Field)
+
+	// частные адреса запрещены политикой
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "http://127.0.0.1/admin"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, urlpolicy.ErrPrivateAddress.Code, status.Convert(err).Details()[0].(*errdetails.ErrorInfo).Reason)
+
+	resolved, err := g.ResolveCode(ctx, &pb.ResolveCodeRequest{Code: "practicum"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://practicum.yandex.ru/", resolved.OriginalUrl)
+
+	_, err = g.ResolveCode(ctx, &pb.ResolveCodeRequest{Code: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// без интерцептора Auth пользователь не определён
+	_, err = g.Shorten(context.Background(), &pb.ShortenRequest{Url: "https://go.dev/"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPC_ShortenBatch(t *testing.T) {
+	ctx := userContext("user")
+	g := newTestGRPC(t)
+
+	resp, err := g.ShortenBatch(ctx, &pb.ShortenBatchRequest{
+		Urls: []*pb.ShortenBatchIn{
+			{CorrelationId: "1", OriginalUrl: "https://ya.ru/"},
+			{CorrelationId: "2", OriginalUrl: "https://go.dev/", TtlSeconds: 60},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Urls, 2)
+	assert.Equal(t, "1", resp.Urls[0].CorrelationId)
+
+	// повтор с тем же correlation_id обновляет ссылку, не меняя её код
+	again, err := g.ShortenBatch(ctx, &pb.ShortenBatchRequest{
+		Urls: []*pb.ShortenBatchIn{{CorrelationId: "1", OriginalUrl: "https://ya.ru/search"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, resp.Urls[0].ShortUrl, again.Urls[0].ShortUrl)
+
+	_, err = g.ShortenBatch(ctx, &pb.ShortenBatchRequest{
+		Urls: []*pb.ShortenBatchIn{{CorrelationId: "3", OriginalUrl: "https://ya.ru/", ExpiresAt: "tomorrow"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// чужой пользователь не может перенаправить ссылку по correlation_id
+	_, err = g.ShortenBatch(userContext("another"), &pb.ShortenBatchRequest{
+		Urls: []*pb.ShortenBatchIn{{CorrelationId: "1", OriginalUrl: "https://evil.example/"}},
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	link, err := g.repo.GetByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ya.ru/search", link.OriginalURL)
+}
+
+func TestGRPC_DeleteUserUrls(t *testing.T) {
+	ctx := userContext("user")
+	g := newTestGRPC(t)
+
+	_, err := g.Shorten(ctx, &pb.ShortenRequest{Url: "https://ya.ru/", Alias: "own"})
+	require.NoError(t, err)
+	_, err = g.Shorten(userContext("another"), &pb.ShortenRequest{Url: "https://go.dev/", Alias: "foreign"})
+	require.NoError(t, err)
+
+	resp, err := g.DeleteUserUrls(ctx, &pb.DeleteUserUrlsRequest{Codes: []string{"own", "foreign"}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.JobId)
+
+	// сбрасываем очередь, не дожидаясь интервала
+	require.NoError(t, g.deletes.Close(ctx))
+
+	job, ok := g.deletes.Job(resp.JobId, "user")
+	require.True(t, ok)
+	assert.Equal(t, 1, job.Deleted)
+	assert.Equal(t, 1, job.Rejected)
+
+	_, err = g.ResolveCode(ctx, &pb.ResolveCodeRequest{Code: "own"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = g.ResolveCode(ctx, &pb.ResolveCodeRequest{Code: "foreign"})
+	assert.NoError(t, err)
+
+	_, err = g.DeleteUserUrls(ctx, &pb.DeleteUserUrlsRequest{Codes: []string{"foreign"}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestGRPC_Moderation(t *testing.T) {
+	ctx := userContext("user")
+	g := newTestGRPC(t)
+
+	bans, err := memory.NewBans("")
+	require.NoError(t, err)
+	audit, err := memory.NewAudit("")
+	require.NoError(t, err)
+	g.moderator = moderation.NewModerator(g.repo, bans, audit, config.Admin{UserIDs: "admin"})
+
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "https://phish.example/", Alias: "phish"})
+	require.NoError(t, err)
+
+	_, err = g.moderator.DisableLink(ctx, "admin", "phish", models.DisableLinkRequest{Reason: "phishing"})
+	require.NoError(t, err)
+
+	_, err = g.ResolveCode(ctx, &pb.ResolveCodeRequest{Code: "phish"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "phishing")
+
+	_, err = g.moderator.BanUser(ctx, "admin", "user", models.BanUserRequest{Reason: "phishing"})
+	require.NoError(t, err)
+
+	_, err = g.Shorten(ctx, &pb.ShortenRequest{Url: "https://another.example/"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "user_banned", status.Convert(err).Details()[0].(*errdetails.ErrorInfo).Reason)
+
+	_, err = g.ShortenBatch(ctx, &pb.ShortenBatchRequest{
+		Urls: []*pb.ShortenBatchIn{{CorrelationId: "1", OriginalUrl: "https://another.example/"}},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
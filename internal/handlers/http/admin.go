@@ -0,0 +1,226 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/moderation"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// disabledPage the page served instead of the redirect of the disabled short link.
+var disabledPage = template.Must(template.New("disabled").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}}</title></head>
+<body>
+<h1>{{.Status}}</h1>
+<p>The short link was disabled by the moderator.</p>
+<p>Reason: {{.Reason}}</p>
+</body>
+</html>
+`))
+
+// writeDisabledPage serves the disabled link with 451 Unavailable For Legal Reasons or 410 Gone and the reason page.
+func writeDisabledPage(w http.ResponseWriter, shortLink *models.ShortLink) {
+	status := http.StatusGone
+	if shortLink.DisabledLegal {
+		status = http.StatusUnavailableForLegalReasons
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	err := disabledPage.Execute(w, struct{ Status, Reason string }{
+		Status: strconv.Itoa(status) + " " + http.StatusText(status),
+		Reason: shortLink.DisabledReason,
+	})
+	if err != nil {
+		logger.Log.Error("cannot write the response", zap.Error(err))
+	}
+}
+
+// checkUser rejects the request of the banned user with 403 Forbidden, false when the request is rejected.
+func (h *Handler) checkUser(w http.ResponseWriter, r *http.Request, userID string) bool {
+	err := h.moderator.CheckUser(r.Context(), userID)
+	if errors.Is(err, moderation.ErrorUserBanned) {
+		writeError(w, http.StatusForbidden, codeUserBanned, err)
+		return false
+	}
+	if err != nil {
+		logger.Log.Error("cannot check the ban of the user", zap.String("user_id", userID), zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+// GetAPIAdminLinks the page of the recently created links of all users or of the user_id, the newest first.
+func (h *Handler) GetAPIAdminLinks(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	query, err := parseShortLinksQuery(r, values.Get("user_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if values.Get("sort") == "" {
+		query.Sort = models.SortCreatedAtDesc
+	}
+
+	page, err := h.moderator.RecentLinks(r.Context(), query)
+	if errors.Is(err, models.ErrCursorInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot list the recent links", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.ShortLinksReviewPageResponse{
+		Links:      make([]models.ShortLinkReviewResponse, 0, len(page.Links)),
+		NextCursor: page.NextCursor,
+	}
+	for _, link := range page.Links {
+		response.Links = append(response.Links, models.NewShortLinkReviewResponse(link))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// PostAPIAdminLinkDisable disables the short link with the reason.
+func (h *Handler) PostAPIAdminLinkDisable(w http.ResponseWriter, r *http.Request) {
+	adminID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.DisableLinkRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = req.Validate(); err != nil {
+		writeValidationError(w, "reason", err)
+		return
+	}
+
+	shortLink, err := h.moderator.DisableLink(r.Context(), adminID, chi.URLParam(r, "code"), req)
+	h.writeModeratedLink(w, shortLink, err)
+}
+
+// PostAPIAdminLinkEnable cancels the disabling of the short link.
+func (h *Handler) PostAPIAdminLinkEnable(w http.ResponseWriter, r *http.Request) {
+	adminID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shortLink, err := h.moderator.EnableLink(r.Context(), adminID, chi.URLParam(r, "code"))
+	h.writeModeratedLink(w, shortLink, err)
+}
+
+// writeModeratedLink writes the result of the action on the short link.
+func (h *Handler) writeModeratedLink(w http.ResponseWriter, shortLink *models.ShortLink, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot moderate the link", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.NewShortLinkReviewResponse(*shortLink))
+}
+
+// PostAPIAdminUserBan bans the user from creating the short links.
+func (h *Handler) PostAPIAdminUserBan(w http.ResponseWriter, r *http.Request) {
+	adminID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.BanUserRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = req.Validate(); err != nil {
+		writeValidationError(w, "reason", err)
+		return
+	}
+
+	ban, err := h.moderator.BanUser(r.Context(), adminID, chi.URLParam(r, "id"), req)
+	if errors.Is(err, moderation.ErrorBanAdmin) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot ban the user", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ban)
+}
+
+// DeleteAPIAdminUserBan lifts the ban of the user.
+func (h *Handler) DeleteAPIAdminUserBan(w http.ResponseWriter, r *http.Request) {
+	adminID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.moderator.UnbanUser(r.Context(), adminID, chi.URLParam(r, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot unban the user", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAPIAdminAudit the latest actions of the admins, the newest first.
+func (h *Handler) GetAPIAdminAudit(w http.ResponseWriter, r *http.Request) {
+	limit := models.DefaultPageLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > models.MaxPageLimit {
+			http.Error(w, models.ErrPageLimitInvalid.Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.moderator.AuditLog(r.Context(), limit)
+	if err != nil {
+		logger.Log.Error("cannot read the audit trail", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// PostAPIUserKeys creates the API key of the user, the secret of the key is returned only once.
+func (h *Handler) PostAPIUserKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.APIKeyRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeys.Create(r.Context(), userID, req)
+	if err != nil {
+		logger.Log.Error("cannot create the API key", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, key)
+}
+
+// GetAPIUserKeys the API keys of the user.
+func (h *Handler) GetAPIUserKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeys.List(r.Context(), userID)
+	if err != nil {
+		logger.Log.Error("cannot list the API keys", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// DeleteAPIUserKey revokes the API key of the user.
+func (h *Handler) DeleteAPIUserKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.apiKeys.Revoke(r.Context(), userID, chi.URLParam(r, "id"))
+	if errors.Is(err, auth.ErrorAPIKeyNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot revoke the API key", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
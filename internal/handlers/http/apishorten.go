@@ -1,25 +1,24 @@
 package http
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/Orendev/shortener/internal/auth"
-	"github.com/Orendev/shortener/internal/dedupe"
-	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/codegen"
+	"github.com/Orendev/shortener/internal/deletion"
 	"github.com/Orendev/shortener/internal/models"
-	"github.com/Orendev/shortener/internal/random"
 	"github.com/Orendev/shortener/internal/repository"
-	"github.com/Orendev/shortener/internal/utils"
 	"github.com/google/uuid"
-	"go.uber.org/zap"
 )
 
+// headerNextCursor the header with the cursor of the next page of the user's links.
+const headerNextCursor = "X-Next-Cursor"
+
 // PostAPIShorten save the link and return the short link.
 func (h *Handler) PostAPIShorten(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -33,6 +32,10 @@ func (h *Handler) PostAPIShorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.checkUser(w, r, userID) {
+		return
+	}
+
 	var req models.ShortLinkRequest
 	dec := json.NewDecoder(r.Body)
 	// читаем тело запроса и декодируем
@@ -42,24 +45,42 @@ func (h *Handler) PostAPIShorten(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err = req.Validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, "", err)
+		return
+	}
+
+	// совпадающие ссылки сохраняются и ищутся в нормальной форме
+	if req.URL, err = h.urls.Normalize(req.URL); err != nil {
+		writeValidationError(w, "url", err)
+		return
+	}
+
+	if err = h.policy.Check(r.Context(), userID, req.URL); err != nil {
+		writeValidationError(w, "url", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
-	code := random.Strn(8)
-	shortLink := &models.ShortLink{
+	// Сохраним модель
+	shortLink, err := h.codes.Save(r.Context(), h.repo, models.ShortLink{
 		UUID:        uuid.New().String(),
 		UserID:      userID,
-		Code:        code,
+		Code:        req.Alias,
 		OriginalURL: req.URL,
-		ShortURL:    fmt.Sprintf("%s/%s", strings.TrimPrefix(h.baseURL, "/"), code),
 		DeletedFlag: false,
+		ExpiresAt:   req.Expiration(time.Now()),
+	}, h.shortURL)
+
+	if errors.Is(err, repository.ErrCodeConflict) {
+		http.Error(w, "the alias is already taken", http.StatusConflict)
+		return
 	}
 
-	// Сохраним модель
-	err = h.repo.Save(r.Context(), *shortLink)
+	if errors.Is(err, codegen.ErrCodesExhausted) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	if err != nil && !errors.Is(err, repository.ErrConflict) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -121,28 +142,72 @@ func (h *Handler) PostAPIShortenBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, req := range reqData {
-		code := random.Strn(8)
+	if !h.checkUser(w, r, userID) {
+		return
+	}
+
+	if len(reqData) > models.BatchMaxSize {
+		writeValidationError(w, "", models.ErrBatchTooLarge)
+		return
+	}
+
+	originalURLs := make([]string, 0, len(reqData))
+	for i := range reqData {
+		if err = reqData[i].Validate(); err != nil {
+			writeValidationError(w, fmt.Sprintf("[%d]", i), err)
+			return
+		}
+
+		if reqData[i].OriginalURL, err = h.urls.Normalize(reqData[i].OriginalURL); err != nil {
+			writeValidationError(w, fmt.Sprintf("[%d].original_url", i), err)
+			return
+		}
+		originalURLs = append(originalURLs, reqData[i].OriginalURL)
+	}
+
+	// домены всей пачки проверяются за одно общее время разрешения
+	if i, err := h.policy.CheckBatch(r.Context(), userID, originalURLs); err != nil {
+		writeValidationError(w, fmt.Sprintf("[%d].original_url", i), err)
+		return
+	}
+
+	// позиции ответа, короткий URL которых известен только после вставки
+	inserted := make([]int, 0, len(reqData))
+
+	now := time.Now()
+	for i, req := range reqData {
 		var model *models.ShortLink
 
 		model, err = h.repo.GetByID(r.Context(), req.CorrelationID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// обновлять по correlation_id можно только свою ссылку
+		if err == nil && model.UserID != userID {
+			writeError(w, http.StatusConflict, codeCorrelationIDTaken,
+				fmt.Errorf("[%d].correlation_id: %w", i, errCorrelationIDTaken))
+			return
+		}
 
 		if err != nil {
 			model = &models.ShortLink{
 				UUID:        req.CorrelationID,
 				UserID:      userID,
-				Code:        code,
 				OriginalURL: req.OriginalURL,
-				ShortURL:    fmt.Sprintf("%s/%s", strings.TrimPrefix(h.baseURL, "/"), code),
 				DeletedFlag: false,
+				ExpiresAt:   req.Expiration(now),
 			}
 
+			inserted = append(inserted, len(shortLinkBatchResponse))
 			shortLinksInsert = append(shortLinksInsert, *model)
 
 		} else {
 
 			model.OriginalURL = req.OriginalURL
 			model.DeletedFlag = false
+			model.ExpiresAt = req.Expiration(now)
 			shortLinksUpdate = append(shortLinksUpdate, *model)
 		}
 
@@ -154,12 +219,20 @@ func (h *Handler) PostAPIShortenBatch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Сохраним модель
-	err = h.repo.InsertBatch(r.Context(), shortLinksInsert)
+	err = h.codes.InsertBatch(r.Context(), h.repo, shortLinksInsert, h.shortURL)
+	if errors.Is(err, codegen.ErrCodesExhausted) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	for i, n := range inserted {
+		shortLinkBatchResponse[n].ShortURL = shortLinksInsert[i].ShortURL
+	}
+
 	err = h.repo.UpdateBatch(r.Context(), shortLinksUpdate)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -206,30 +279,34 @@ func (h *Handler) DeleteAPIUserUrls(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	go func() {
-		for _, code := range reqData {
-			h.msgDeleteUserUrlsChan <- models.Message{
-				UserID: userID,
-				Code:   code,
-			}
-		}
-	}()
+	job, err := h.deletes.Enqueue(r.Context(), userID, reqData)
+	if errors.Is(err, deletion.ErrQueueFull) || errors.Is(err, deletion.ErrQueueClosed) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	// Запрос получен, но еще не обработан
-	w.WriteHeader(http.StatusAccepted)
+	statusURL := "/api/user/urls/delete-jobs/" + job.ID
+	w.Header().Set("Location", statusURL)
 
+	// Запрос получен, но еще не обработан
+	writeJSON(w, http.StatusAccepted, models.DeleteJobResponse{JobID: job.ID, StatusURL: statusURL})
 }
 
 // GetAPIUserUrls we will get the user's links.
+//
+// The response is the array of the links of the page, the cursor of the next page
+// is returned in the X-Next-Cursor header.
 func (h *Handler) GetAPIUserUrls(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	limit := 100
 	w.Header().Set("Content-Type", "application/json")
-	shortLinkUserResponse := make([]models.ShortLinkUserResponse, 0, limit)
 
 	userID, err := auth.GetAuthIdentifier(r.Context())
 	if err != nil {
@@ -237,25 +314,37 @@ func (h *Handler) GetAPIUserUrls(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shortLinks, err := h.repo.ShortLinksByUserID(r.Context(), userID, limit)
+	query, err := parseShortLinksQuery(r, userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if len(shortLinks) == 0 {
+
+	page, err := h.repo.ShortLinksByUserID(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(page.Links) == 0 {
 		w.WriteHeader(http.StatusNoContent)
+		return
 	}
 
-	for _, model := range shortLinks {
+	response := make([]models.ShortLinkUserResponse, 0, len(page.Links))
+	for _, model := range page.Links {
 		// заполняем модель ответа
-		shortLinkUserResponse = append(shortLinkUserResponse, models.ShortLinkUserResponse{
+		response = append(response, models.ShortLinkUserResponse{
 			OriginalURL: model.OriginalURL,
 			ShortURL:    model.ShortURL,
 		})
 	}
 
-	// заполняем модель ответа
-	enc, err := json.Marshal(shortLinkUserResponse)
+	// тело остаётся массивом ссылок, курсор следующей страницы передаётся в заголовке
+	if page.NextCursor != "" {
+		w.Header().Set(headerNextCursor, page.NextCursor)
+	}
+
+	enc, err := json.Marshal(response)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -270,26 +359,33 @@ func (h *Handler) GetAPIUserUrls(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetAPIStats statistics on the short link service.
-func (h Handler) GetAPIStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+// parseShortLinksQuery reads the page of the listing from the cursor, limit, sort and q query parameters.
+func parseShortLinksQuery(r *http.Request, userID string) (models.ShortLinksQuery, error) {
+	values := r.URL.Query()
+	query := models.NewShortLinksQuery(userID)
 
-	ip, err := utils.ResolveIP(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	query.Cursor = values.Get("cursor")
+	query.Search = values.Get("q")
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return query, models.ErrPageLimitInvalid
+		}
+		query.Limit = n
 	}
 
-	check, err := utils.CidrRangeContains(h.trustedSubnet, ip.String())
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if sort := values.Get("sort"); sort != "" {
+		query.Sort = models.ShortLinkSort(sort)
 	}
-	if !check {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+
+	return query, query.Validate()
+}
+
+// GetAPIStats statistics on the short link service, the access is checked by the TrustedSubnet middleware.
+func (h Handler) GetAPIStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
@@ -308,6 +404,14 @@ func (h Handler) GetAPIStats(w http.ResponseWriter, r *http.Request) {
 		Users: users,
 	}
 
+	if cached, ok := h.repo.(repository.CacheStatsProvider); ok {
+		cacheStats := cached.CacheStats()
+		stats.Cache = &cacheStats
+	}
+
+	codeStats := h.codes.Stats()
+	stats.Codes = &codeStats
+
 	// заполняем модель ответа
 	enc, err := json.Marshal(stats)
 	if err != nil {
@@ -324,36 +428,3 @@ func (h Handler) GetAPIStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 }
-
-func (h *Handler) flushDeleteShortLink() {
-
-	messages := make(map[string][]string)
-
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case message := <-h.msgDeleteUserUrlsChan:
-			messages[message.UserID] = dedupe.DedupeStrings(append(messages[message.UserID], message.Code))
-		case <-ticker.C:
-			if len(messages) == 0 {
-				continue
-			}
-
-			for userID, codes := range messages {
-				err := h.repo.DeleteFlagBatch(context.Background(), codes, userID)
-				if err != nil {
-					logger.Log.Error("cannot delete shortLink", zap.Error(err))
-					continue
-				}
-			}
-
-			// почистим срез сообщений на удаления
-			for k := range messages {
-				delete(messages, k)
-			}
-		}
-	}
-
-}
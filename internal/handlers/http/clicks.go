@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// Default number of buckets in the click statistics when the range is not specified.
+const (
+	defaultHourBuckets = 24
+	defaultDayBuckets  = 30
+)
+
+// GetAPIUserURLStats click statistics of the user's short link.
+func (h *Handler) GetAPIUserURLStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.clicks == nil {
+		http.Error(w, "analytics is disabled", http.StatusNotFound)
+		return
+	}
+
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	code := chi.URLParam(r, "code")
+
+	// статистика доступна только владельцу ссылки
+	shortLink, err := h.repo.GetByCode(r.Context(), code)
+	if err != nil || shortLink.UserID != userID {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+
+	interval := models.StatsIntervalHour
+	if v := query.Get("interval"); v != "" {
+		interval = models.StatsInterval(v)
+	}
+	if err = interval.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	buckets := defaultHourBuckets
+	if interval == models.StatsIntervalDay {
+		buckets = defaultDayBuckets
+	}
+	from := interval.Truncate(to.Add(-time.Duration(buckets-1) * interval.Duration()))
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		http.Error(w, models.ErrStatsRangeEmpty.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store := h.clicks.Store()
+
+	total, err := store.ClicksTotal(r.Context(), code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	series, err := store.ClicksSeries(r.Context(), code, interval, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// заполняем модель ответа
+	enc, err := json.Marshal(models.ClickStatsResponse{
+		Code:     code,
+		Total:    total,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Buckets:  series,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(enc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
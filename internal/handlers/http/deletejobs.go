@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// GetAPIDeleteJob the status of the user's background deletion job.
+func (h *Handler) GetAPIDeleteJob(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	job, ok := h.deletes.Job(chi.URLParam(r, "id"), userID)
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	enc, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(enc)
+	if err != nil {
+		logger.Log.Error("cannot write the response", zap.Error(err))
+	}
+}
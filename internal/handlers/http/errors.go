@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/urlnorm"
+)
+
+// Codes of the structured error responses.
+const (
+	// codeInvalidRequest the code of the rejected requests without a more specific reason.
+	codeInvalidRequest = "invalid_request"
+	// codeUserBanned the user is banned from creating the links.
+	codeUserBanned = "user_banned"
+	// codeCorrelationIDTaken the correlation_id of the batch is the link of another user.
+	codeCorrelationIDTaken = "correlation_id_taken"
+)
+
+// errCorrelationIDTaken the correlation_id of the batch belongs to the link of another user.
+var errCorrelationIDTaken = errors.New("the correlation_id is taken by another user")
+
+// writeValidationError writes the structured 400 Bad Request response of the rejected field.
+func writeValidationError(w http.ResponseWriter, field string, err error) {
+	detail := models.ErrorDetail{Code: codeInvalidRequest, Field: field, Message: err.Error()}
+
+	var urlErr *urlnorm.Error
+	if errors.As(err, &urlErr) {
+		detail.Code = urlErr.Code
+	}
+
+	writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: detail})
+}
+
+// writeError writes the structured error response with the code.
+func writeError(w http.ResponseWriter, status int, code string, err error) {
+	writeJSON(w, status, models.ErrorResponse{Error: models.ErrorDetail{Code: code, Message: err.Error()}})
+}
@@ -1,22 +1,152 @@
 package http
 
 import (
-	"github.com/Orendev/shortener/internal/models"
+	"errors"
+	"fmt"
+
+	"github.com/Orendev/shortener/internal/analytics"
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/codegen"
+	"github.com/Orendev/shortener/internal/deletion"
+	"github.com/Orendev/shortener/internal/moderation"
 	"github.com/Orendev/shortener/internal/repository"
+	"github.com/Orendev/shortener/internal/urlnorm"
+	"github.com/Orendev/shortener/internal/urlpolicy"
 )
 
+// ErrDependencyRequired the required dependency of the Handler is not passed.
+var ErrDependencyRequired = errors.New("the handler dependency is required")
+
 // Handler - structure describing the handler
 type Handler struct {
-	repo                  repository.Storage
-	baseURL               string
-	trustedSubnet         string
-	msgDeleteUserUrlsChan chan models.Message
-}
-
-// NewHandler конструктор создает структуру Handler
-func NewHandler(repo repository.Storage, baseURL, trustedSubnet string) Handler {
-	instance := Handler{repo: repo, baseURL: baseURL, msgDeleteUserUrlsChan: make(chan models.Message, 10), trustedSubnet: trustedSubnet}
-	// запустим горутину с фоновым удалением пользовательских ссылок
-	go instance.flushDeleteShortLink()
-	return instance
+	repo            repository.Storage
+	baseURL         string
+	expiredNotFound bool
+	clicks          *analytics.Recorder
+	deletes         *deletion.Queue
+	sessions        *auth.Sessions
+	apiKeys         *auth.APIKeys
+	accounts        *auth.Accounts
+	codes           *codegen.Issuer
+	urls            *urlnorm.Normalizer
+	policy          *urlpolicy.Policy
+	moderator       *moderation.Moderator
+}
+
+// Option configures the optional behaviour of the Handler.
+type Option func(h *Handler)
+
+// WithExpiredNotFound respond with 404 Not Found instead of 410 Gone to the expired short links.
+func WithExpiredNotFound(notFound bool) Option {
+	return func(h *Handler) {
+		h.expiredNotFound = notFound
+	}
+}
+
+// WithAnalytics record the redirects and serve the click statistics.
+func WithAnalytics(clicks *analytics.Recorder) Option {
+	return func(h *Handler) {
+		h.clicks = clicks
+	}
+}
+
+// WithDeletionQueue queue the deletions of the user's links to the queue.
+func WithDeletionQueue(deletes *deletion.Queue) Option {
+	return func(h *Handler) {
+		h.deletes = deletes
+	}
+}
+
+// WithSessions store the refresh tokens of the login, refresh and logout requests with the sessions.
+func WithSessions(sessions *auth.Sessions) Option {
+	return func(h *Handler) {
+		h.sessions = sessions
+	}
+}
+
+// WithAPIKeys manage the API keys of the users with the keys.
+func WithAPIKeys(keys *auth.APIKeys) Option {
+	return func(h *Handler) {
+		h.apiKeys = keys
+	}
+}
+
+// WithAccounts register and login the accounts of the users with the accounts.
+func WithAccounts(accounts *auth.Accounts) Option {
+	return func(h *Handler) {
+		h.accounts = accounts
+	}
+}
+
+// WithCodes generate the codes of the new short links with the codes.
+func WithCodes(codes *codegen.Issuer) Option {
+	return func(h *Handler) {
+		h.codes = codes
+	}
+}
+
+// WithURLNormalizer normalize the original URLs of the new and changed short links with the urls.
+func WithURLNormalizer(urls *urlnorm.Normalizer) Option {
+	return func(h *Handler) {
+		h.urls = urls
+	}
+}
+
+// WithURLPolicy reject the original URLs of the new and changed short links denied by the policy.
+func WithURLPolicy(policy *urlpolicy.Policy) Option {
+	return func(h *Handler) {
+		h.policy = policy
+	}
+}
+
+// WithModerator serve the admin API and reject the links of the banned users with the moderator.
+func WithModerator(moderator *moderation.Moderator) Option {
+	return func(h *Handler) {
+		h.moderator = moderator
+	}
+}
+
+// NewHandler конструктор создает структуру Handler.
+//
+// The deletion queue, sessions, API keys, accounts, code issuer, URL normalizer, URL policy
+// and moderator are required, without them ErrDependencyRequired is returned.
+func NewHandler(repo repository.Storage, baseURL string, opts ...Option) (Handler, error) {
+	instance := Handler{repo: repo, baseURL: baseURL}
+	for _, opt := range opts {
+		opt(&instance)
+	}
+
+	if instance.deletes == nil {
+		return Handler{}, fmt.Errorf("%w: deletion queue", ErrDependencyRequired)
+	}
+
+	if instance.sessions == nil {
+		return Handler{}, fmt.Errorf("%w: sessions", ErrDependencyRequired)
+	}
+
+	if instance.apiKeys == nil {
+		return Handler{}, fmt.Errorf("%w: API keys", ErrDependencyRequired)
+	}
+
+	if instance.accounts == nil {
+		return Handler{}, fmt.Errorf("%w: accounts", ErrDependencyRequired)
+	}
+
+	if instance.codes == nil {
+		return Handler{}, fmt.Errorf("%w: code issuer", ErrDependencyRequired)
+	}
+
+	if instance.urls == nil {
+		return Handler{}, fmt.Errorf("%w: URL normalizer", ErrDependencyRequired)
+	}
+
+	if instance.policy == nil {
+		return Handler{}, fmt.Errorf("%w: URL policy", ErrDependencyRequired)
+	}
+
+	if instance.moderator == nil {
+		return Handler{}, fmt.Errorf("%w: moderator", ErrDependencyRequired)
+	}
+
+	return instance, nil
 }
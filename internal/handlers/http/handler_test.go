@@ -2,16 +2,31 @@ package http_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Orendev/shortener/internal/analytics"
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/codegen"
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/deletion"
 	http2 "github.com/Orendev/shortener/internal/handlers/http"
 	http3 "github.com/Orendev/shortener/internal/middlewares/http"
 	"github.com/Orendev/shortener/internal/models"
-	"github.com/Orendev/shortener/internal/random"
+	"github.com/Orendev/shortener/internal/moderation"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/Orendev/shortener/internal/repository/memory"
 	"github.com/Orendev/shortener/internal/repository/mock"
+	"github.com/Orendev/shortener/internal/urlnorm"
+	"github.com/Orendev/shortener/internal/urlpolicy"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -19,6 +34,72 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// newCode the random code of the test link.
+func newCode(t *testing.T) string {
+	t.Helper()
+
+	gen, err := codegen.NewRandom(0, "")
+	require.NoError(t, err)
+	code, err := gen.Generate(context.Background())
+	require.NoError(t, err)
+
+	return code
+}
+
+// newTestHandler the handler with the in-memory dependencies, the opts replace them.
+func newTestHandler(t *testing.T, repo repository.Storage, opts ...http2.Option) http2.Handler {
+	t.Helper()
+
+	deletes, err := deletion.NewQueue(context.Background(), repo, nil, config.Deletion{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, deletes.Close(context.Background()))
+	})
+
+	sessions := auth.NewSessions(memory.NewSessions(), time.Hour)
+
+	keys, err := memory.NewAPIKeys("")
+	require.NoError(t, err)
+
+	users, err := memory.NewUsers("")
+	require.NoError(t, err)
+
+	random, err := codegen.NewRandom(0, "")
+	require.NoError(t, err)
+
+	policy, err := urlpolicy.New(config.URLPolicy{})
+	require.NoError(t, err)
+
+	bans, err := memory.NewBans("")
+	require.NoError(t, err)
+	audit, err := memory.NewAudit("")
+	require.NoError(t, err)
+
+	defaults := []http2.Option{
+		http2.WithDeletionQueue(deletes),
+		http2.WithSessions(sessions),
+		http2.WithAPIKeys(auth.NewAPIKeys(keys)),
+		http2.WithAccounts(auth.NewAccounts(users, repo, sessions)),
+		http2.WithCodes(codegen.NewIssuer(random, 0)),
+		http2.WithURLNormalizer(urlnorm.NewNormalizer(config.URLs{})),
+		http2.WithURLPolicy(policy),
+		http2.WithModerator(moderation.NewModerator(repo, bans, audit, config.Admin{})),
+	}
+
+	h, err := http2.NewHandler(repo, "http://localhost", append(defaults, opts...)...)
+	require.NoError(t, err)
+
+	return h
+}
+
+func TestNewHandler_DependencyRequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	_, err := http2.NewHandler(s, "http://localhost")
+	assert.ErrorIs(t, err, http2.ErrDependencyRequired)
+}
+
 func TestHandler_GetShorten(t *testing.T) {
 
 	// создадим конроллер моков и экземпляр мок-хранилища
@@ -26,7 +107,7 @@ func TestHandler_GetShorten(t *testing.T) {
 	s := mockStore.NewMockStorage(ctrl)
 
 	// определим, какой результат будем получать от «хранилища»
-	code := random.Strn(8)
+	code := newCode(t)
 	// определим, какой результат будем получать от «хранилища»
 	model := models.ShortLink{
 		UUID:        uuid.New().String(),
@@ -41,7 +122,7 @@ func TestHandler_GetShorten(t *testing.T) {
 		Return(&model, nil)
 
 	// создадим экземпляр приложения и передадим ему «хранилище»
-	h := http2.NewHandler(s, "http://localhost", "192.168.1.0/24")
+	h := newTestHandler(t, s)
 
 	srv := httptest.NewServer(http.HandlerFunc(h.GetShorten))
 	defer srv.Close()
@@ -90,6 +171,69 @@ func TestHandler_GetShorten(t *testing.T) {
 	}
 }
 
+func TestHandler_GetShortenExpired(t *testing.T) {
+	// создадим конроллер моков и экземпляр мок-хранилища
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	code := newCode(t)
+	expiresAt := time.Now().Add(-time.Minute)
+
+	// ссылка, срок жизни которой уже истёк
+	model := models.ShortLink{
+		UUID:        uuid.New().String(),
+		Code:        code,
+		ShortURL:    "http://localhost/" + code,
+		OriginalURL: "https://practicum.yandex.ru/",
+		ExpiresAt:   &expiresAt,
+	}
+
+	s.EXPECT().
+		GetByCode(gomock.Any(), gomock.Any()).
+		Return(&model, nil).
+		AnyTimes()
+
+	tests := []struct {
+		name         string
+		opts         []http2.Option
+		expectedCode int
+	}{
+		{
+			name:         "expired link gone",
+			expectedCode: http.StatusGone,
+		},
+		{
+			name:         "expired link not found",
+			opts:         []http2.Option{http2.WithExpiredNotFound(true)},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, s, tt.opts...)
+			srv := httptest.NewServer(http.HandlerFunc(h.GetShorten))
+			defer srv.Close()
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/"+code, nil)
+			require.NoError(t, err)
+
+			resp, err := srv.Client().Transport.RoundTrip(req)
+			require.NoError(t, err)
+
+			defer func() {
+				err := resp.Body.Close()
+				if err != nil {
+					require.NoError(t, err)
+				}
+			}()
+
+			assert.Empty(t, resp.Header.Get("Location"))
+			assert.Equal(t, tt.expectedCode, resp.StatusCode, "code didn't match expected")
+		})
+	}
+}
+
 func TestHandler_PostShorten(t *testing.T) {
 
 	// создадим конроллер моков и экземпляр мок-хранилища
@@ -103,7 +247,7 @@ func TestHandler_PostShorten(t *testing.T) {
 		Return(nil)
 
 	// создадим экземпляр приложения и передадим ему «хранилище»
-	h := http2.NewHandler(s, "http://localhost", "192.168.1.0/24")
+	h := newTestHandler(t, s)
 
 	r := chi.NewRouter()
 	r.Use(http3.Auth)
@@ -196,7 +340,7 @@ func TestHandler_PostAPIShorten(t *testing.T) {
 		Return(nil)
 
 	// создадим экземпляр приложения и передадим ему «хранилище»
-	h := http2.NewHandler(s, "http://localhost", "192.168.1.0/24")
+	h := newTestHandler(t, s)
 	r := chi.NewRouter()
 	r.Use(http3.Auth)
 	r.Post("/api/shorten", h.PostAPIShorten)
@@ -309,6 +453,101 @@ func TestHandler_PostAPIShorten(t *testing.T) {
 	}
 }
 
+func TestHandler_PostAPIShortenAlias(t *testing.T) {
+
+	// создадим конроллер моков и экземпляр мок-хранилища
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	// первый alias свободен, второй уже занят
+	gomock.InOrder(
+		s.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(nil),
+		s.EXPECT().
+			Save(gomock.Any(), gomock.Any()).
+			Return(repository.ErrCodeConflict),
+	)
+
+	h := newTestHandler(t, s)
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Post("/api/shorten", h.PostAPIShorten)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	type want struct {
+		expectedCode int
+		expectedBody string
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want want
+	}{
+		{
+			name: "alias success",
+			body: `{"url":"https://practicum.yandex.ru/","alias":"q3-report"}`,
+			want: want{
+				expectedCode: http.StatusCreated,
+				expectedBody: `{"result":"http://localhost/q3-report"}`,
+			},
+		},
+		{
+			name: "alias taken",
+			body: `{"url":"https://practicum.yandex.ru/q4","alias":"q3-report"}`,
+			want: want{
+				expectedCode: http.StatusConflict,
+				expectedBody: "the alias is already taken",
+			},
+		},
+		{
+			name: "alias reserved",
+			body: `{"url":"https://practicum.yandex.ru/","alias":"api"}`,
+			want: want{
+				expectedCode: http.StatusBadRequest,
+				expectedBody: "the alias is reserved",
+			},
+		},
+		{
+			name: "alias invalid chars",
+			body: `{"url":"https://practicum.yandex.ru/","alias":"q3/report"}`,
+			want: want{
+				expectedCode: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/shorten", bytes.NewReader([]byte(tt.body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+
+			defer func() {
+				err = resp.Body.Close()
+				if err != nil {
+					require.NoError(t, err)
+				}
+			}()
+
+			assert.Equal(t, tt.want.expectedCode, resp.StatusCode, "code didn't match expected")
+
+			if tt.want.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Contains(t, string(body), tt.want.expectedBody)
+			}
+		})
+	}
+}
+
 func TestHandler_PostAPIShortenBatch(t *testing.T) {
 
 	// создадим конроллер моков и экземпляр мок-хранилища
@@ -316,22 +555,19 @@ func TestHandler_PostAPIShortenBatch(t *testing.T) {
 	s := mockStore.NewMockStorage(ctrl)
 
 	// определим, какой результат будем получать от «хранилища»
-	code := random.Strn(8)
+	code := newCode(t)
 	id := uuid.New().String()
+	userID := uuid.New().String()
 	// определим, какой результат будем получать от «хранилища»
 	model := models.ShortLink{
 		UUID:        id,
+		UserID:      userID,
 		Code:        code,
 		ShortURL:    "http://localhost/" + code,
 		OriginalURL: "https://practicum.yandex.ru/",
 	}
 
-	// определим, какой результат будем получать от «хранилища»
-	// установим условие: при любом вызове метода Save возвращать uuid без ошибки
-	s.EXPECT().
-		InsertBatch(gomock.Any(), gomock.Any()).
-		Return(nil)
-
+	// ссылка уже существует, поэтому она обновляется, а пустая вставка в хранилище не отправляется
 	s.EXPECT().
 		UpdateBatch(gomock.Any(), gomock.Any()).
 		Return(nil)
@@ -341,7 +577,7 @@ func TestHandler_PostAPIShortenBatch(t *testing.T) {
 		Return(&model, nil)
 
 	// создадим экземпляр приложения и передадим ему «хранилище»
-	h := http2.NewHandler(s, "http://localhost", "192.168.1.0/24")
+	h := newTestHandler(t, s)
 
 	r := chi.NewRouter()
 	r.Use(http3.Auth)
@@ -350,6 +586,10 @@ func TestHandler_PostAPIShortenBatch(t *testing.T) {
 	srv := httptest.NewServer(r)
 	defer srv.Close()
 
+	// ссылка обновляется своим пользователем
+	signed, err := http3.NewSigner(context.WithValue(context.Background(), auth.JwtUserIDContextKey, userID))
+	require.NoError(t, err)
+
 	type want struct {
 		expectedCode int
 		expectedBody string
@@ -387,6 +627,7 @@ func TestHandler_PostAPIShortenBatch(t *testing.T) {
 			if len(tt.body) > 0 {
 				req.Header.Set("Content-Type", "application/json")
 			}
+			req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+signed.Value(auth.JwtContextKey).(string))
 
 			resp, err := srv.Client().Do(req)
 			require.NoError(t, err)
@@ -413,13 +654,57 @@ func TestHandler_PostAPIShortenBatch(t *testing.T) {
 	}
 }
 
+func TestHandler_PostAPIShortenBatch_Ownership(t *testing.T) {
+	store, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")})
+	require.NoError(t, err)
+	defer store.Close()
+
+	h := newTestHandler(t, store)
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Post("/api/shorten/batch", h.PostAPIShortenBatch)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	post := func(userID, body string) *http.Response {
+		signed, err := http3.NewSigner(context.WithValue(context.Background(), auth.JwtUserIDContextKey, userID))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/shorten/batch", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+signed.Value(auth.JwtContextKey).(string))
+
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	correlationID := uuid.New().String()
+	resp := post("owner", `[{"correlation_id":"`+correlationID+`","original_url":"https://ya.ru/"}]`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// чужой пользователь не может перенаправить ссылку по correlation_id
+	resp = post("another", `[{"correlation_id":"`+correlationID+`","original_url":"https://evil.example/"}]`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	link, err := store.GetByID(context.Background(), correlationID)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", link.UserID)
+	assert.Equal(t, "https://ya.ru/", link.OriginalURL)
+}
+
 func TestHandler_GetAPIUserUrls(t *testing.T) {
 	// создадим конроллер моков и экземпляр мок-хранилища
 	ctrl := gomock.NewController(t)
 	s := mockStore.NewMockStorage(ctrl)
 
 	// определим, какой результат будем получать от «хранилища»
-	code := random.Strn(8)
+	code := newCode(t)
 	id := uuid.New().String()
 	userID := uuid.New().String()
 	// определим, какой результат будем получать от «хранилища»
@@ -435,11 +720,11 @@ func TestHandler_GetAPIUserUrls(t *testing.T) {
 	shortLinks = append(shortLinks, model)
 	// определим, какой результат будем получать от «хранилища»
 	s.EXPECT().
-		ShortLinksByUserID(gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(shortLinks, nil)
+		ShortLinksByUserID(gomock.Any(), gomock.Any()).
+		Return(&models.ShortLinksPage{Links: shortLinks, NextCursor: "next"}, nil)
 
 	// создадим экземпляр приложения и передадим ему «хранилище»
-	h := http2.NewHandler(s, "http://localhost", "192.168.1.0/24")
+	h := newTestHandler(t, s)
 
 	r := chi.NewRouter()
 	r.Use(http3.Auth)
@@ -452,10 +737,12 @@ func TestHandler_GetAPIUserUrls(t *testing.T) {
 		expectedCode int
 		expectedBody string
 		contentType  string
+		nextCursor   string
 	}
 
 	type args struct {
 		userID string
+		query  string
 	}
 
 	tests := []struct {
@@ -469,18 +756,53 @@ func TestHandler_GetAPIUserUrls(t *testing.T) {
 			method: http.MethodGet,
 			args: args{
 				userID: userID,
+				query:  "?limit=10&sort=-created_at&q=yandex",
 			},
 			want: want{
 				contentType:  "application/json",
 				expectedCode: http.StatusOK,
-				expectedBody: `[{"original_url": "https://practicum.yandex.ru/", "short_url":"http://localhost/.*"}]`,
+				expectedBody: `^\[\{"original_url":"https://practicum.yandex.ru/","short_url":"http://localhost/.*"\}\]$`,
+				nextCursor:   "next",
+			},
+		},
+		{
+			name:   "unknown sort",
+			method: http.MethodGet,
+			args: args{
+				userID: userID,
+				query:  "?sort=code",
+			},
+			want: want{
+				expectedCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:   "limit out of range",
+			method: http.MethodGet,
+			args: args{
+				userID: userID,
+				query:  "?limit=0",
+			},
+			want: want{
+				expectedCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:   "invalid cursor",
+			method: http.MethodGet,
+			args: args{
+				userID: userID,
+				query:  "?cursor=garbage",
+			},
+			want: want{
+				expectedCode: http.StatusBadRequest,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var bodyReader io.Reader
-			req, err := http.NewRequest(tt.method, srv.URL+"/api/user/urls", bodyReader)
+			req, err := http.NewRequest(tt.method, srv.URL+"/api/user/urls"+tt.args.query, bodyReader)
 			require.NoError(t, err)
 
 			resp, err := srv.Client().Do(req)
@@ -504,6 +826,7 @@ func TestHandler_GetAPIUserUrls(t *testing.T) {
 				}
 				assert.Regexp(t, tt.want.expectedBody, string(body))
 			}
+			assert.Equal(t, tt.want.nextCursor, resp.Header.Get("X-Next-Cursor"))
 		})
 	}
 }
@@ -518,7 +841,7 @@ func TestHandler_GetPing(t *testing.T) {
 		Ping(gomock.Any()).
 		Return(nil)
 
-	h := http2.NewHandler(s, "http://localhost", "192.168.1.0/24")
+	h := newTestHandler(t, s)
 
 	r := chi.NewRouter()
 	r.Use(http3.Auth)
@@ -567,3 +890,814 @@ func TestHandler_GetPing(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetAPIUserURLStats(t *testing.T) {
+	// создадим конроллер моков и экземпляр мок-хранилища
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	code := newCode(t)
+	userID := uuid.New().String()
+
+	model := models.ShortLink{
+		UUID:        uuid.New().String(),
+		UserID:      userID,
+		Code:        code,
+		ShortURL:    "http://localhost/" + code,
+		OriginalURL: "https://practicum.yandex.ru/",
+	}
+
+	s.EXPECT().
+		GetByCode(gomock.Any(), code).
+		Return(&model, nil).
+		AnyTimes()
+
+	clicks := memory.NewAnalytics()
+	err := clicks.SaveClicks(context.Background(), []models.Click{
+		{Code: code, ClickedAt: time.Now().UTC()},
+		{Code: code, ClickedAt: time.Now().UTC()},
+	})
+	require.NoError(t, err)
+
+	recorder := analytics.NewRecorder(clicks, 10, time.Second)
+	defer recorder.Close()
+
+	h := newTestHandler(t, s, http2.WithAnalytics(recorder))
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Get("/api/user/urls/{code}/stats", h.GetAPIUserURLStats)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		userID       string
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "owner",
+			userID:       userID,
+			expectedCode: http.StatusOK,
+			expectedBody: `"total":2,"interval":"hour"`,
+		},
+		{
+			name:         "owner daily",
+			userID:       userID,
+			query:        "?interval=day",
+			expectedCode: http.StatusOK,
+			expectedBody: `"interval":"day"`,
+		},
+		{
+			name:         "unknown interval",
+			userID:       userID,
+			query:        "?interval=week",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "empty range",
+			userID:       userID,
+			query:        "?from=2023-09-02T00:00:00Z&to=2023-09-01T00:00:00Z",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: models.ErrStatsRangeEmpty.Error(),
+		},
+		{
+			name:         "another user",
+			userID:       uuid.New().String(),
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := http3.NewSigner(context.WithValue(context.Background(), auth.JwtUserIDContextKey, tt.userID))
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/user/urls/"+code+"/stats"+tt.query, nil)
+			require.NoError(t, err)
+			req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+ctx.Value(auth.JwtContextKey).(string))
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+
+			defer func() {
+				err = resp.Body.Close()
+				if err != nil {
+					require.NoError(t, err)
+				}
+			}()
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode, "code didn't match expected")
+
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestHandler_UserURL(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")})
+	require.NoError(t, err)
+	defer store.Close()
+
+	userID := uuid.New().String()
+	model := models.ShortLink{
+		UUID:        uuid.New().String(),
+		UserID:      userID,
+		Code:        "4rSPg8ap",
+		ShortURL:    "http://localhost/4rSPg8ap",
+		OriginalURL: "https://practicum.yandex.ru/",
+	}
+	require.NoError(t, store.Save(ctx, model))
+	require.NoError(t, store.Save(ctx, models.ShortLink{
+		UUID:        uuid.New().String(),
+		UserID:      userID,
+		Code:        "taken",
+		OriginalURL: "https://ya.ru/",
+	}))
+
+	h := newTestHandler(t, store)
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Get("/api/user/urls/{code}", h.GetAPIUserURL)
+	r.Patch("/api/user/urls/{code}", h.PatchAPIUserURL)
+	r.Post("/api/user/urls/{code}/restore", h.PostAPIUserURLRestore)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	// шаги выполняются последовательно и зависят друг от друга
+	tests := []struct {
+		name         string
+		userID       string
+		method       string
+		path         string
+		body         string
+		before       func()
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "get",
+			userID:       userID,
+			method:       http.MethodGet,
+			path:         "/api/user/urls/4rSPg8ap",
+			expectedCode: http.StatusOK,
+			expectedBody: `"original_url":"https://practicum.yandex.ru/","is_deleted":false`,
+		},
+		{
+			name:         "get another user",
+			userID:       uuid.New().String(),
+			method:       http.MethodGet,
+			path:         "/api/user/urls/4rSPg8ap",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "get missing",
+			userID:       userID,
+			method:       http.MethodGet,
+			path:         "/api/user/urls/missing",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "patch",
+			userID:       userID,
+			method:       http.MethodPatch,
+			path:         "/api/user/urls/4rSPg8ap",
+			body:         `{"url":"https://go.dev/"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `"original_url":"https://go.dev/"`,
+		},
+		{
+			name:         "patch empty url",
+			userID:       userID,
+			method:       http.MethodPatch,
+			path:         "/api/user/urls/4rSPg8ap",
+			body:         `{"url":""}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "patch taken url",
+			userID:       userID,
+			method:       http.MethodPatch,
+			path:         "/api/user/urls/4rSPg8ap",
+			body:         `{"url":"https://ya.ru/"}`,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "patch another user",
+			userID:       uuid.New().String(),
+			method:       http.MethodPatch,
+			path:         "/api/user/urls/4rSPg8ap",
+			body:         `{"url":"https://evil.example/"}`,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:   "patch deleted",
+			userID: userID,
+			method: http.MethodPatch,
+			path:   "/api/user/urls/4rSPg8ap",
+			body:   `{"url":"https://go.dev/doc/"}`,
+			before: func() {
+				_, err := store.DeleteFlagBatch(ctx, []string{"4rSPg8ap"}, userID)
+				require.NoError(t, err)
+			},
+			expectedCode: http.StatusGone,
+		},
+		{
+			name:         "restore another user",
+			userID:       uuid.New().String(),
+			method:       http.MethodPost,
+			path:         "/api/user/urls/4rSPg8ap/restore",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "restore",
+			userID:       userID,
+			method:       http.MethodPost,
+			path:         "/api/user/urls/4rSPg8ap/restore",
+			expectedCode: http.StatusOK,
+			expectedBody: `"original_url":"https://go.dev/","is_deleted":false`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before()
+			}
+
+			ctx, err := http3.NewSigner(context.WithValue(context.Background(), auth.JwtUserIDContextKey, tt.userID))
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+ctx.Value(auth.JwtContextKey).(string))
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode, "code didn't match expected")
+
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteAPIUserUrls(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")})
+	require.NoError(t, err)
+	defer store.Close()
+
+	userID := uuid.New().String()
+	anotherID := uuid.New().String()
+	require.NoError(t, store.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: userID, Code: "own", OriginalURL: "https://ya.ru/"},
+		{UUID: uuid.New().String(), UserID: anotherID, Code: "foreign", OriginalURL: "https://go.dev/"},
+	}))
+
+	h := newTestHandler(t, store)
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Delete("/api/user/urls", h.DeleteAPIUserUrls)
+	r.Get("/api/user/urls/delete-jobs/{id}", h.GetAPIDeleteJob)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	do := func(userID, method, path, body string) *http.Response {
+		signed, err := http3.NewSigner(context.WithValue(context.Background(), auth.JwtUserIDContextKey, userID))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+signed.Value(auth.JwtContextKey).(string))
+
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	resp := do(userID, http.MethodDelete, "/api/user/urls", `["own","foreign","missing","own"]`)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	accepted := models.DeleteJobResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accepted))
+	assert.Equal(t, accepted.StatusURL, resp.Header.Get("Location"))
+
+	// чужое задание не видно
+	resp = do(anotherID, http.MethodGet, accepted.StatusURL, "")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	job := models.DeleteJob{}
+	require.Eventually(t, func() bool {
+		resp := do(userID, http.MethodGet, accepted.StatusURL, "")
+		defer resp.Body.Close()
+
+		return resp.StatusCode == http.StatusOK &&
+			json.NewDecoder(resp.Body).Decode(&job) == nil &&
+			job.Status != models.DeleteJobPending
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, models.DeleteJobDone, job.Status)
+	assert.Equal(t, 3, job.Requested)
+	assert.Equal(t, 1, job.Deleted)
+	assert.Equal(t, 2, job.Rejected)
+
+	own, err := store.GetByCode(ctx, "own")
+	require.NoError(t, err)
+	assert.True(t, own.DeletedFlag)
+
+	foreign, err := store.GetByCode(ctx, "foreign")
+	require.NoError(t, err)
+	assert.False(t, foreign.DeletedFlag)
+}
+
+func TestHandler_DeleteAPIUserUrls_QueueFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	deletes, err := deletion.NewQueue(context.Background(), s, nil, config.Deletion{QueueSize: 1, FlushInterval: time.Hour})
+	require.NoError(t, err)
+
+	h := newTestHandler(t, s, http2.WithDeletionQueue(deletes))
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Delete("/api/user/urls", h.DeleteAPIUserUrls)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL+"/api/user/urls", strings.NewReader(`["a","b"]`))
+	require.NoError(t, err)
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestHandler_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	users, err := memory.NewUsers("")
+	require.NoError(t, err)
+	sessions := auth.NewSessions(memory.NewSessions(), time.Hour)
+
+	h := newTestHandler(t, s,
+		http2.WithSessions(sessions),
+		http2.WithAccounts(auth.NewAccounts(users, s, sessions)),
+	)
+
+	r := chi.NewRouter()
+	r.Post("/api/auth/refresh", h.PostAPIAuthRefresh)
+	r.Post("/api/auth/logout", h.PostAPIAuthLogout)
+	r.With(http3.Auth).Post("/api/auth/login", h.PostAPIAuthLogin)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	post := func(path, body string) (*http.Response, models.TokenPair) {
+		resp, err := srv.Client().Post(srv.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var pair models.TokenPair
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&pair))
+		}
+		return resp, pair
+	}
+
+	resp, login := post("/api/auth/login", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, login.RefreshToken)
+
+	resp, refreshed := post("/api/auth/refresh", `{"refresh_token":"`+login.RefreshToken+`"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// токены обоих обменов принадлежат одному пользователю
+	loginUser, err := auth.ParseToken(login.AccessToken)
+	require.NoError(t, err)
+	refreshedUser, err := auth.ParseToken(refreshed.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, loginUser, refreshedUser)
+
+	resp, _ = post("/api/auth/logout", `{"refresh_token":"`+refreshed.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = post("/api/auth/refresh", `{"refresh_token":"`+refreshed.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = post("/api/auth/refresh", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_APIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	keyStore, err := memory.NewAPIKeys("")
+	require.NoError(t, err)
+	h := newTestHandler(t, s, http2.WithAPIKeys(auth.NewAPIKeys(keyStore)))
+
+	r := chi.NewRouter()
+	r.Post("/api/user/keys", h.PostAPIUserKeys)
+	r.Get("/api/user/keys", h.GetAPIUserKeys)
+	r.Delete("/api/user/keys/{id}", h.DeleteAPIUserKey)
+
+	ctx := context.WithValue(context.Background(), auth.JwtUserIDContextKey, "user")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)).WithContext(ctx))
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/user/keys", `{"name":"ci","scopes":["create"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created models.APIKeyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.NotEmpty(t, created.Key)
+
+	w = do(http.MethodPost, "/api/user/keys", `{"name":"ci","scopes":["admin"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// секрет и его хеш в списке ключей не возвращаются
+	w = do(http.MethodGet, "/api/user/keys", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Key)
+	assert.NotContains(t, w.Body.String(), "hash")
+
+	w = do(http.MethodDelete, "/api/user/keys/"+created.ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(http.MethodDelete, "/api/user/keys/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_Accounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	users, err := memory.NewUsers("")
+	require.NoError(t, err)
+	sessions := auth.NewSessions(memory.NewSessions(), time.Hour)
+
+	h := newTestHandler(t, s,
+		http2.WithSessions(sessions),
+		http2.WithAccounts(auth.NewAccounts(users, s, sessions)),
+	)
+
+	r := chi.NewRouter()
+	r.With(http3.Auth).Post("/api/auth/register", h.PostAPIAuthRegister)
+	r.With(http3.Auth).Post("/api/auth/login", h.PostAPIAuthLogin)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	// у клиента нет cookie, поэтому каждый запрос приходит от нового анонимного пользователя
+	post := func(path, body string) (*http.Response, models.TokenPair) {
+		resp, err := srv.Client().Post(srv.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var pair models.TokenPair
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&pair))
+		}
+		return resp, pair
+	}
+
+	credentials := `{"email":"user@example.com","password":"correct horse"}`
+
+	resp, registered := post("/api/auth/register", credentials)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	accountID, err := auth.ParseToken(registered.AccessToken)
+	require.NoError(t, err)
+
+	resp, _ = post("/api/auth/register", credentials)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, _ = post("/api/auth/register", `{"email":"user","password":"correct horse"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = post("/api/auth/login", `{"email":"user@example.com","password":"wrong password"}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// токен доступа учётной записи не обменивается на сессию без пароля
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/auth/login", nil)
+	require.NoError(t, err)
+	req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+registered.AccessToken)
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// ссылки анонимного пользователя переходят к учётной записи
+	s.EXPECT().ClaimShortLinks(gomock.Any(), gomock.Any(), accountID).Return([]string{"code"}, nil)
+
+	resp, login := post("/api/auth/login", credentials)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	loginUser, err := auth.ParseToken(login.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, accountID, loginUser)
+}
+
+func TestHandler_URLNormalization(t *testing.T) {
+	store, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")})
+	require.NoError(t, err)
+	defer store.Close()
+
+	h := newTestHandler(t, store,
+		http2.WithURLNormalizer(urlnorm.NewNormalizer(config.URLs{StripTracking: true})),
+	)
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Post("/", h.PostShorten)
+	r.Post("/api/shorten", h.PostAPIShorten)
+	r.Post("/api/shorten/batch", h.PostAPIShortenBatch)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	post := func(path, body string) (*http.Response, []byte) {
+		resp, err := srv.Client().Post(srv.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, data
+	}
+
+	resp, created := post("/", "https://Practicum.Yandex.ru:443?utm_source=mail")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// та же ссылка в другой записи находится по нормальной форме
+	resp, body := post("/api/shorten", `{"url":"HTTPS://practicum.yandex.ru/"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Contains(t, string(body), string(created))
+
+	link, err := store.GetByOriginalURL(context.Background(), "https://practicum.yandex.ru/")
+	require.NoError(t, err)
+	assert.Equal(t, string(created), link.ShortURL)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected models.ErrorDetail
+	}{
+		{
+			name: "javascript",
+			path: "/",
+			body: "javascript:alert(1)",
+			expected: models.ErrorDetail{
+				Code: urlnorm.ErrSchemeNotAllowed.Code, Field: "url", Message: urlnorm.ErrSchemeNotAllowed.Message,
+			},
+		},
+		{
+			name: "relative",
+			path: "/api/shorten",
+			body: `{"url":"/admin"}`,
+			expected: models.ErrorDetail{
+				Code: urlnorm.ErrNotAbsolute.Code, Field: "url", Message: urlnorm.ErrNotAbsolute.Message,
+			},
+		},
+		{
+			name: "batch",
+			path: "/api/shorten/batch",
+			body: `[{"correlation_id":"1","original_url":"https://go.dev/"},{"correlation_id":"2","original_url":"https://"}]`,
+			expected: models.ErrorDetail{
+				Code: urlnorm.ErrHostInvalid.Code, Field: "[1].original_url", Message: urlnorm.ErrHostInvalid.Message,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := post(tt.path, tt.body)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(body, &response))
+			assert.Equal(t, tt.expected, response.Error)
+		})
+	}
+}
+
+func TestHandler_URLPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"deny":["*.phish.example"]}`), 0o600))
+
+	policy, err := urlpolicy.New(config.URLPolicy{File: path})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	s := mockStore.NewMockStorage(ctrl)
+
+	h := newTestHandler(t, s, http2.WithURLPolicy(policy))
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Post("/", h.PostShorten)
+	r.Post("/api/shorten/batch", h.PostAPIShortenBatch)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected models.ErrorDetail
+	}{
+		{
+			name: "denied",
+			path: "/",
+			body: "https://login.phish.example/bank",
+			expected: models.ErrorDetail{
+				Code: urlpolicy.ErrDomainDenied.Code, Field: "url", Message: urlpolicy.ErrDomainDenied.Message,
+			},
+		},
+		{
+			name: "private",
+			path: "/api/shorten/batch",
+			body: `[{"correlation_id":"1","original_url":"http://169.254.169.254/latest/meta-data/"}]`,
+			expected: models.ErrorDetail{
+				Code: urlpolicy.ErrPrivateAddress.Code, Field: "[0].original_url", Message: urlpolicy.ErrPrivateAddress.Message,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// отклонённые ссылки не доходят до хранилища
+			resp, err := srv.Client().Post(srv.URL+tt.path, "application/json", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+			assert.Equal(t, tt.expected, response.Error)
+		})
+	}
+}
+
+func TestHandler_Moderation(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")})
+	require.NoError(t, err)
+	defer store.Close()
+
+	bans, err := memory.NewBans("")
+	require.NoError(t, err)
+	audit, err := memory.NewAudit("")
+	require.NoError(t, err)
+	moderator := moderation.NewModerator(store, bans, audit, config.Admin{UserIDs: "admin"})
+
+	require.NoError(t, store.Save(ctx, models.ShortLink{
+		UUID: uuid.New().String(), UserID: "spammer", Code: "phish", ShortURL: "http://localhost/phish", OriginalURL: "https://phish.example/",
+	}))
+
+	h := newTestHandler(t, store, http2.WithModerator(moderator))
+
+	r := chi.NewRouter()
+	r.Use(http3.Auth)
+	r.Get("/{id}", h.GetShorten)
+	r.Post("/", h.PostShorten)
+	r.Post("/api/user/urls/{code}/restore", h.PostAPIUserURLRestore)
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(http3.RequireAdmin(moderator.IsAdmin))
+		r.Get("/links", h.GetAPIAdminLinks)
+		r.Post("/links/{code}/disable", h.PostAPIAdminLinkDisable)
+		r.Post("/links/{code}/enable", h.PostAPIAdminLinkEnable)
+		r.Post("/users/{id}/ban", h.PostAPIAdminUserBan)
+		r.Delete("/users/{id}/ban", h.DeleteAPIAdminUserBan)
+		r.Get("/audit", h.GetAPIAdminAudit)
+	})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client := srv.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	do := func(userID, method, path, body string) (*http.Response, []byte) {
+		signed, err := http3.NewSigner(context.WithValue(ctx, auth.JwtUserIDContextKey, userID))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+signed.Value(auth.JwtContextKey).(string))
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, data
+	}
+
+	resp, _ := do("spammer", http.MethodGet, "/api/admin/links", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, body := do("admin", http.MethodGet, "/api/admin/links", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var page models.ShortLinksReviewPageResponse
+	require.NoError(t, json.Unmarshal(body, &page))
+	require.Len(t, page.Links, 1)
+	assert.Equal(t, "spammer", page.Links[0].UserID)
+
+	resp, body = do("admin", http.MethodPost, "/api/admin/links/phish/disable", `{"reason":" "}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var errResp models.ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &errResp))
+	assert.Equal(t, "reason", errResp.Error.Field)
+
+	resp, _ = do("admin", http.MethodPost, "/api/admin/links/missing/disable", `{"reason":"spam"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// страница причины экранирует текст модератора
+	resp, _ = do("admin", http.MethodPost, "/api/admin/links/phish/disable", `{"reason":"court <order>","legal":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, body = do("visitor", http.MethodGet, "/phish", "")
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, resp.StatusCode)
+	assert.Contains(t, string(body), "court &lt;order&gt;")
+
+	resp, _ = do("admin", http.MethodPost, "/api/admin/links/phish/enable", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = do("visitor", http.MethodGet, "/phish", "")
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	resp, _ = do("admin", http.MethodPost, "/api/admin/links/phish/disable", `{"reason":"phishing"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, body = do("visitor", http.MethodGet, "/phish", "")
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+	assert.Contains(t, string(body), "phishing")
+
+	resp, _ = do("admin", http.MethodPost, "/api/admin/users/admin/ban", `{"reason":"oops"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, _ = do("admin", http.MethodPost, "/api/admin/users/spammer/ban", `{"reason":"phishing"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = do("spammer", http.MethodPost, "/", "https://another.example/")
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	errResp = models.ErrorResponse{}
+	require.NoError(t, json.Unmarshal(body, &errResp))
+	assert.Equal(t, "user_banned", errResp.Error.Code)
+
+	// заблокированный пользователь не может вернуть ссылку
+	resp, _ = do("spammer", http.MethodPost, "/api/user/urls/phish/restore", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = do("admin", http.MethodDelete, "/api/admin/users/spammer/ban", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = do("admin", http.MethodDelete, "/api/admin/users/spammer/ban", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = do("spammer", http.MethodPost, "/", "https://another.example/")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, body = do("admin", http.MethodGet, "/api/admin/audit?limit=2", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var entries []models.AuditEntry
+	require.NoError(t, json.Unmarshal(body, &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditUserUnbanned, entries[0].Action)
+	assert.Equal(t, models.AuditUserBanned, entries[1].Action)
+
+	resp, _ = do("admin", http.MethodGet, "/api/admin/audit?limit=0", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,13 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+)
+
+// GetJWKS the public keys verifying the tokens of the service.
+func (h *Handler) GetJWKS(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, auth.PublicKeys())
+}
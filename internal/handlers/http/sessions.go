@@ -0,0 +1,173 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"go.uber.org/zap"
+)
+
+// refreshCookiePath the refresh token cookie is sent only to the session endpoints.
+const refreshCookiePath = "/api/auth"
+
+// PostAPIAuthLogin starts the session of the current anonymous user and returns the token pair.
+// With the email and the password in the body the session of the account is started instead,
+// the links of the anonymous current user are claimed by the account. The registered account
+// always logs in with its credentials.
+func (h *Handler) PostAPIAuthLogin(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CredentialsRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var pair *models.TokenPair
+	if len(req.Email) > 0 || len(req.Password) > 0 {
+		pair, err = h.accounts.Login(r.Context(), userID, req)
+	} else {
+		pair, err = h.accounts.LoginAnonymous(r.Context(), userID)
+	}
+	if errors.Is(err, auth.ErrorCredentialsInvalid) || errors.Is(err, auth.ErrorCredentialsRequired) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot login", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokenPair(w, http.StatusOK, pair)
+}
+
+// PostAPIAuthRegister creates the account of the current user keeping its links and starts its session.
+func (h *Handler) PostAPIAuthRegister(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CredentialsRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pair, err := h.accounts.Register(r.Context(), userID, req)
+	if errors.Is(err, auth.ErrorAccountExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot register", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokenPair(w, http.StatusCreated, pair)
+}
+
+// PostAPIAuthRefresh exchanges the refresh token of the body or the cookie for a new token pair.
+func (h *Handler) PostAPIAuthRefresh(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := readRefreshToken(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(refreshToken) == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	pair, err := h.sessions.Refresh(r.Context(), refreshToken)
+	if errors.Is(err, auth.ErrorRefreshTokenInvalid) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("cannot refresh the session", zap.Error(err))
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokenPair(w, http.StatusOK, pair)
+}
+
+// PostAPIAuthLogout revokes the refresh token of the body or the cookie and clears the session cookies.
+func (h *Handler) PostAPIAuthLogout(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := readRefreshToken(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(refreshToken) > 0 {
+		if err = h.sessions.Logout(r.Context(), refreshToken); err != nil {
+			logger.Log.Error("cannot logout", zap.Error(err))
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.SetCookie(w, sessionCookie(auth.CookieAccessTokenKey, "", "/", -1))
+	http.SetCookie(w, sessionCookie(auth.CookieRefreshTokenKey, "", refreshCookiePath, -1))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeTokenPair(w http.ResponseWriter, status int, pair *models.TokenPair) {
+	w.Header().Set(auth.HeaderAuthorizationKey, fmt.Sprintf("Bearer %s", pair.AccessToken))
+	w.Header().Set("Cache-Control", "no-store")
+	http.SetCookie(w, sessionCookie(auth.CookieAccessTokenKey, pair.AccessToken, "/", int(pair.ExpiresIn)))
+	http.SetCookie(w, sessionCookie(auth.CookieRefreshTokenKey, pair.RefreshToken, refreshCookiePath,
+		int(h.sessions.RefreshTokenExp().Seconds())))
+
+	writeJSON(w, status, pair)
+}
+
+// readRefreshToken the refresh token of the JSON body, or of the cookie when the body is empty.
+func readRefreshToken(r *http.Request) (string, error) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if len(req.RefreshToken) > 0 {
+		return req.RefreshToken, nil
+	}
+
+	if cookie, err := r.Cookie(auth.CookieRefreshTokenKey); err == nil {
+		return cookie.Value, nil
+	}
+
+	return "", nil
+}
+
+func sessionCookie(name, value, path string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
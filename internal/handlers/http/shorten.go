@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Orendev/shortener/internal/analytics"
 	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/codegen"
 	"github.com/Orendev/shortener/internal/models"
-	"github.com/Orendev/shortener/internal/random"
 	"github.com/Orendev/shortener/internal/repository"
+	"github.com/Orendev/shortener/internal/utils"
 	"github.com/google/uuid"
 )
 
@@ -29,12 +33,38 @@ func (h *Handler) GetShorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if shortLink.IsDisabled() {
+		// Ссылку отключил модератор
+		writeDisabledPage(w, shortLink)
+		return
+	}
+
+	if shortLink.IsExpired(time.Now()) {
+		// Срок жизни ссылки истёк
+		if h.expiredNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
 	if shortLink.DeletedFlag {
 		// Целевой запрос больше не доступен
 		w.WriteHeader(http.StatusGone)
 		return
 	}
 
+	if h.clicks != nil {
+		h.clicks.Record(models.Click{
+			Code:      code,
+			ClickedAt: time.Now().UTC(),
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        analytics.AnonymizeIP(utils.ClientIP(r)),
+		})
+	}
+
 	w.Header().Add("Location", shortLink.OriginalURL)
 	w.WriteHeader(http.StatusTemporaryRedirect)
 }
@@ -58,29 +88,57 @@ func (h *Handler) PostShorten(w http.ResponseWriter, r *http.Request) {
 
 	req := models.ShortLinkRequest{}
 	req.URL = string(body)
+	req.Alias = r.URL.Query().Get("alias")
 
-	if err = req.Validate(); err != nil {
+	if err = parseExpirationQuery(r, &req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if err = req.Validate(); err != nil {
+		writeValidationError(w, "", err)
+		return
+	}
+
+	// совпадающие ссылки сохраняются и ищутся в нормальной форме
+	if req.URL, err = h.urls.Normalize(req.URL); err != nil {
+		writeValidationError(w, "url", err)
+		return
+	}
+
 	userID, err := auth.GetAuthIdentifier(r.Context())
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	code := random.Strn(8)
-	shortLink := &models.ShortLink{
+	if !h.checkUser(w, r, userID) {
+		return
+	}
+
+	if err = h.policy.Check(r.Context(), userID, req.URL); err != nil {
+		writeValidationError(w, "url", err)
+		return
+	}
+
+	shortLink, err := h.codes.Save(r.Context(), h.repo, models.ShortLink{
 		UUID:        uuid.New().String(),
 		UserID:      userID,
-		Code:        code,
+		Code:        req.Alias,
 		OriginalURL: req.URL,
-		ShortURL:    fmt.Sprintf("%s/%s", strings.TrimPrefix(h.baseURL, "/"), code),
 		DeletedFlag: false,
+		ExpiresAt:   req.Expiration(time.Now()),
+	}, h.shortURL)
+
+	if errors.Is(err, repository.ErrCodeConflict) {
+		http.Error(w, "the alias is already taken", http.StatusConflict)
+		return
 	}
 
-	err = h.repo.Save(r.Context(), *shortLink)
+	if errors.Is(err, codegen.ErrCodesExhausted) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	if err != nil && !errors.Is(err, repository.ErrConflict) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -106,3 +164,31 @@ func (h *Handler) PostShorten(w http.ResponseWriter, r *http.Request) {
 	}
 
 }
+
+// shortURL the short URL of the code.
+func (h *Handler) shortURL(code string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimPrefix(h.baseURL, "/"), code)
+}
+
+// parseExpirationQuery reads the link lifetime from the expires_at and ttl_seconds query parameters.
+func parseExpirationQuery(r *http.Request, req *models.ShortLinkRequest) error {
+	query := r.URL.Query()
+
+	if expiresAt := query.Get("expires_at"); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return err
+		}
+		req.ExpiresAt = &t
+	}
+
+	if ttl := query.Get("ttl_seconds"); ttl != "" {
+		seconds, err := strconv.ParseInt(ttl, 10, 64)
+		if err != nil {
+			return err
+		}
+		req.TTLSeconds = seconds
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetAPIUserURL the user's short link by code.
+func (h *Handler) GetAPIUserURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shortLink, err := h.repo.GetByCode(r.Context(), chi.URLParam(r, "code"))
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && shortLink.UserID != userID) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeShortLinkDetail(w, shortLink)
+}
+
+// PatchAPIUserURL changes the original URL of the user's short link.
+func (h *Handler) PatchAPIUserURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !h.checkUser(w, r, userID) {
+		return
+	}
+
+	var req models.ShortLinkUpdateRequest
+	// читаем тело запроса и декодируем
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = req.Validate(); err != nil {
+		writeValidationError(w, "url", err)
+		return
+	}
+
+	if req.URL, err = h.urls.Normalize(req.URL); err != nil {
+		writeValidationError(w, "url", err)
+		return
+	}
+
+	if err = h.policy.Check(r.Context(), userID, req.URL); err != nil {
+		writeValidationError(w, "url", err)
+		return
+	}
+
+	code := chi.URLParam(r, "code")
+
+	// удалённую ссылку сначала нужно восстановить
+	shortLink, err := h.repo.GetByCode(r.Context(), code)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && shortLink.UserID != userID) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if shortLink.DeletedFlag {
+		http.Error(w, "the short link is deleted", http.StatusGone)
+		return
+	}
+
+	shortLink, err = h.repo.UpdateOriginalURL(r.Context(), code, userID, req.URL)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		http.Error(w, "the URL is already shortened", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeShortLinkDetail(w, shortLink)
+}
+
+// PostAPIUserURLRestore cancels the soft delete of the user's short link.
+func (h *Handler) PostAPIUserURLRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := auth.GetAuthIdentifier(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !h.checkUser(w, r, userID) {
+		return
+	}
+
+	shortLink, err := h.repo.Restore(r.Context(), chi.URLParam(r, "code"), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeShortLinkDetail(w, shortLink)
+}
+
+// writeShortLinkDetail writes the short link as models.ShortLinkDetailResponse.
+func writeShortLinkDetail(w http.ResponseWriter, shortLink *models.ShortLink) {
+	// заполняем модель ответа
+	enc, err := json.Marshal(models.ShortLinkDetailResponse{
+		Code:           shortLink.Code,
+		ShortURL:       shortLink.ShortURL,
+		OriginalURL:    shortLink.OriginalURL,
+		IsDeleted:      shortLink.DeletedFlag,
+		DisabledReason: shortLink.DisabledReason,
+		ExpiresAt:      shortLink.ExpiresAt,
+		CreatedAt:      shortLink.CreatedAt,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(enc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
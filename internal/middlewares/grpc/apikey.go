@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyKey the metadata key of the API key.
+var apiKeyKey = strings.ToLower(auth.HeaderAPIKey)
+
+// methodScopes the scopes of the methods available to the API keys, the other methods are denied to them.
+var methodScopes = map[string]models.APIKeyScope{
+	pb.ShortenerService_Ping_FullMethodName:           models.ScopeRead,
+	pb.ShortenerService_ResolveCode_FullMethodName:    models.ScopeRead,
+	pb.ShortenerService_GetAPIUserUrls_FullMethodName: models.ScopeRead,
+	pb.ShortenerService_SaveAPIShorten_FullMethodName: models.ScopeCreate,
+	pb.ShortenerService_Shorten_FullMethodName:        models.ScopeCreate,
+	pb.ShortenerService_ShortenBatch_FullMethodName:   models.ScopeCreate,
+	pb.ShortenerService_DeleteUserUrls_FullMethodName: models.ScopeDelete,
+	pb.ShortenerService_GetAPIStats_FullMethodName:    models.ScopeStats,
+}
+
+// APIKey adds the interceptors authenticating the calls with the x-api-key metadata as the user of the key.
+// The calls without the key are left to the Auth interceptors.
+func APIKey(opts []grpc.ServerOption, keys *auth.APIKeys) []grpc.ServerOption {
+	return append(
+		opts,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context,
+			req interface{},
+			info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (resp interface{}, err error) {
+
+			ctx, err = authenticateAPIKey(ctx, keys, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{},
+			ss grpc.ServerStream,
+			info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+
+			ctx, err := authenticateAPIKey(ss.Context(), keys, info.FullMethod)
+			if err != nil {
+				return err
+			}
+
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		}),
+	)
+}
+
+// authenticateAPIKey puts the key of the call into the context and checks its scope.
+func authenticateAPIKey(ctx context.Context, keys *auth.APIKeys, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(apiKeyKey)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	key, err := keys.Authenticate(ctx, values[0])
+	if errors.Is(err, auth.ErrorAPIKeyInvalid) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("cannot authenticate the API key", zap.Error(err))
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	scope, ok := methodScopes[fullMethod]
+	if !ok || !key.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "the API key has no scope for the method")
+	}
+
+	return auth.WithAPIKey(ctx, key), nil
+}
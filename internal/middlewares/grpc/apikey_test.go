@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/models"
+	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	store, err := memory.NewAPIKeys("")
+	require.NoError(t, err)
+	keys := auth.NewAPIKeys(store)
+	created, err := keys.Create(context.Background(), "user", models.APIKeyRequest{Name: "bot", Scopes: []models.APIKeyScope{models.ScopeCreate}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		want   codes.Code
+	}{
+		{name: "method of the scope", key: created.Key, method: pb.ShortenerService_ShortenBatch_FullMethodName, want: codes.OK},
+		{name: "method of another scope", key: created.Key, method: pb.ShortenerService_DeleteUserUrls_FullMethodName, want: codes.PermissionDenied},
+		{name: "method unknown to the keys", key: created.Key, method: "/grpcshortener.ShortenerService/Unknown", want: codes.PermissionDenied},
+		{name: "invalid key", key: "shk_invalid", method: pb.ShortenerService_Shorten_FullMethodName, want: codes.Unauthenticated},
+		{name: "no key", method: pb.ShortenerService_DeleteUserUrls_FullMethodName, want: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(apiKeyKey, tt.key))
+			}
+
+			got, err := authenticateAPIKey(ctx, keys, tt.method)
+			assert.Equal(t, tt.want, status.Code(err))
+
+			if tt.want == codes.OK && tt.key != "" {
+				userID, err := auth.GetAuthIdentifier(got)
+				require.NoError(t, err)
+				assert.Equal(t, "user", userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	bearer       string = "bearer"
+	bearerFormat string = "Bearer %s"
+)
+
+// authorizationKey the metadata key of the token, the gRPC metadata keys are lowercase.
+var authorizationKey = strings.ToLower(auth.HeaderAuthorizationKey)
+
+// Auth adds the interceptors authenticating the calls with the JWT of the HTTP Auth middleware.
+//
+// The token is read from the authorization metadata. When it is missing, the token of a new user
+// is issued in the authorization header of the response; the expired or invalid tokens are rejected.
+func Auth(opts []grpc.ServerOption) []grpc.ServerOption {
+	return append(opts, grpc.ChainUnaryInterceptor(unaryAuth), grpc.ChainStreamInterceptor(streamAuth))
+}
+
+func unaryAuth(ctx context.Context,
+	req interface{},
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+
+	ctx, token, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		if err = grpc.SetHeader(ctx, tokenMetadata(token)); err != nil {
+			return nil, status.Error(codes.Internal, "server error")
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func streamAuth(srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+
+	ctx, token, err := authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		if err = ss.SetHeader(tokenMetadata(token)); err != nil {
+			return status.Error(codes.Internal, "server error")
+		}
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// serverStream - structure describing the stream with the authenticated context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context the context with the user of the call.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate puts the user of the call into the context,
+// the new token is returned when the call had no token.
+func authenticate(ctx context.Context) (context.Context, string, error) {
+	// вызов уже аутентифицирован ключом API
+	if auth.IsAPIKey(ctx) {
+		return ctx, "", nil
+	}
+
+	tokenString, ok := extractToken(ctx)
+	if ok {
+		userID, err := auth.ParseToken(tokenString)
+		if err != nil {
+			return nil, "", status.Error(codes.Unauthenticated, err.Error())
+		}
+		return withUser(ctx, tokenString, userID), "", nil
+	}
+
+	return issue(ctx, uuid.New().String())
+}
+
+// issue signs a new token of the anonymous user.
+func issue(ctx context.Context, userID string) (context.Context, string, error) {
+	tokenString, err := auth.NewAnonymousToken(userID)
+	if err != nil {
+		return nil, "", status.Error(codes.Internal, "server error")
+	}
+
+	return withUser(ctx, tokenString, userID), tokenString, nil
+}
+
+func withUser(ctx context.Context, tokenString, userID string) context.Context {
+	ctx = context.WithValue(ctx, auth.JwtContextKey, tokenString)
+	return context.WithValue(ctx, auth.JwtUserIDContextKey, userID)
+}
+
+// extractToken the Bearer token of the incoming metadata.
+func extractToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	authHeaderParts := strings.Split(values[0], " ")
+	if len(authHeaderParts) != 2 || !strings.EqualFold(authHeaderParts[0], bearer) {
+		return "", false
+	}
+
+	return authHeaderParts[1], true
+}
+
+func tokenMetadata(token string) metadata.MD {
+	return metadata.Pairs(authorizationKey, fmt.Sprintf(bearerFormat, token))
+}
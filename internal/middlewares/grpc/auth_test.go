@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// transportStream records the headers sent by the interceptor.
+type transportStream struct {
+	header metadata.MD
+}
+
+func (s *transportStream) Method() string { return "/shortener.ShortenerService/Shorten" }
+
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *transportStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *transportStream) SetTrailer(metadata.MD) error { return nil }
+
+func TestAuth(t *testing.T) {
+	valid, err := auth.NewToken("user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantUserID    string
+		wantNewToken  bool
+		wantCode      codes.Code
+	}{
+		{
+			name:         "issues a token when it is missing",
+			wantNewToken: true,
+		},
+		{
+			name:          "accepts a valid token",
+			authorization: "Bearer " + valid,
+			wantUserID:    "user",
+		},
+		{
+			name:          "rejects a malformed token",
+			authorization: "Bearer invalid",
+			wantCode:      codes.Unauthenticated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &transportStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+
+			var userID string
+			_, err := unaryAuth(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				userID, err = auth.GetAuthIdentifier(ctx)
+				return nil, err
+			})
+
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, userID)
+			if tt.wantUserID != "" {
+				assert.Equal(t, tt.wantUserID, userID)
+			}
+
+			header := stream.header.Get("authorization")
+			if !tt.wantNewToken {
+				assert.Empty(t, header)
+				return
+			}
+
+			// выданный токен принадлежит тому же пользователю
+			require.Len(t, header, 1)
+			got, err := auth.ParseToken(strings.TrimPrefix(header[0], "Bearer "))
+			require.NoError(t, err)
+			assert.Equal(t, userID, got)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/Orendev/shortener/internal/trusted"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// realIPKey the metadata key of the client IP set by the proxy.
+const realIPKey = "x-real-ip"
+
+// TrustedSubnet adds the interceptors allowing the internal methods only to the callers of the trusted subnet.
+//
+// The caller IP is the peer address, the x-real-ip metadata is used instead
+// only when the peer is in the trustedProxy subnet.
+func TrustedSubnet(opts []grpc.ServerOption, subnet, trustedProxy string) []grpc.ServerOption {
+	check := func(ctx context.Context, fullMethod string) error {
+		return checkTrusted(ctx, fullMethod, subnet, trustedProxy)
+	}
+
+	return append(
+		opts,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context,
+			req interface{},
+			info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (resp interface{}, err error) {
+
+			if err = check(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{},
+			ss grpc.ServerStream,
+			info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+
+			if err := check(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+
+			return handler(srv, ss)
+		}),
+	)
+}
+
+// checkTrusted denies the internal method to the caller outside of the subnet.
+func checkTrusted(ctx context.Context, fullMethod, subnet, trustedProxy string) error {
+	if !trusted.IsInternalMethod(fullMethod) {
+		return nil
+	}
+
+	ok, err := trusted.Contains(subnet, clientIP(ctx, trustedProxy))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if !ok {
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+
+	return nil
+}
+
+// clientIP the IP address of the caller.
+func clientIP(ctx context.Context, trustedProxy string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	ip, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		ip = p.Addr.String()
+	}
+
+	// адрес клиента за прокси берём из метаданных, только если прокси доверенный
+	if fromProxy, _ := trusted.Contains(trustedProxy, ip); !fromProxy {
+		return ip
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(realIPKey); len(values) > 0 && net.ParseIP(values[0]) != nil {
+		return values[0]
+	}
+
+	return ip
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckTrusted(t *testing.T) {
+	const subnet = "192.168.1.0/24"
+
+	tests := []struct {
+		name         string
+		method       string
+		peerIP       string
+		realIP       string
+		trustedProxy string
+		want         codes.Code
+	}{
+		{
+			name:   "internal method from the trusted subnet",
+			method: pb.ShortenerService_GetAPIStats_FullMethodName,
+			peerIP: "192.168.1.10",
+			want:   codes.OK,
+		},
+		{
+			name:   "internal method from another subnet",
+			method: pb.ShortenerService_GetAPIStats_FullMethodName,
+			peerIP: "10.0.0.1",
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "x-real-ip is ignored without a trusted proxy",
+			method: pb.ShortenerService_GetAPIStats_FullMethodName,
+			peerIP: "10.0.0.1",
+			realIP: "192.168.1.10",
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:         "x-real-ip of the trusted proxy",
+			method:       pb.ShortenerService_GetAPIStats_FullMethodName,
+			peerIP:       "10.0.0.1",
+			realIP:       "192.168.1.10",
+			trustedProxy: "10.0.0.0/8",
+			want:         codes.OK,
+		},
+		{
+			name:   "public method",
+			method: pb.ShortenerService_Shorten_FullMethodName,
+			peerIP: "10.0.0.1",
+			want:   codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{
+				Addr: &net.TCPAddr{IP: net.ParseIP(tt.peerIP), Port: 50000},
+			})
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(realIPKey, tt.realIP))
+			}
+
+			err := checkTrusted(ctx, tt.method, subnet, tt.trustedProxy)
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+}
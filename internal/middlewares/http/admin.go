@@ -0,0 +1,23 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+)
+
+// RequireAdmin middlewares rejecting the requests of the users who are not admins
+// and of the API keys, the admin acts only in its own session.
+func RequireAdmin(isAdmin func(userID string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := auth.GetAuthIdentifier(r.Context())
+			if err != nil || auth.IsAPIKey(r.Context()) || !isAdmin(userID) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
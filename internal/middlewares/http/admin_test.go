@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	isAdmin := func(userID string) bool {
+		return userID == "admin"
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{name: "admin", ctx: context.WithValue(context.Background(), auth.JwtUserIDContextKey, "admin"), want: http.StatusOK},
+		{name: "user", ctx: context.WithValue(context.Background(), auth.JwtUserIDContextKey, "user"), want: http.StatusForbidden},
+		{name: "no user", ctx: context.Background(), want: http.StatusForbidden},
+		{
+			name: "api key of the admin",
+			ctx:  auth.WithAPIKey(context.Background(), &models.APIKey{UserID: "admin", Scopes: []models.APIKeyScope{models.ScopeRead}}),
+			want: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/admin/links", nil).WithContext(tt.ctx)
+			w := httptest.NewRecorder()
+
+			RequireAdmin(isAdmin)(next).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
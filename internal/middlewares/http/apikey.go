@@ -0,0 +1,65 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"go.uber.org/zap"
+)
+
+// APIKey middlewares authenticating the requests with the X-API-Key header as the user of the key.
+// The requests without the header are left to the Auth middleware.
+func APIKey(keys *auth.APIKeys) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get(auth.HeaderAPIKey)
+			if len(secret) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := keys.Authenticate(r.Context(), secret)
+			if errors.Is(err, auth.ErrorAPIKeyInvalid) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				logger.Log.Error("cannot authenticate the API key", zap.Error(err))
+				http.Error(w, "server error", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithAPIKey(r.Context(), key)))
+		})
+	}
+}
+
+// RequireScope middlewares rejecting the requests of the API keys without the scope.
+func RequireScope(scope models.APIKeyScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.HasScope(r.Context(), scope) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession middlewares rejecting the requests of the API keys,
+// so the key cannot manage the keys or start a session of its user.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.IsAPIKey(r.Context()) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
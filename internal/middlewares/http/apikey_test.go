@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/auth"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	store, err := memory.NewAPIKeys("")
+	require.NoError(t, err)
+	keys := auth.NewAPIKeys(store)
+	created, err := keys.Create(context.Background(), "user", models.APIKeyRequest{Name: "ci", Scopes: []models.APIKeyScope{models.ScopeCreate}})
+	require.NoError(t, err)
+
+	var userID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = auth.GetAuthIdentifier(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		key        string
+		handler    http.Handler
+		want       int
+		wantUserID string
+	}{
+		{name: "valid key", key: created.Key, handler: RequireScope(models.ScopeCreate)(next), want: http.StatusOK, wantUserID: "user"},
+		{name: "invalid key", key: "shk_invalid", handler: next, want: http.StatusUnauthorized},
+		{name: "key without the scope", key: created.Key, handler: RequireScope(models.ScopeDelete)(next), want: http.StatusForbidden},
+		{name: "key on the session endpoint", key: created.Key, handler: RequireSession(next), want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID = ""
+			r := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+			r.Header.Set(auth.HeaderAPIKey, tt.key)
+			w := httptest.NewRecorder()
+
+			APIKey(keys)(Auth(tt.handler)).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.wantUserID, userID)
+			// запрос с ключом не получает токен нового пользователя
+			assert.Empty(t, w.Header().Get(auth.HeaderAuthorizationKey))
+		})
+	}
+}
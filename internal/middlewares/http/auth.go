@@ -6,10 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/Orendev/shortener/internal/auth"
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 )
 
@@ -19,56 +17,50 @@ const (
 )
 
 // Auth  middlewares authorization.
+//
+// The request without a token gets the long-lived token of a new anonymous user, so the user
+// keeps its links past the access token lifetime. The expired or invalid tokens
+// are rejected, the session is continued with POST /api/auth/refresh.
+// The requests already authenticated by the APIKey middleware are passed as is.
 func Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// по умолчанию устанавливаем оригинальный http.ResponseWriter, http.Request как тот,
-		// который будем передавать следующей функции
-		ow := w
-		or := r
-		ctx, err := HTTPToContext(or)
+		if auth.IsAPIKey(r.Context()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := HTTPToContext(r)
+		if err == nil {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if !errors.Is(err, http.ErrNoCookie) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// токена нет, выдаём токен новому пользователю
+		ctx, err = NewSigner(r.Context())
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+
+		or, err := contextToHTTP(w, r.WithContext(ctx))
 		if err != nil {
-			switch {
-			case errors.Is(err, http.ErrNoCookie) || errors.Is(err, auth.ErrorTokenExpired):
-
-				if ctx != nil {
-					or = or.WithContext(ctx)
-				}
-
-				ctx, err = NewSigner(or.Context())
-				if err != nil {
-					http.Error(ow, "server error", http.StatusInternalServerError)
-					return
-				}
-
-				or, err = contextToHTTP(ow, or.WithContext(ctx))
-				if err != nil {
-					http.Error(ow, "server error", http.StatusInternalServerError)
-					return
-				}
-
-			default:
-				http.Error(ow, "server error", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			or = or.WithContext(ctx)
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
 		}
 
 		ctx, err = newParse(or.Context())
-
 		if err != nil {
-			switch {
-			case errors.Is(err, auth.ErrorTokenContextMissing):
-				http.Error(ow, "Unauthorized", http.StatusUnauthorized)
-			case errors.Is(err, auth.ErrorTokenExpired):
-				http.Error(ow, "Unauthorized", http.StatusUnauthorized)
-			default:
-				http.Error(ow, "server error", http.StatusInternalServerError)
-			}
+			http.Error(w, "server error", http.StatusInternalServerError)
 			return
 		}
 
-		next.ServeHTTP(ow, or.WithContext(ctx))
+		next.ServeHTTP(w, or.WithContext(ctx))
 	})
 }
 
@@ -79,18 +71,7 @@ func NewSigner(ctx context.Context) (context.Context, error) {
 	if err != nil {
 		userID = uuid.New().String()
 	}
-	// создаём новый токен с алгоритмом подписи HS256 и утверждениями — Claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			// когда создан токен
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(auth.TokenExp)),
-		},
-		// собственное утверждение
-		UserID: userID,
-	})
-
-	// создаём строку токена
-	tokenString, err := token.SignedString([]byte(auth.SecretKey))
+	tokenString, err := auth.NewAnonymousToken(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +106,7 @@ func contextToHTTP(w http.ResponseWriter, r *http.Request) (*http.Request, error
 		Name:     auth.CookieAccessTokenKey,
 		Value:    tokenString,
 		Path:     "/",
-		MaxAge:   int(auth.TokenExp.Seconds()),
+		MaxAge:   int(auth.AnonymousTokenExp().Seconds()),
 		HttpOnly: true,
 		Secure:   true,
 		SameSite: http.SameSiteLaxMode,
@@ -135,44 +116,17 @@ func contextToHTTP(w http.ResponseWriter, r *http.Request) (*http.Request, error
 }
 
 func newParse(ctx context.Context) (context.Context, error) {
-	claims := &auth.Claims{}
 	tokenString, ok := ctx.Value(auth.JwtContextKey).(string)
 	if !ok {
 		return nil, auth.ErrorTokenContextMissing
 	}
-	token, err := jwt.ParseWithClaims(tokenString, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, auth.ErrorUnexpectedSigningMethod
-			}
-			return []byte(auth.SecretKey), nil
-		})
 
+	userID, err := auth.ParseToken(tokenString)
 	if err != nil {
-		if e, ok := err.(*jwt.ValidationError); ok {
-			switch {
-			case e.Errors&jwt.ValidationErrorMalformed != 0:
-				// Token is malformed
-				return nil, auth.ErrorTokenMalformed
-			case e.Errors&jwt.ValidationErrorExpired != 0:
-				// Token is expired
-				return context.WithValue(ctx, auth.JwtUserIDContextKey, claims.UserID), auth.ErrorTokenExpired
-			case e.Errors&jwt.ValidationErrorNotValidYet != 0:
-				// Token is not active yet
-				return nil, auth.ErrorTokenNotActive
-			case e.Inner != nil:
-				// report e.Inner
-				return nil, e.Inner
-			}
-		}
 		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, auth.ErrorTokenInvalid
-	}
-
-	return context.WithValue(ctx, auth.JwtUserIDContextKey, claims.UserID), nil
+	return context.WithValue(ctx, auth.JwtUserIDContextKey, userID), nil
 }
 
 func extractTokenFromAuthHeader(val string) (token string, ok bool) {
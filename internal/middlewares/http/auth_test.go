@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Orendev/shortener/internal/auth"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -193,3 +195,75 @@ func TestNewSigner(t *testing.T) {
 		})
 	}
 }
+
+func TestAuth_ExpiredToken(t *testing.T) {
+	key, err := auth.NewKey("test", "HS256", []byte("secret"))
+	require.NoError(t, err)
+	keyring, err := auth.NewKeyring(time.Hour, key)
+	require.NoError(t, err)
+	auth.SetKeyring(keyring)
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))},
+		UserID:           "user",
+	})
+	expired.Header["kid"] = "test"
+	tokenString, err := expired.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// истёкший токен не продлевается
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(auth.HeaderAuthorizationKey, "Bearer "+tokenString)
+	w := httptest.NewRecorder()
+	Auth(next).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get(auth.HeaderAuthorizationKey))
+}
+
+func TestAuth_AnonymousKeepsUser(t *testing.T) {
+	key, err := auth.NewKey("test", "HS256", []byte("secret"))
+	require.NoError(t, err)
+	// токены доступа истекают сразу, токены анонимных пользователей — нет
+	keyring, err := auth.NewKeyring(time.Nanosecond, key)
+	require.NoError(t, err)
+	auth.SetKeyring(keyring)
+
+	var userID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = auth.GetAuthIdentifier(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// первый запрос создаёт анонимного пользователя
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	Auth(next).ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEmpty(t, userID)
+	first := userID
+
+	cookies := w.Result().Cookies()
+	require.NotEmpty(t, cookies)
+
+	// токен доступа с тем же сроком уже истёк
+	accessToken, err := auth.NewToken(first)
+	require.NoError(t, err)
+	_, err = auth.ParseToken(accessToken)
+	assert.ErrorIs(t, err, auth.ErrorTokenExpired)
+
+	// анонимный пользователь сохраняется после срока жизни токена доступа
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	userID = ""
+	Auth(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, first, userID)
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/Orendev/shortener/internal/trusted"
+)
+
+// TrustedSubnet middlewares allowing the internal endpoints only to the clients of the trusted subnet.
+//
+// The client IP is the remote address, the X-Real-IP header is used instead
+// only when the remote address is in the trustedProxy subnet.
+func TrustedSubnet(subnet, trustedProxy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !trusted.IsInternalPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			check, err := trusted.Contains(subnet, clientIP(r, trustedProxy))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !check {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP the IP address of the client.
+func clientIP(r *http.Request, trustedProxy string) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	// адрес клиента за прокси берём из заголовка, только если прокси доверенный
+	if fromProxy, _ := trusted.Contains(trustedProxy, ip); !fromProxy {
+		return ip
+	}
+
+	if realIP := r.Header.Get("X-Real-IP"); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return ip
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedSubnet(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		realIP     string
+		want       int
+	}{
+		{name: "internal path from the trusted subnet", path: "/api/internal/stats", remoteAddr: "192.168.1.10:1234", want: http.StatusOK},
+		{name: "internal path from another subnet", path: "/api/internal/stats", remoteAddr: "10.0.0.1:1234", want: http.StatusForbidden},
+		{name: "public path", path: "/api/user/urls", remoteAddr: "10.0.0.1:1234", want: http.StatusOK},
+		{name: "admin path from the trusted subnet", path: "/api/admin/links", remoteAddr: "192.168.1.10:1234", want: http.StatusOK},
+		{name: "admin path from another subnet", path: "/api/admin/links", remoteAddr: "10.0.0.1:1234", want: http.StatusForbidden},
+		{name: "admin path with a spoofed header", path: "/api/admin/links", remoteAddr: "10.0.0.1:1234", realIP: "192.168.1.10", want: http.StatusForbidden},
+		{name: "admin path through the trusted proxy", path: "/api/admin/links", remoteAddr: "172.16.0.2:1234", realIP: "192.168.1.10", want: http.StatusOK},
+		{name: "admin path through the trusted proxy from another subnet", path: "/api/admin/links", remoteAddr: "172.16.0.2:1234", realIP: "10.0.0.1", want: http.StatusForbidden},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+
+			TrustedSubnet("192.168.1.0/24", "172.16.0.0/24")(next).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
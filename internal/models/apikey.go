@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKeyScope the permission granted to the API key.
+type APIKeyScope string
+
+// API key scopes.
+const (
+	ScopeRead   APIKeyScope = "read"
+	ScopeCreate APIKeyScope = "create"
+	ScopeDelete APIKeyScope = "delete"
+	ScopeStats  APIKeyScope = "stats"
+)
+
+// maxAPIKeyNameLen the limit of the name of the API key.
+const maxAPIKeyNameLen = 100
+
+// Errors of the API key requests.
+var (
+	ErrAPIKeyNameInvalid  = errors.New("the name of the key must be 1 to 100 characters")
+	ErrAPIKeyScopesEmpty  = errors.New("the key must have at least one scope")
+	ErrAPIKeyScopeUnknown = errors.New("unknown scope")
+)
+
+// APIKey the API key of the machine client acting as the user, only the hash of the key is stored.
+type APIKey struct {
+	ID         string        `json:"id" db:"id"`
+	UserID     string        `json:"-" db:"user_id"`
+	Name       string        `json:"name" db:"name"`
+	Prefix     string        `json:"prefix" db:"prefix"`
+	Hash       string        `json:"-" db:"key_hash"`
+	Scopes     []APIKeyScope `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// HasScope reports whether the key grants the scope.
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRequest the request of POST /api/user/keys.
+type APIKeyRequest struct {
+	Name   string        `json:"name"`
+	Scopes []APIKeyScope `json:"scopes"`
+}
+
+// Validate checks the name and the scopes of the key.
+func (r APIKeyRequest) Validate() error {
+	if name := strings.TrimSpace(r.Name); len(name) == 0 || len(name) > maxAPIKeyNameLen {
+		return ErrAPIKeyNameInvalid
+	}
+
+	if len(r.Scopes) == 0 {
+		return ErrAPIKeyScopesEmpty
+	}
+
+	for _, scope := range r.Scopes {
+		switch scope {
+		case ScopeRead, ScopeCreate, ScopeDelete, ScopeStats:
+		default:
+			return fmt.Errorf("%w: %s", ErrAPIKeyScopeUnknown, scope)
+		}
+	}
+
+	return nil
+}
+
+// APIKeyResponse the created key, the secret is returned only once.
+type APIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
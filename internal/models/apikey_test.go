@@ -0,0 +1,27 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     APIKeyRequest
+		wantErr error
+	}{
+		{name: "valid", req: APIKeyRequest{Name: "ci", Scopes: []APIKeyScope{ScopeRead, ScopeCreate}}},
+		{name: "empty name", req: APIKeyRequest{Name: " ", Scopes: []APIKeyScope{ScopeRead}}, wantErr: ErrAPIKeyNameInvalid},
+		{name: "long name", req: APIKeyRequest{Name: strings.Repeat("a", 101), Scopes: []APIKeyScope{ScopeRead}}, wantErr: ErrAPIKeyNameInvalid},
+		{name: "no scopes", req: APIKeyRequest{Name: "ci"}, wantErr: ErrAPIKeyScopesEmpty},
+		{name: "unknown scope", req: APIKeyRequest{Name: "ci", Scopes: []APIKeyScope{"admin"}}, wantErr: ErrAPIKeyScopeUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.req.Validate(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// StatsInterval the width of the click statistics bucket.
+type StatsInterval string
+
+// Supported click statistics intervals.
+const (
+	StatsIntervalHour StatsInterval = "hour"
+	StatsIntervalDay  StatsInterval = "day"
+)
+
+// Errors of the click statistics request.
+var (
+	// ErrStatsIntervalUnknown the interval is not one of the supported values.
+	ErrStatsIntervalUnknown = errors.New("the interval must be hour or day")
+	// ErrStatsRangeEmpty the beginning of the range is not before its end.
+	ErrStatsRangeEmpty = errors.New("from must be before to")
+)
+
+// Click describes a single redirect through a short link.
+type Click struct {
+	Code      string    `json:"code" db:"code"`
+	ClickedAt time.Time `json:"clicked_at" db:"clicked_at"`
+	Referrer  string    `json:"referrer" db:"referrer"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	IP        string    `json:"ip" db:"ip"`
+}
+
+// ClickBucket the number of clicks in one time series bucket.
+type ClickBucket struct {
+	Time   time.Time `json:"time"`
+	Clicks int       `json:"clicks"`
+}
+
+// ClickStatsResponse describes the response with the short link click statistics.
+type ClickStatsResponse struct {
+	Code     string        `json:"code"`
+	Total    int           `json:"total"`
+	Interval StatsInterval `json:"interval"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Buckets  []ClickBucket `json:"buckets"`
+}
+
+// Duration the width of the bucket.
+func (i StatsInterval) Duration() time.Duration {
+	if i == StatsIntervalDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// Validate checks that the interval is supported.
+func (i StatsInterval) Validate() error {
+	switch i {
+	case StatsIntervalHour, StatsIntervalDay:
+		return nil
+	}
+	return ErrStatsIntervalUnknown
+}
+
+// Truncate rounds the moment down to the beginning of its bucket in UTC.
+func (i StatsInterval) Truncate(t time.Time) time.Time {
+	return t.UTC().Truncate(i.Duration())
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// DeleteJobStatus the state of the background deletion of the user's links.
+type DeleteJobStatus string
+
+// Deletion job states.
+const (
+	DeleteJobPending DeleteJobStatus = "pending"
+	DeleteJobDone    DeleteJobStatus = "done"
+)
+
+// DeleteJob describes one DELETE /api/user/urls request processed in the background.
+//
+// Deleted counts the codes owned by the user that were marked as deleted,
+// Rejected counts the missing codes and the codes of other users.
+// Error holds the last storage error while the deletion is being retried.
+type DeleteJob struct {
+	ID         string          `json:"id"`
+	UserID     string          `json:"-"`
+	Status     DeleteJobStatus `json:"status"`
+	Requested  int             `json:"requested"`
+	Deleted    int             `json:"deleted"`
+	Rejected   int             `json:"rejected"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// DeleteJobResponse describes the response to the accepted deletion request.
+type DeleteJobResponse struct {
+	JobID     string `json:"job_id"`
+	StatusURL string `json:"status_url"`
+}
+
+// Record counts the result of one code and finishes the job once every code is counted.
+func (j *DeleteJob) Record(deleted bool, now time.Time) {
+	if deleted {
+		j.Deleted++
+	} else {
+		j.Rejected++
+	}
+
+	if j.Status == DeleteJobPending && j.Deleted+j.Rejected >= j.Requested {
+		j.Status = DeleteJobDone
+		j.Error = ""
+		j.FinishedAt = &now
+	}
+}
+
+// Retry remembers the storage error, the job stays pending until the deletion is retried.
+func (j *DeleteJob) Retry(err error) {
+	j.Error = err.Error()
+}
@@ -0,0 +1,16 @@
+package models
+
+// ErrorResponse describes the structured error response of the rejected request.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes why the request was rejected.
+//
+// Code is the machine readable reason, Field points to the rejected value of the request,
+// e.g. "url" or "[2].original_url" for the batch.
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
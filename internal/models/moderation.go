@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReasonMaxLength the longest reason of the moderator's action.
+const ReasonMaxLength = 500
+
+// Errors when validating the moderator's requests.
+var (
+	// ErrReasonRequired the reason of the action is empty.
+	ErrReasonRequired = errors.New("the reason is required")
+
+	// ErrReasonTooLong the reason exceeds the ReasonMaxLength.
+	ErrReasonTooLong = fmt.Errorf("the reason must be at most %d characters", ReasonMaxLength)
+)
+
+// AuditAction the moderator's action recorded in the audit trail.
+type AuditAction string
+
+// Audited actions.
+const (
+	AuditLinkDisabled AuditAction = "link.disable"
+	AuditLinkEnabled  AuditAction = "link.enable"
+	AuditUserBanned   AuditAction = "user.ban"
+	AuditUserUnbanned AuditAction = "user.unban"
+)
+
+// AuditEntry the record of the moderator's action.
+type AuditEntry struct {
+	ID      string      `json:"id" db:"id"`
+	AdminID string      `json:"admin_id" db:"admin_id"`
+	Action  AuditAction `json:"action" db:"action"`
+	// Target the code of the link or the ID of the user.
+	Target    string    `json:"target" db:"target"`
+	Reason    string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// UserBan the ban of the user creating the short links.
+type UserBan struct {
+	UserID   string    `json:"user_id" db:"user_id"`
+	Reason   string    `json:"reason" db:"reason"`
+	BannedBy string    `json:"banned_by" db:"banned_by"`
+	BannedAt time.Time `json:"banned_at" db:"banned_at"`
+}
+
+// DisableLinkRequest describes the request to disable the short link.
+//
+// The disabled link is served with 451 Unavailable For Legal Reasons when Legal is set,
+// otherwise with 410 Gone, the page shows the reason.
+type DisableLinkRequest struct {
+	Reason string `json:"reason"`
+	Legal  bool   `json:"legal,omitempty"`
+}
+
+// Validate validation of the input request.
+func (r DisableLinkRequest) Validate() error {
+	return validateReason(r.Reason)
+}
+
+// BanUserRequest describes the request to ban the user.
+type BanUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Validate validation of the input request.
+func (r BanUserRequest) Validate() error {
+	return validateReason(r.Reason)
+}
+
+// ShortLinkReviewResponse describes the short link in the moderator's listing.
+type ShortLinkReviewResponse struct {
+	Code           string     `json:"code"`
+	ShortURL       string     `json:"short_url"`
+	OriginalURL    string     `json:"original_url"`
+	UserID         string     `json:"user_id"`
+	IsDeleted      bool       `json:"is_deleted"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DisabledLegal  bool       `json:"disabled_legal,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ShortLinksReviewPageResponse describes the response with a page of the recent short links.
+type ShortLinksReviewPageResponse struct {
+	Links      []ShortLinkReviewResponse `json:"links"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
+
+// NewShortLinkReviewResponse the short link in the moderator's listing.
+func NewShortLinkReviewResponse(link ShortLink) ShortLinkReviewResponse {
+	return ShortLinkReviewResponse{
+		Code:           link.Code,
+		ShortURL:       link.ShortURL,
+		OriginalURL:    link.OriginalURL,
+		UserID:         link.UserID,
+		IsDeleted:      link.DeletedFlag,
+		DisabledReason: link.DisabledReason,
+		DisabledLegal:  link.DisabledLegal,
+		ExpiresAt:      link.ExpiresAt,
+		CreatedAt:      link.CreatedAt,
+	}
+}
+
+func validateReason(reason string) error {
+	reason = strings.TrimSpace(reason)
+
+	if len(reason) == 0 {
+		return ErrReasonRequired
+	}
+
+	if len([]rune(reason)) > ReasonMaxLength {
+		return ErrReasonTooLong
+	}
+
+	return nil
+}
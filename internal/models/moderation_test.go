@@ -0,0 +1,26 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisableLinkRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     DisableLinkRequest
+		wantErr error
+	}{
+		{name: "valid", req: DisableLinkRequest{Reason: "phishing", Legal: true}},
+		{name: "empty reason", req: DisableLinkRequest{Reason: "  "}, wantErr: ErrReasonRequired},
+		{name: "long reason", req: DisableLinkRequest{Reason: strings.Repeat("я", ReasonMaxLength+1)}, wantErr: ErrReasonTooLong},
+		{name: "longest reason", req: DisableLinkRequest{Reason: strings.Repeat("я", ReasonMaxLength)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.req.Validate(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ShortLinkSort the order of the user's short links in the listing.
+type ShortLinkSort string
+
+// Supported orders of the listing.
+const (
+	// SortCreatedAt the oldest links first.
+	SortCreatedAt ShortLinkSort = "created_at"
+	// SortCreatedAtDesc the newest links first.
+	SortCreatedAtDesc ShortLinkSort = "-created_at"
+)
+
+// Page size limits of the listing.
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+var (
+	// ErrSortUnknown the listing order is not supported.
+	ErrSortUnknown = errors.New("unknown sort, expected created_at or -created_at")
+	// ErrPageLimitInvalid the page size is out of range.
+	ErrPageLimitInvalid = fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+	// ErrCursorInvalid the cursor is malformed or was issued for another order.
+	ErrCursorInvalid = errors.New("invalid cursor")
+)
+
+// ShortLinksQuery the request of a page of the user's short links.
+type ShortLinksQuery struct {
+	UserID string
+	// Cursor the opaque position after which the page starts, empty for the first page.
+	Cursor string
+	Limit  int
+	Sort   ShortLinkSort
+	// Search the case-insensitive substring of the original URL.
+	Search string
+}
+
+// ShortLinksPage a page of the user's short links.
+type ShortLinksPage struct {
+	Links []ShortLink
+	// NextCursor the cursor of the next page, empty on the last page.
+	NextCursor string
+}
+
+// PageCursor the position in the listing: the sort key of the last link of the previous page.
+type PageCursor struct {
+	Sort      ShortLinkSort `json:"s"`
+	CreatedAt time.Time     `json:"t"`
+	Code      string        `json:"c"`
+}
+
+// NewShortLinksQuery the query of the first page with the default size and order.
+func NewShortLinksQuery(userID string) ShortLinksQuery {
+	return ShortLinksQuery{
+		UserID: userID,
+		Limit:  DefaultPageLimit,
+		Sort:   SortCreatedAt,
+	}
+}
+
+// Validate validation of the query.
+func (q ShortLinksQuery) Validate() error {
+	if q.Sort != SortCreatedAt && q.Sort != SortCreatedAtDesc {
+		return fmt.Errorf("%w: %q", ErrSortUnknown, q.Sort)
+	}
+
+	if q.Limit < 1 || q.Limit > MaxPageLimit {
+		return ErrPageLimitInvalid
+	}
+
+	_, err := q.After()
+
+	return err
+}
+
+// After decodes the cursor, nil for the first page.
+func (q ShortLinksQuery) After() (*PageCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrCursorInvalid
+	}
+
+	cursor := PageCursor{}
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Sort != q.Sort {
+		return nil, ErrCursorInvalid
+	}
+
+	return &cursor, nil
+}
+
+// Matches reports whether the original URL of the link contains the search string.
+func (q ShortLinksQuery) Matches(link ShortLink) bool {
+	return q.Search == "" || strings.Contains(strings.ToLower(link.OriginalURL), strings.ToLower(q.Search))
+}
+
+// Less reports whether the link a goes before the link b in the order of the query.
+func (q ShortLinksQuery) Less(a, b ShortLink) bool {
+	if q.Sort == SortCreatedAtDesc {
+		a, b = b, a
+	}
+	return a.CreatedAt.Before(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.Code < b.Code)
+}
+
+// IsAfter reports whether the link goes after the cursor in the order of the query.
+func (q ShortLinksQuery) IsAfter(link ShortLink, cursor *PageCursor) bool {
+	if cursor == nil {
+		return true
+	}
+	return q.Less(ShortLink{CreatedAt: cursor.CreatedAt, Code: cursor.Code}, link)
+}
+
+// Page sorts the matching links after the cursor and cuts the page of the query out of them.
+func (q ShortLinksQuery) Page(links []ShortLink) (*ShortLinksPage, error) {
+	cursor, err := q.After()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]ShortLink, 0, len(links))
+	for _, link := range links {
+		if q.Matches(link) && q.IsAfter(link, cursor) {
+			selected = append(selected, link)
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return q.Less(selected[i], selected[j])
+	})
+
+	return q.NewPage(selected), nil
+}
+
+// NewPage builds the page from the ordered links following the cursor;
+// one link more than the limit tells that there is the next page.
+func (q ShortLinksQuery) NewPage(links []ShortLink) *ShortLinksPage {
+	page := &ShortLinksPage{Links: links}
+
+	if len(links) > q.Limit {
+		page.Links = links[:q.Limit]
+		page.NextCursor = q.cursorOf(page.Links[q.Limit-1])
+	}
+
+	return page
+}
+
+func (q ShortLinksQuery) cursorOf(link ShortLink) string {
+	data, _ := json.Marshal(PageCursor{Sort: q.Sort, CreatedAt: link.CreatedAt, Code: link.Code})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
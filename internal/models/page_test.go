@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShortLinksQuery_Validate(t *testing.T) {
+	cursor := NewShortLinksQuery("user").cursorOf(ShortLink{Code: "a", CreatedAt: time.Now()})
+
+	tests := []struct {
+		name    string
+		modify  func(q *ShortLinksQuery)
+		wantErr error
+	}{
+		{
+			name:   "defaults",
+			modify: func(q *ShortLinksQuery) {},
+		},
+		{
+			name:   "cursor of the same order",
+			modify: func(q *ShortLinksQuery) { q.Cursor = cursor },
+		},
+		{
+			name:    "unknown sort",
+			modify:  func(q *ShortLinksQuery) { q.Sort = "code" },
+			wantErr: ErrSortUnknown,
+		},
+		{
+			name:    "zero limit",
+			modify:  func(q *ShortLinksQuery) { q.Limit = 0 },
+			wantErr: ErrPageLimitInvalid,
+		},
+		{
+			name:    "limit too large",
+			modify:  func(q *ShortLinksQuery) { q.Limit = MaxPageLimit + 1 },
+			wantErr: ErrPageLimitInvalid,
+		},
+		{
+			name:    "malformed cursor",
+			modify:  func(q *ShortLinksQuery) { q.Cursor = "!!!" },
+			wantErr: ErrCursorInvalid,
+		},
+		{
+			name: "cursor of another order",
+			modify: func(q *ShortLinksQuery) {
+				q.Cursor = cursor
+				q.Sort = SortCreatedAtDesc
+			},
+			wantErr: ErrCursorInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewShortLinksQuery("user")
+			tt.modify(&q)
+
+			if err := q.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShortLinksQuery_Page(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// у b и c одинаковое время создания, порядок между ними задаёт код
+	links := []ShortLink{
+		{Code: "c", OriginalURL: "http://ya.ru/c", CreatedAt: now.Add(time.Second)},
+		{Code: "a", OriginalURL: "http://YANDEX.ru/a", CreatedAt: now},
+		{Code: "d", OriginalURL: "http://yandex.ru/d", CreatedAt: now.Add(2 * time.Second)},
+		{Code: "b", OriginalURL: "http://yandex.ru/b", CreatedAt: now.Add(time.Second)},
+	}
+
+	tests := []struct {
+		name   string
+		sort   ShortLinkSort
+		search string
+		want   string
+	}{
+		{
+			name: "oldest first",
+			sort: SortCreatedAt,
+			want: "abcd",
+		},
+		{
+			name: "newest first",
+			sort: SortCreatedAtDesc,
+			want: "dcba",
+		},
+		{
+			name:   "search ignores case",
+			sort:   SortCreatedAt,
+			search: "yandex",
+			want:   "abd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewShortLinksQuery("user")
+			q.Sort = tt.sort
+			q.Search = tt.search
+			q.Limit = 1
+
+			got := ""
+			for {
+				page, err := q.Page(links)
+				if err != nil {
+					t.Fatalf("Page() error = %v", err)
+				}
+				for _, link := range page.Links {
+					got += link.Code
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+
+			if got != tt.want {
+				t.Errorf("Page() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
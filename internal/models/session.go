@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// RefreshToken the server side record of the refresh token, only the hash of the token is stored.
+type RefreshToken struct {
+	Hash      string     `db:"token_hash"`
+	UserID    string     `db:"user_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// Active reports whether the refresh token can be exchanged at now.
+func (t RefreshToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// TokenPair the response of the login and refresh requests.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// RefreshRequest the request of the refresh and logout requests.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
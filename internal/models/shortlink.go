@@ -2,16 +2,65 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alias restrictions.
+const (
+	AliasMaxLength = 32
+	AliasAlphabet  = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// BatchMaxSize the most links created or updated by one batch request.
+const BatchMaxSize = 1000
+
+// ErrBatchTooLarge the batch has more than BatchMaxSize links.
+var ErrBatchTooLarge = fmt.Errorf("the batch may contain at most %d links", BatchMaxSize)
+
+// ReservedAliases the words that cannot be used as an alias, they are occupied by the service routes.
+// The "delete-jobs" is the segment of the deletion job route under /api/user/urls.
+var ReservedAliases = []string{"api", "ping", "debug", "delete-jobs"}
+
+// Errors when validating the alias.
+var (
+	// ErrAliasTooLong the alias exceeds the AliasMaxLength.
+	ErrAliasTooLong = errors.New("the alias is too long")
+
+	// ErrAliasInvalidChars the alias contains characters outside the allowed set.
+	ErrAliasInvalidChars = errors.New("the alias may contain only latin letters, digits, '-' and '_'")
+
+	// ErrAliasReserved the alias is a reserved word.
+	ErrAliasReserved = errors.New("the alias is reserved")
+)
+
+// Errors when validating the link lifetime.
+var (
+	// ErrExpirationAmbiguous both expires_at and ttl_seconds were passed.
+	ErrExpirationAmbiguous = errors.New("only one of expires_at and ttl_seconds may be set")
+
+	// ErrTTLNegative the ttl_seconds is negative.
+	ErrTTLNegative = errors.New("the ttl_seconds must be positive")
+
+	// ErrExpiresAtInPast the expires_at is not in the future.
+	ErrExpiresAtInPast = errors.New("the expires_at must be in the future")
 )
 
 // ShortLink the short link model.
 type ShortLink struct {
-	UUID        string `json:"uuid" db:"id"`
-	UserID      string `json:"user_id" db:"user_id"`
-	Code        string `json:"code" db:"-"`
-	ShortURL    string `json:"short_url" db:"short_url"`
-	OriginalURL string `json:"original_url" db:"original_url"`
-	DeletedFlag bool   `json:"is_deleted" db:"is_deleted"`
+	UUID        string     `json:"uuid" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	Code        string     `json:"code" db:"-"`
+	ShortURL    string     `json:"short_url" db:"short_url"`
+	OriginalURL string     `json:"original_url" db:"original_url"`
+	DeletedFlag bool       `json:"is_deleted" db:"is_deleted"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	// DisabledReason why the moderator disabled the link, empty for the enabled link.
+	DisabledReason string `json:"disabled_reason,omitempty" db:"disabled_reason"`
+	// DisabledLegal the link is disabled for legal reasons.
+	DisabledLegal bool `json:"disabled_legal,omitempty" db:"disabled_legal"`
 }
 
 // ShortLinkResponse describes the server response.
@@ -21,13 +70,18 @@ type ShortLinkResponse struct {
 
 // ShortLinkRequest describes the client's request.
 type ShortLinkRequest struct {
-	URL string `json:"url"`
+	URL        string     `json:"url"`
+	Alias      string     `json:"alias,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds,omitempty"`
 }
 
 // ShortLinkBatchRequest describes the client's request.
 type ShortLinkBatchRequest struct {
-	CorrelationID string `json:"correlation_id"`
-	OriginalURL   string `json:"original_url"`
+	CorrelationID string     `json:"correlation_id"`
+	OriginalURL   string     `json:"original_url"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds    int64      `json:"ttl_seconds,omitempty"`
 }
 
 // ShortLinkBatchResponse describes the response of the short link list server.
@@ -42,10 +96,43 @@ type ShortLinkUserResponse struct {
 	ShortURL    string `json:"short_url"`
 }
 
+// ShortLinkUpdateRequest describes the request to change the original URL of the user's short link.
+type ShortLinkUpdateRequest struct {
+	URL string `json:"url"`
+}
+
+// ShortLinkDetailResponse describes the response with the user's short link.
+type ShortLinkDetailResponse struct {
+	Code           string     `json:"code"`
+	ShortURL       string     `json:"short_url"`
+	OriginalURL    string     `json:"original_url"`
+	IsDeleted      bool       `json:"is_deleted"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
 // StatsResponse response to a request for statistics on the short link service.
 type StatsResponse struct {
-	Urls  int `json:"urls"`
-	Users int `json:"users"`
+	Urls  int         `json:"urls"`
+	Users int         `json:"users"`
+	Cache *CacheStats `json:"cache,omitempty"`
+	Codes *CodeStats  `json:"codes,omitempty"`
+}
+
+// CacheStats hit and miss counters of the storage cache.
+type CacheStats struct {
+	Hits         uint64  `json:"hits"`
+	NegativeHits uint64  `json:"negative_hits"`
+	Misses       uint64  `json:"misses"`
+	HitRatio     float64 `json:"hit_ratio"`
+}
+
+// CodeStats counters of the generated short link codes.
+type CodeStats struct {
+	Generated  uint64 `json:"generated"`
+	Collisions uint64 `json:"collisions"`
+	Exhausted  uint64 `json:"exhausted"`
 }
 
 // Validate validation of the input request.
@@ -55,11 +142,102 @@ func (sl ShortLinkRequest) Validate() error {
 		err = errors.New("the URL field is required")
 	}
 
+	if err == nil && sl.Alias != "" {
+		err = ValidateAlias(sl.Alias)
+	}
+
+	if err == nil {
+		err = validateExpiration(sl.ExpiresAt, sl.TTLSeconds, time.Now())
+	}
+
 	return err
 }
 
+// Expiration the moment the requested link expires, nil if the link lives forever.
+func (sl ShortLinkRequest) Expiration(now time.Time) *time.Time {
+	return expiration(sl.ExpiresAt, sl.TTLSeconds, now)
+}
+
+// Validate validation of the input request.
+func (sl ShortLinkBatchRequest) Validate() error {
+	return validateExpiration(sl.ExpiresAt, sl.TTLSeconds, time.Now())
+}
+
+// Expiration the moment the requested link expires, nil if the link lives forever.
+func (sl ShortLinkBatchRequest) Expiration(now time.Time) *time.Time {
+	return expiration(sl.ExpiresAt, sl.TTLSeconds, now)
+}
+
+// IsDisabled reports whether the link is disabled by the moderator.
+func (sl ShortLink) IsDisabled() bool {
+	return sl.DisabledReason != ""
+}
+
+// IsExpired reports whether the link lifetime is over at the moment now.
+func (sl ShortLink) IsExpired(now time.Time) bool {
+	return sl.ExpiresAt != nil && !now.Before(*sl.ExpiresAt)
+}
+
+func validateExpiration(expiresAt *time.Time, ttlSeconds int64, now time.Time) error {
+	switch {
+	case expiresAt != nil && ttlSeconds != 0:
+		return ErrExpirationAmbiguous
+	case ttlSeconds < 0:
+		return ErrTTLNegative
+	case expiresAt != nil && !expiresAt.After(now):
+		return ErrExpiresAtInPast
+	}
+
+	return nil
+}
+
+func expiration(expiresAt *time.Time, ttlSeconds int64, now time.Time) *time.Time {
+	if expiresAt != nil {
+		t := expiresAt.UTC()
+		return &t
+	}
+
+	if ttlSeconds > 0 {
+		t := now.Add(time.Duration(ttlSeconds) * time.Second).UTC()
+		return &t
+	}
+
+	return nil
+}
+
+// Validate validation of the input request.
+func (r ShortLinkUpdateRequest) Validate() error {
+	if r.URL == "" {
+		return errors.New("the URL field is required")
+	}
+
+	return nil
+}
+
+// ValidateAlias checks the alias against the allowed character set, the length limit and the reserved words.
+func ValidateAlias(alias string) error {
+	if len(alias) > AliasMaxLength {
+		return ErrAliasTooLong
+	}
+
+	for _, r := range alias {
+		if !strings.ContainsRune(AliasAlphabet, r) {
+			return ErrAliasInvalidChars
+		}
+	}
+
+	for _, reserved := range ReservedAliases {
+		if strings.EqualFold(alias, reserved) {
+			return ErrAliasReserved
+		}
+	}
+
+	return nil
+}
+
 // Message описывает объект сообщения
 type Message struct {
-	UserID string // пользователь
-	Code   string // код ссылки
+	JobID  string `json:"job_id" db:"job_id"`   // задание на удаление
+	UserID string `json:"user_id" db:"user_id"` // пользователь
+	Code   string `json:"code" db:"code"`       // код ссылки
 }
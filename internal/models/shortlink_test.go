@@ -0,0 +1,115 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr error
+	}{
+		{
+			name:  "valid alias",
+			alias: "q3-report_2023",
+		},
+		{
+			name:    "too long",
+			alias:   strings.Repeat("a", AliasMaxLength+1),
+			wantErr: ErrAliasTooLong,
+		},
+		{
+			name:    "invalid chars",
+			alias:   "q3/report",
+			wantErr: ErrAliasInvalidChars,
+		},
+		{
+			name:    "reserved word",
+			alias:   "Ping",
+			wantErr: ErrAliasReserved,
+		},
+		{
+			name:    "reserved route segment",
+			alias:   "delete-jobs",
+			wantErr: ErrAliasReserved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAlias(tt.alias); err != tt.wantErr {
+				t.Errorf("ValidateAlias() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShortLinkRequest_Expiration(t *testing.T) {
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		req     ShortLinkRequest
+		want    *time.Time
+		wantErr error
+	}{
+		{
+			name: "without lifetime",
+			req:  ShortLinkRequest{URL: "https://practicum.yandex.ru/"},
+		},
+		{
+			name: "ttl seconds",
+			req:  ShortLinkRequest{URL: "https://practicum.yandex.ru/", TTLSeconds: 3600},
+			want: &expiresAt,
+		},
+		{
+			name: "expires at",
+			req:  ShortLinkRequest{URL: "https://practicum.yandex.ru/", ExpiresAt: &expiresAt},
+			want: &expiresAt,
+		},
+		{
+			name:    "both set",
+			req:     ShortLinkRequest{URL: "https://practicum.yandex.ru/", ExpiresAt: &expiresAt, TTLSeconds: 10},
+			wantErr: ErrExpirationAmbiguous,
+		},
+		{
+			name:    "negative ttl",
+			req:     ShortLinkRequest{URL: "https://practicum.yandex.ru/", TTLSeconds: -1},
+			wantErr: ErrTTLNegative,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateExpiration(tt.req.ExpiresAt, tt.req.TTLSeconds, now); err != tt.wantErr {
+				t.Errorf("validateExpiration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			got := tt.req.Expiration(now)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("Expiration() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortLink_IsExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Second)
+	future := now.Add(time.Second)
+
+	if (ShortLink{}).IsExpired(now) {
+		t.Error("link without expiration must not expire")
+	}
+	if !(ShortLink{ExpiresAt: &past}).IsExpired(now) {
+		t.Error("link must be expired")
+	}
+	if (ShortLink{ExpiresAt: &future}).IsExpired(now) {
+		t.Error("link must not be expired yet")
+	}
+}
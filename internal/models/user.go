@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Limits of the password, bcrypt ignores the bytes after the 72nd.
+const (
+	minPasswordLen = 8
+	maxPasswordLen = 72
+)
+
+// Errors of the registration and login requests.
+var (
+	ErrEmailInvalid    = errors.New("the email is invalid")
+	ErrPasswordInvalid = errors.New("the password must be 8 to 72 bytes")
+)
+
+// User the registered account, the links of the account are kept under its ID.
+type User struct {
+	ID           string    `json:"id" db:"id"`
+	Email        string    `json:"email" db:"email"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// CredentialsRequest the request of POST /api/auth/register and POST /api/auth/login.
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// NormalizedEmail the email compared case-insensitively.
+func (r CredentialsRequest) NormalizedEmail() string {
+	return strings.ToLower(strings.TrimSpace(r.Email))
+}
+
+// Validate checks the email and the length of the password.
+func (r CredentialsRequest) Validate() error {
+	address, err := mail.ParseAddress(r.NormalizedEmail())
+	// адрес с отображаемым именем вида "Name <a@b>" не принимается
+	if err != nil || address.Address != r.NormalizedEmail() {
+		return ErrEmailInvalid
+	}
+
+	if len(r.Password) < minPasswordLen || len(r.Password) > maxPasswordLen {
+		return ErrPasswordInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialsRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CredentialsRequest
+		wantErr error
+	}{
+		{name: "valid", req: CredentialsRequest{Email: " User@Example.com ", Password: "correct horse"}},
+		{name: "invalid email", req: CredentialsRequest{Email: "user", Password: "correct horse"}, wantErr: ErrEmailInvalid},
+		{name: "display name", req: CredentialsRequest{Email: "User <user@example.com>", Password: "correct horse"}, wantErr: ErrEmailInvalid},
+		{name: "short password", req: CredentialsRequest{Email: "user@example.com", Password: "short"}, wantErr: ErrPasswordInvalid},
+		{name: "long password", req: CredentialsRequest{Email: "user@example.com", Password: strings.Repeat("a", 73)}, wantErr: ErrPasswordInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.req.Validate(), tt.wantErr)
+		})
+	}
+}
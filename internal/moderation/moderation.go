@@ -0,0 +1,148 @@
+// Package moderation implements the actions of the admins on the short links and the users.
+package moderation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Errors of the moderation.
+var (
+	ErrorUserBanned = errors.New("the user is banned")
+	ErrorBanAdmin   = errors.New("an admin cannot be banned")
+)
+
+// Moderator - structure describing the moderation of the short links.
+//
+// The admins are the users listed in the configuration. They disable the links,
+// ban the users from creating new ones, and every action is written to the audit trail.
+type Moderator struct {
+	links  repository.Storage
+	bans   repository.BanStore
+	audit  repository.AuditStore
+	admins map[string]struct{}
+}
+
+// NewModerator constructor creates the Moderator of the links with the bans in bans and the audit trail in audit.
+func NewModerator(links repository.Storage, bans repository.BanStore, audit repository.AuditStore, cfg config.Admin) *Moderator {
+	admins := make(map[string]struct{})
+	for _, id := range strings.Split(cfg.UserIDs, ",") {
+		if id = strings.TrimSpace(id); len(id) > 0 {
+			admins[id] = struct{}{}
+		}
+	}
+
+	return &Moderator{links: links, bans: bans, audit: audit, admins: admins}
+}
+
+// IsAdmin reports whether the user is allowed to moderate.
+func (m *Moderator) IsAdmin(userID string) bool {
+	_, ok := m.admins[userID]
+	return ok
+}
+
+// CheckUser returns ErrorUserBanned when the user may not create the links.
+func (m *Moderator) CheckUser(ctx context.Context, userID string) error {
+	_, err := m.bans.GetBan(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return ErrorUserBanned
+}
+
+// RecentLinks the page of the recently created links of all users.
+func (m *Moderator) RecentLinks(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	return m.links.RecentShortLinks(ctx, query)
+}
+
+// DisableLink disables the link, it is served with the reason instead of the redirect.
+func (m *Moderator) DisableLink(ctx context.Context, adminID, code string, req models.DisableLinkRequest) (*models.ShortLink, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	shortLink, err := m.links.DisableShortLink(ctx, code, reason, req.Legal)
+	if err != nil {
+		return nil, err
+	}
+
+	return shortLink, m.record(ctx, adminID, models.AuditLinkDisabled, code, reason)
+}
+
+// EnableLink cancels the disabling of the link.
+func (m *Moderator) EnableLink(ctx context.Context, adminID, code string) (*models.ShortLink, error) {
+	shortLink, err := m.links.EnableShortLink(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return shortLink, m.record(ctx, adminID, models.AuditLinkEnabled, code, "")
+}
+
+// BanUser bans the user from creating the links, the existing links stay as they are.
+func (m *Moderator) BanUser(ctx context.Context, adminID, userID string, req models.BanUserRequest) (*models.UserBan, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if m.IsAdmin(userID) {
+		return nil, ErrorBanAdmin
+	}
+
+	ban := models.UserBan{
+		UserID:   userID,
+		Reason:   strings.TrimSpace(req.Reason),
+		BannedBy: adminID,
+		BannedAt: time.Now().UTC(),
+	}
+	if err := m.bans.BanUser(ctx, ban); err != nil {
+		return nil, err
+	}
+
+	return &ban, m.record(ctx, adminID, models.AuditUserBanned, userID, ban.Reason)
+}
+
+// UnbanUser lifts the ban of the user, returns repository.ErrNotFound when the user is not banned.
+func (m *Moderator) UnbanUser(ctx context.Context, adminID, userID string) error {
+	if err := m.bans.UnbanUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return m.record(ctx, adminID, models.AuditUserUnbanned, userID, "")
+}
+
+// AuditLog the latest actions of the admins, the newest first.
+func (m *Moderator) AuditLog(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	return m.audit.AuditEntries(ctx, limit)
+}
+
+// record writes the action to the audit trail, the action itself is already done.
+func (m *Moderator) record(ctx context.Context, adminID string, action models.AuditAction, target, reason string) error {
+	entry := models.AuditEntry{
+		ID:        uuid.New().String(),
+		AdminID:   adminID,
+		Action:    action,
+		Target:    target,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	logger.Log.Info("moderation action",
+		zap.String("admin_id", adminID), zap.String("action", string(action)), zap.String("target", target))
+
+	return m.audit.AppendAudit(ctx, entry)
+}
@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/Orendev/shortener/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModerator(t *testing.T) {
+	ctx := context.Background()
+
+	links, err := memory.NewMemory(config.File{FileStoragePath: filepath.Join(t.TempDir(), "links.json")})
+	require.NoError(t, err)
+	defer links.Close()
+
+	bans, err := memory.NewBans("")
+	require.NoError(t, err)
+	audit, err := memory.NewAudit("")
+	require.NoError(t, err)
+
+	m := NewModerator(links, bans, audit, config.Admin{UserIDs: " admin , root,"})
+	assert.True(t, m.IsAdmin("admin"))
+	assert.True(t, m.IsAdmin("root"))
+	assert.False(t, m.IsAdmin(""))
+	assert.False(t, m.IsAdmin("user"))
+
+	require.NoError(t, links.Save(ctx, models.ShortLink{UUID: "1", Code: "phish", OriginalURL: "https://phish.example", UserID: "user"}))
+
+	_, err = m.DisableLink(ctx, "admin", "phish", models.DisableLinkRequest{Reason: " "})
+	assert.ErrorIs(t, err, models.ErrReasonRequired)
+	_, err = m.DisableLink(ctx, "admin", "missing", models.DisableLinkRequest{Reason: "spam"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	link, err := m.DisableLink(ctx, "admin", "phish", models.DisableLinkRequest{Reason: " phishing ", Legal: true})
+	require.NoError(t, err)
+	assert.Equal(t, "phishing", link.DisabledReason)
+	assert.True(t, link.DisabledLegal)
+
+	link, err = m.EnableLink(ctx, "root", "phish")
+	require.NoError(t, err)
+	assert.False(t, link.IsDisabled())
+
+	require.NoError(t, m.CheckUser(ctx, "user"))
+	_, err = m.BanUser(ctx, "admin", "root", models.BanUserRequest{Reason: "oops"})
+	assert.ErrorIs(t, err, ErrorBanAdmin)
+
+	ban, err := m.BanUser(ctx, "admin", "user", models.BanUserRequest{Reason: "phishing"})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", ban.BannedBy)
+	assert.ErrorIs(t, m.CheckUser(ctx, "user"), ErrorUserBanned)
+
+	require.NoError(t, m.UnbanUser(ctx, "admin", "user"))
+	require.NoError(t, m.CheckUser(ctx, "user"))
+	assert.ErrorIs(t, m.UnbanUser(ctx, "admin", "user"), repository.ErrNotFound)
+
+	// в журнал попадают только выполненные действия
+	entries, err := m.AuditLog(ctx, 10)
+	require.NoError(t, err)
+	actions := make([]models.AuditAction, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []models.AuditAction{
+		models.AuditUserUnbanned, models.AuditUserBanned, models.AuditLinkEnabled, models.AuditLinkDisabled,
+	}, actions)
+	assert.Equal(t, "root", entries[2].AdminID)
+	assert.Equal(t, "phishing", entries[3].Reason)
+}
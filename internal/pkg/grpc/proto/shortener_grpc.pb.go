@@ -23,6 +23,10 @@ const (
 	ShortenerService_GetAPIStats_FullMethodName    = "/grpcshortener.ShortenerService/GetAPIStats"
 	ShortenerService_SaveAPIShorten_FullMethodName = "/grpcshortener.ShortenerService/SaveAPIShorten"
 	ShortenerService_Ping_FullMethodName           = "/grpcshortener.ShortenerService/Ping"
+	ShortenerService_Shorten_FullMethodName        = "/grpcshortener.ShortenerService/Shorten"
+	ShortenerService_ShortenBatch_FullMethodName   = "/grpcshortener.ShortenerService/ShortenBatch"
+	ShortenerService_DeleteUserUrls_FullMethodName = "/grpcshortener.ShortenerService/DeleteUserUrls"
+	ShortenerService_ResolveCode_FullMethodName    = "/grpcshortener.ShortenerService/ResolveCode"
 )
 
 // ShortenerServiceClient is the client API for ShortenerService service.
@@ -33,6 +37,10 @@ type ShortenerServiceClient interface {
 	GetAPIStats(ctx context.Context, in *APIStatsRequest, opts ...grpc.CallOption) (*APIStatsResponse, error)
 	SaveAPIShorten(ctx context.Context, in *APIShortenRequest, opts ...grpc.CallOption) (*APIShortenResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error)
+	ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error)
+	DeleteUserUrls(ctx context.Context, in *DeleteUserUrlsRequest, opts ...grpc.CallOption) (*DeleteUserUrlsResponse, error)
+	ResolveCode(ctx context.Context, in *ResolveCodeRequest, opts ...grpc.CallOption) (*ResolveCodeResponse, error)
 }
 
 type shortenerServiceClient struct {
@@ -79,6 +87,42 @@ func (c *shortenerServiceClient) Ping(ctx context.Context, in *PingRequest, opts
 	return out, nil
 }
 
+func (c *shortenerServiceClient) Shorten(ctx context.Context, in *ShortenRequest, opts ...grpc.CallOption) (*ShortenResponse, error) {
+	out := new(ShortenResponse)
+	err := c.cc.Invoke(ctx, ShortenerService_Shorten_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) ShortenBatch(ctx context.Context, in *ShortenBatchRequest, opts ...grpc.CallOption) (*ShortenBatchResponse, error) {
+	out := new(ShortenBatchResponse)
+	err := c.cc.Invoke(ctx, ShortenerService_ShortenBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) DeleteUserUrls(ctx context.Context, in *DeleteUserUrlsRequest, opts ...grpc.CallOption) (*DeleteUserUrlsResponse, error) {
+	out := new(DeleteUserUrlsResponse)
+	err := c.cc.Invoke(ctx, ShortenerService_DeleteUserUrls_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerServiceClient) ResolveCode(ctx context.Context, in *ResolveCodeRequest, opts ...grpc.CallOption) (*ResolveCodeResponse, error) {
+	out := new(ResolveCodeResponse)
+	err := c.cc.Invoke(ctx, ShortenerService_ResolveCode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShortenerServiceServer is the server API for ShortenerService service.
 // All implementations must embed UnimplementedShortenerServiceServer
 // for forward compatibility
@@ -87,6 +131,10 @@ type ShortenerServiceServer interface {
 	GetAPIStats(context.Context, *APIStatsRequest) (*APIStatsResponse, error)
 	SaveAPIShorten(context.Context, *APIShortenRequest) (*APIShortenResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error)
+	ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error)
+	DeleteUserUrls(context.Context, *DeleteUserUrlsRequest) (*DeleteUserUrlsResponse, error)
+	ResolveCode(context.Context, *ResolveCodeRequest) (*ResolveCodeResponse, error)
 	mustEmbedUnimplementedShortenerServiceServer()
 }
 
@@ -106,6 +154,18 @@ func (UnimplementedShortenerServiceServer) SaveAPIShorten(context.Context, *APIS
 func (UnimplementedShortenerServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedShortenerServiceServer) Shorten(context.Context, *ShortenRequest) (*ShortenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shorten not implemented")
+}
+func (UnimplementedShortenerServiceServer) ShortenBatch(context.Context, *ShortenBatchRequest) (*ShortenBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShortenBatch not implemented")
+}
+func (UnimplementedShortenerServiceServer) DeleteUserUrls(context.Context, *DeleteUserUrlsRequest) (*DeleteUserUrlsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserUrls not implemented")
+}
+func (UnimplementedShortenerServiceServer) ResolveCode(context.Context, *ResolveCodeRequest) (*ResolveCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveCode not implemented")
+}
 func (UnimplementedShortenerServiceServer) mustEmbedUnimplementedShortenerServiceServer() {}
 
 // UnsafeShortenerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -191,6 +251,78 @@ func _ShortenerService_Ping_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ShortenerService_Shorten_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).Shorten(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_Shorten_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).Shorten(ctx, req.(*ShortenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_ShortenBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortenBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).ShortenBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_ShortenBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).ShortenBatch(ctx, req.(*ShortenBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_DeleteUserUrls_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserUrlsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).DeleteUserUrls(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_DeleteUserUrls_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).DeleteUserUrls(ctx, req.(*DeleteUserUrlsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShortenerService_ResolveCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServiceServer).ResolveCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShortenerService_ResolveCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServiceServer).ResolveCode(ctx, req.(*ResolveCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ShortenerService_ServiceDesc is the grpc.ServiceDesc for ShortenerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -214,6 +346,22 @@ var ShortenerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _ShortenerService_Ping_Handler,
 		},
+		{
+			MethodName: "Shorten",
+			Handler:    _ShortenerService_Shorten_Handler,
+		},
+		{
+			MethodName: "ShortenBatch",
+			Handler:    _ShortenerService_ShortenBatch_Handler,
+		},
+		{
+			MethodName: "DeleteUserUrls",
+			Handler:    _ShortenerService_DeleteUserUrls_Handler,
+		},
+		{
+			MethodName: "ResolveCode",
+			Handler:    _ShortenerService_ResolveCode_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "shortener.proto",
@@ -1,9 +0,0 @@
-package random
-
-import "fmt"
-
-func ExampleStrn() {
-	n := 8
-	str := Strn(n)
-	fmt.Println(str)
-}
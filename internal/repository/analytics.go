@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// AnalyticsStorage interface for the short link click storage.
+type AnalyticsStorage interface {
+	SaveClicks(ctx context.Context, clicks []models.Click) error
+	ClicksTotal(ctx context.Context, code string) (int, error)
+	ClicksSeries(ctx context.Context, code string, interval models.StatsInterval, from, to time.Time) ([]models.ClickBucket, error)
+}
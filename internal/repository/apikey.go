@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// APIKeyStorage interface for the storage of the API keys.
+type APIKeyStorage interface {
+	SaveAPIKey(ctx context.Context, key models.APIKey) error
+	APIKeysByUserID(ctx context.Context, userID string) ([]models.APIKey, error)
+	// GetAPIKeyByHash returns ErrNotFound for the unknown hash.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// RevokeAPIKey reports false when the user has no such active key.
+	RevokeAPIKey(ctx context.Context, id, userID string, at time.Time) (bool, error)
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
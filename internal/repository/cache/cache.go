@@ -0,0 +1,46 @@
+// Package cache implements a read-through caching decorator for repository.Storage
+// with the in-process LRU and the RESP (Redis protocol) cache backends.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+)
+
+// Supported cache backends.
+const (
+	// TypeLRU the in-process LRU cache.
+	TypeLRU = "lru"
+	// TypeRESP the cache server speaking the Redis protocol.
+	TypeRESP = "resp"
+)
+
+// ErrTypeUnknown the cache type is not one of lru or resp.
+var ErrTypeUnknown = errors.New("unknown cache type, expected lru or resp")
+
+// Cache interface for the key-value cache backend.
+type Cache interface {
+	// Get returns the value of the key, ok is false if the key is missing or has expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value of the key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys.
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// New creates the cache backend selected by cfg.Type.
+func New(cfg config.Cache) (Cache, error) {
+	switch cfg.Type {
+	case TypeLRU:
+		return NewLRU(cfg.Size), nil
+	case TypeRESP:
+		return NewRESP(cfg.Addr, cfg.PoolSize), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrTypeUnknown, cfg.Type)
+}
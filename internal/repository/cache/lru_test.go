@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+
+	// обращение к a делает вытесняемым b
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+	assert.Equal(t, 2, c.Len())
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "c")
+	assert.True(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "ttl", []byte("4"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, _ = c.Get(ctx, "ttl")
+	assert.False(t, ok)
+}
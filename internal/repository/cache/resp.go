@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// respTimeout the deadline of a command when the context has none.
+const respTimeout = time.Second
+
+// ErrRESPUnexpectedReply the server answered with a reply of unexpected type.
+var ErrRESPUnexpectedReply = errors.New("unexpected RESP reply")
+
+// RESPError the error reply of the server.
+type RESPError string
+
+// Error implements the error interface.
+func (e RESPError) Error() string {
+	return "resp: " + string(e)
+}
+
+// RESP - structure describing the client of a cache server speaking the Redis protocol.
+//
+// The connections are kept in a pool of at most poolSize idle connections
+// and dialed on demand; a connection is dropped after any I/O error.
+type RESP struct {
+	addr string
+	pool chan *respConn
+}
+
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRESP - constructor a new instance of RESP.
+func NewRESP(addr string, poolSize int) *RESP {
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+
+	return &RESP{
+		addr: addr,
+		pool: make(chan *respConn, poolSize),
+	}
+}
+
+// Get returns the value of the key, ok is false if the key is missing or has expired.
+func (c *RESP) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", []byte(key))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("%w %T to GET", ErrRESPUnexpectedReply, reply)
+	}
+
+	return value, true, nil
+}
+
+// Set stores the value of the key for ttl, zero ttl keeps the key until it is evicted.
+func (c *RESP) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := [][]byte{[]byte(key), value}
+	if ttl > 0 {
+		args = append(args, []byte("PX"), []byte(strconv.FormatInt(ttl.Milliseconds(), 10)))
+	}
+
+	_, err := c.do(ctx, "SET", args...)
+
+	return err
+}
+
+// Delete removes the keys.
+func (c *RESP) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, []byte(key))
+	}
+
+	_, err := c.do(ctx, "DEL", args...)
+
+	return err
+}
+
+// Ping service check.
+func (c *RESP) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (c *RESP) Close() error {
+	var err error
+
+	for {
+		select {
+		case rc := <-c.pool:
+			if errClose := rc.conn.Close(); err == nil {
+				err = errClose
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// do sends the command and reads its reply.
+func (c *RESP) do(ctx context.Context, cmd string, args ...[]byte) (any, error) {
+	rc, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(respTimeout)
+	}
+
+	reply, err := rc.exec(deadline, cmd, args...)
+
+	var respErr RESPError
+	if err != nil && !errors.As(err, &respErr) {
+		// после ошибки ввода-вывода состояние соединения неизвестно
+		_ = rc.conn.Close()
+		return nil, err
+	}
+
+	select {
+	case c.pool <- rc:
+	default:
+		_ = rc.conn.Close()
+	}
+
+	return reply, err
+}
+
+// conn takes an idle connection from the pool or dials a new one.
+func (c *RESP) conn(ctx context.Context) (*respConn, error) {
+	select {
+	case rc := <-c.pool:
+		return rc, nil
+	default:
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respConn{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+	}, nil
+}
+
+// exec writes the command and reads the reply.
+func (rc *respConn) exec(deadline time.Time, cmd string, args ...[]byte) (any, error) {
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := rc.conn.Write(encodeCommand(cmd, args...)); err != nil {
+		return nil, err
+	}
+
+	return readReply(rc.r)
+}
+
+// encodeCommand encodes the command as an array of bulk strings.
+func encodeCommand(cmd string, args ...[]byte) []byte {
+	buf := make([]byte, 0, 64)
+
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)+1), 10)
+	buf = append(buf, '\r', '\n')
+
+	for _, arg := range append([][]byte{[]byte(cmd)}, args...) {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	return buf
+}
+
+// readReply reads one reply: a simple string, an error, an integer, a bulk string or an array.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("%w: malformed line %q", ErrRESPUnexpectedReply, line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RESPError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	case '*':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+
+		items := make([]any, 0, size)
+		for i := 0; i < size; i++ {
+			item, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown type %q", ErrRESPUnexpectedReply, line[0])
+}
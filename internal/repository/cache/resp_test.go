@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// respServer a local stand-in of a Redis server supporting PING, GET, SET with PX and DEL.
+type respServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	data     map[string]string
+	expires  map[string]time.Time
+}
+
+func newRESPServer(t *testing.T) *respServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &respServer{
+		listener: listener,
+		data:     make(map[string]string),
+		expires:  make(map[string]time.Time),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	return srv
+}
+
+func (s *respServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *respServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		items, _ := reply.([]any)
+		args := make([]string, 0, len(items))
+		for _, item := range items {
+			arg, _ := item.([]byte)
+			args = append(args, string(arg))
+		}
+
+		if _, err = io.WriteString(conn, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *respServer) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(args) == 0 {
+		return "-ERR empty command\r\n"
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		value, ok := s.data[args[1]]
+		if expiresAt, exp := s.expires[args[1]]; exp && !time.Now().Before(expiresAt) {
+			ok = false
+		}
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+	case "SET":
+		s.data[args[1]] = args[2]
+		delete(s.expires, args[1])
+		if len(args) == 5 && strings.EqualFold(args[3], "PX") {
+			ms, _ := strconv.Atoi(args[4])
+			s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		count := 0
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				count++
+			}
+		}
+		return ":" + strconv.Itoa(count) + "\r\n"
+	}
+
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func TestRESP(t *testing.T) {
+	ctx := context.Background()
+	srv := newRESPServer(t)
+
+	c := NewRESP(srv.addr(), 2)
+	defer c.Close()
+
+	require.NoError(t, c.Ping(ctx))
+
+	_, ok, err := c.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "key", []byte("value\r\nwith crlf"), 0))
+	value, ok, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value\r\nwith crlf"), value)
+
+	// пустое значение отличается от отсутствующего ключа
+	require.NoError(t, c.Set(ctx, "empty", nil, time.Minute))
+	value, ok, err = c.Get(ctx, "empty")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, value)
+
+	require.NoError(t, c.Set(ctx, "ttl", []byte("1"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err = c.Get(ctx, "ttl")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "key", "empty"))
+	_, ok, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// ошибка сервера не рвёт соединение
+	_, err = c.do(ctx, "UNKNOWN")
+	var respErr RESPError
+	assert.ErrorAs(t, err, &respErr)
+	require.NoError(t, c.Ping(ctx))
+}
+
+func TestRESP_Unavailable(t *testing.T) {
+	srv := newRESPServer(t)
+	addr := srv.addr()
+	require.NoError(t, srv.listener.Close())
+
+	c := NewRESP(addr, 1)
+	_, _, err := c.Get(context.Background(), "key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,315 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Prefixes of the cache keys.
+const (
+	codeKeyPrefix = "shortener:code:"
+	urlKeyPrefix  = "shortener:url:"
+)
+
+// Storage - structure describing the caching decorator of a repository.Storage.
+//
+// GetByCode and GetByOriginalURL are read through the cache: the link is cached by code
+// and the original URL points to the code. A missing key is cached as an empty value
+// for negativeTTL. The writes go to the wrapped storage first and then drop the affected keys.
+// The links soft-deleted by DeleteExpired stay in the cache until ttl runs out.
+type Storage struct {
+	repository.Storage
+	cache        Cache
+	ttl          time.Duration
+	negativeTTL  time.Duration
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+}
+
+// NewStorage - constructor a new instance of Storage wrapping the repo.
+func NewStorage(repo repository.Storage, cache Cache, ttl, negativeTTL time.Duration) *Storage {
+	return &Storage{
+		Storage:     repo,
+		cache:       cache,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// GetByCode we get a model models.ShortLink of a short link by code.
+func (s *Storage) GetByCode(ctx context.Context, code string) (*models.ShortLink, error) {
+	key := codeKeyPrefix + code
+
+	if value, ok := s.get(ctx, key); ok {
+		if len(value) == 0 {
+			s.negativeHits.Add(1)
+			return nil, repository.ErrNotFound
+		}
+
+		shortLink := models.ShortLink{}
+		if err := json.Unmarshal(value, &shortLink); err == nil {
+			s.hits.Add(1)
+			return &shortLink, nil
+		}
+	}
+
+	s.misses.Add(1)
+
+	shortLink, err := s.Storage.GetByCode(ctx, code)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.set(ctx, key, nil, s.negativeTTL)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.setLink(ctx, shortLink)
+
+	return shortLink, nil
+}
+
+// GetByOriginalURL we will get the model with a short link models.ShortLink to the original URL.
+func (s *Storage) GetByOriginalURL(ctx context.Context, originalURL string) (*models.ShortLink, error) {
+	key := urlKeyPrefix + originalURL
+
+	if value, ok := s.get(ctx, key); ok {
+		if len(value) == 0 {
+			s.negativeHits.Add(1)
+			return nil, repository.ErrNotFound
+		}
+
+		// ссылка могла сменить URL, тогда указатель устарел
+		shortLink, ok := s.cachedLink(ctx, string(value))
+		if ok && shortLink.OriginalURL == originalURL {
+			s.hits.Add(1)
+			return shortLink, nil
+		}
+	}
+
+	s.misses.Add(1)
+
+	shortLink, err := s.Storage.GetByOriginalURL(ctx, originalURL)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.set(ctx, key, nil, s.negativeTTL)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.set(ctx, key, []byte(shortLink.Code), s.ttl)
+	s.setLink(ctx, shortLink)
+
+	return shortLink, nil
+}
+
+// Save let's save the model of the short link models.ShortLink.
+func (s *Storage) Save(ctx context.Context, model models.ShortLink) error {
+	err := s.Storage.Save(ctx, model)
+
+	// сбрасываем закешированное отсутствие ссылки
+	s.invalidate(ctx, model)
+
+	return err
+}
+
+// InsertBatch group insertion of short link models []models.ShortLink.
+func (s *Storage) InsertBatch(ctx context.Context, shortLinks []models.ShortLink) error {
+	err := s.Storage.InsertBatch(ctx, shortLinks)
+
+	s.invalidate(ctx, shortLinks...)
+
+	return err
+}
+
+// UpdateBatch group update of short link models []models.ShortLink.
+func (s *Storage) UpdateBatch(ctx context.Context, shortLinks []models.ShortLink) error {
+	// старые код и URL ссылки тоже надо сбросить
+	affected := make([]models.ShortLink, 0, 2*len(shortLinks))
+	for _, link := range shortLinks {
+		affected = append(affected, link)
+		if old, err := s.Storage.GetByID(ctx, link.UUID); err == nil {
+			affected = append(affected, *old)
+		}
+	}
+
+	err := s.Storage.UpdateBatch(ctx, shortLinks)
+
+	s.invalidate(ctx, affected...)
+
+	return err
+}
+
+// DeleteFlagBatch group delete of the user's short links, returns the codes that were deleted.
+func (s *Storage) DeleteFlagBatch(ctx context.Context, codes []string, userID string) ([]string, error) {
+	deleted, err := s.Storage.DeleteFlagBatch(ctx, codes, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(deleted))
+	for _, code := range deleted {
+		keys = append(keys, codeKeyPrefix+code)
+	}
+	s.delete(ctx, keys...)
+
+	return deleted, nil
+}
+
+// UpdateOriginalURL changes the original URL of the user's short link.
+func (s *Storage) UpdateOriginalURL(ctx context.Context, code, userID, originalURL string) (*models.ShortLink, error) {
+	shortLink, err := s.Storage.UpdateOriginalURL(ctx, code, userID, originalURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// указатель со старого URL отбрасывается при чтении, достаточно сбросить код и новый URL
+	s.invalidate(ctx, *shortLink)
+
+	return shortLink, nil
+}
+
+// Restore cancels the soft delete of the user's short link.
+func (s *Storage) Restore(ctx context.Context, code, userID string) (*models.ShortLink, error) {
+	shortLink, err := s.Storage.Restore(ctx, code, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, *shortLink)
+
+	return shortLink, nil
+}
+
+// DisableShortLink disables the link with the reason.
+func (s *Storage) DisableShortLink(ctx context.Context, code, reason string, legal bool) (*models.ShortLink, error) {
+	shortLink, err := s.Storage.DisableShortLink(ctx, code, reason, legal)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, *shortLink)
+
+	return shortLink, nil
+}
+
+// EnableShortLink cancels the disabling of the link.
+func (s *Storage) EnableShortLink(ctx context.Context, code string) (*models.ShortLink, error) {
+	shortLink, err := s.Storage.EnableShortLink(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, *shortLink)
+
+	return shortLink, nil
+}
+
+// ClaimShortLinks moves the links of the user fromUserID to toUserID, returns the codes of the moved links.
+func (s *Storage) ClaimShortLinks(ctx context.Context, fromUserID, toUserID string) ([]string, error) {
+	claimed, err := s.Storage.ClaimShortLinks(ctx, fromUserID, toUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// в кэше по коду лежит ссылка со старым владельцем
+	keys := make([]string, 0, len(claimed))
+	for _, code := range claimed {
+		keys = append(keys, codeKeyPrefix+code)
+	}
+	s.delete(ctx, keys...)
+
+	return claimed, nil
+}
+
+// Close closing the wrapped storage and the cache.
+func (s *Storage) Close() error {
+	err := s.Storage.Close()
+	if errClose := s.cache.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
+// CacheStats the hit and miss counters of the cache.
+func (s *Storage) CacheStats() models.CacheStats {
+	stats := models.CacheStats{
+		Hits:         s.hits.Load(),
+		NegativeHits: s.negativeHits.Load(),
+		Misses:       s.misses.Load(),
+	}
+
+	if total := stats.Hits + stats.NegativeHits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits+stats.NegativeHits) / float64(total)
+	}
+
+	return stats
+}
+
+// get reads the key, an unavailable cache is treated as a miss.
+func (s *Storage) get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		logger.Log.Warn("cannot read the cache", zap.String("key", key), zap.Error(err))
+		return nil, false
+	}
+
+	return value, ok
+}
+
+// cachedLink the link cached by code, without counting the hit or the miss.
+func (s *Storage) cachedLink(ctx context.Context, code string) (*models.ShortLink, bool) {
+	value, ok := s.get(ctx, codeKeyPrefix+code)
+	if !ok || len(value) == 0 {
+		return nil, false
+	}
+
+	shortLink := models.ShortLink{}
+	if err := json.Unmarshal(value, &shortLink); err != nil {
+		return nil, false
+	}
+
+	return &shortLink, true
+}
+
+func (s *Storage) set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		logger.Log.Warn("cannot write the cache", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (s *Storage) setLink(ctx context.Context, shortLink *models.ShortLink) {
+	value, err := json.Marshal(shortLink)
+	if err != nil {
+		return
+	}
+
+	s.set(ctx, codeKeyPrefix+shortLink.Code, value, s.ttl)
+}
+
+func (s *Storage) delete(ctx context.Context, keys ...string) {
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		logger.Log.Error("cannot invalidate the cache", zap.Strings("keys", keys), zap.Error(err))
+	}
+}
+
+// invalidate drops the keys by code and original URL of the links.
+func (s *Storage) invalidate(ctx context.Context, shortLinks ...models.ShortLink) {
+	keys := make([]string, 0, 2*len(shortLinks))
+	for _, link := range shortLinks {
+		keys = append(keys, codeKeyPrefix+link.Code, urlKeyPrefix+link.OriginalURL)
+	}
+
+	s.delete(ctx, keys...)
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	mockStore "github.com/Orendev/shortener/internal/repository/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_GetByCode(t *testing.T) {
+	ctx := context.Background()
+	link := models.ShortLink{UUID: "id", UserID: "user", Code: "4rSPg8ap", OriginalURL: "http://yandex.ru"}
+
+	ctrl := gomock.NewController(t)
+	repo := mockStore.NewMockStorage(ctrl)
+
+	// каждая ссылка читается из хранилища один раз до инвалидации
+	repo.EXPECT().GetByCode(gomock.Any(), link.Code).Return(&link, nil).Times(2)
+	repo.EXPECT().GetByCode(gomock.Any(), "missing").Return(nil, repository.ErrNotFound).Times(1)
+	repo.EXPECT().DeleteFlagBatch(gomock.Any(), []string{link.Code}, link.UserID).Return([]string{link.Code}, nil)
+
+	s := NewStorage(repo, NewLRU(100), time.Minute, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		got, err := s.GetByCode(ctx, link.Code)
+		require.NoError(t, err)
+		assert.Equal(t, link, *got)
+
+		_, err = s.GetByCode(ctx, "missing")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	}
+
+	_, err := s.DeleteFlagBatch(ctx, []string{link.Code}, link.UserID)
+	require.NoError(t, err)
+
+	_, err = s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.CacheStats{
+		Hits:         2,
+		NegativeHits: 2,
+		Misses:       3,
+		HitRatio:     4.0 / 7.0,
+	}, s.CacheStats())
+}
+
+func TestStorage_GetByOriginalURL(t *testing.T) {
+	ctx := context.Background()
+	link := models.ShortLink{UUID: "id", UserID: "user", Code: "4rSPg8ap", OriginalURL: "http://yandex.ru"}
+	moved := link
+	moved.OriginalURL = "http://ya.ru"
+
+	ctrl := gomock.NewController(t)
+	repo := mockStore.NewMockStorage(ctrl)
+
+	gomock.InOrder(
+		repo.EXPECT().GetByOriginalURL(gomock.Any(), link.OriginalURL).Return(nil, repository.ErrNotFound),
+		repo.EXPECT().Save(gomock.Any(), link).Return(nil),
+		repo.EXPECT().GetByOriginalURL(gomock.Any(), link.OriginalURL).Return(&link, nil),
+		repo.EXPECT().GetByID(gomock.Any(), link.UUID).Return(&link, nil),
+		repo.EXPECT().UpdateBatch(gomock.Any(), []models.ShortLink{moved}).Return(nil),
+		repo.EXPECT().GetByOriginalURL(gomock.Any(), link.OriginalURL).Return(nil, repository.ErrNotFound),
+	)
+	repo.EXPECT().GetByCode(gomock.Any(), link.Code).Return(&moved, nil)
+
+	s := NewStorage(repo, NewLRU(100), time.Minute, time.Minute)
+
+	_, err := s.GetByOriginalURL(ctx, link.OriginalURL)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// сохранение сбрасывает закешированное отсутствие
+	require.NoError(t, s.Save(ctx, link))
+
+	got, err := s.GetByOriginalURL(ctx, link.OriginalURL)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	got, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	// смена URL сбрасывает и старый URL, и код
+	require.NoError(t, s.UpdateBatch(ctx, []models.ShortLink{moved}))
+
+	_, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	got, err = s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+	assert.Equal(t, moved, *got)
+
+	// попадание по URL считается один раз
+	assert.Equal(t, models.CacheStats{
+		Hits:     1,
+		Misses:   4,
+		HitRatio: 1.0 / 5.0,
+	}, s.CacheStats())
+}
+
+func TestStorage_DisableShortLink(t *testing.T) {
+	ctx := context.Background()
+	link := models.ShortLink{UUID: "id", UserID: "user", Code: "4rSPg8ap", OriginalURL: "http://yandex.ru"}
+	disabled := link
+	disabled.DisabledReason = "phishing"
+
+	ctrl := gomock.NewController(t)
+	repo := mockStore.NewMockStorage(ctrl)
+
+	gomock.InOrder(
+		repo.EXPECT().GetByCode(gomock.Any(), link.Code).Return(&link, nil),
+		repo.EXPECT().DisableShortLink(gomock.Any(), link.Code, "phishing", false).Return(&disabled, nil),
+		repo.EXPECT().GetByCode(gomock.Any(), link.Code).Return(&disabled, nil),
+		repo.EXPECT().EnableShortLink(gomock.Any(), link.Code).Return(&link, nil),
+		repo.EXPECT().GetByCode(gomock.Any(), link.Code).Return(&link, nil),
+	)
+
+	s := NewStorage(repo, NewLRU(100), time.Minute, time.Minute)
+
+	_, err := s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+
+	// отключённая ссылка не должна отдаваться из кэша
+	_, err = s.DisableShortLink(ctx, link.Code, "phishing", false)
+	require.NoError(t, err)
+
+	got, err := s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+	assert.True(t, got.IsDisabled())
+
+	_, err = s.EnableShortLink(ctx, link.Code)
+	require.NoError(t, err)
+
+	got, err = s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+	assert.False(t, got.IsDisabled())
+}
+
+func TestStorage_RESP(t *testing.T) {
+	ctx := context.Background()
+	link := models.ShortLink{UUID: "id", UserID: "user", Code: "4rSPg8ap", OriginalURL: "http://yandex.ru"}
+
+	ctrl := gomock.NewController(t)
+	repo := mockStore.NewMockStorage(ctrl)
+	repo.EXPECT().GetByCode(gomock.Any(), link.Code).Return(&link, nil).Times(1)
+	repo.EXPECT().Close().Return(nil)
+
+	srv := newRESPServer(t)
+	s := NewStorage(repo, NewRESP(srv.addr(), 2), time.Minute, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		got, err := s.GetByCode(ctx, link.Code)
+		require.NoError(t, err)
+		assert.Equal(t, link, *got)
+	}
+
+	assert.Equal(t, uint64(1), s.CacheStats().Hits)
+	require.NoError(t, s.Close())
+}
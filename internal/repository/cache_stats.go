@@ -0,0 +1,8 @@
+package repository
+
+import "github.com/Orendev/shortener/internal/models"
+
+// CacheStatsProvider interface of the storage caching the reads.
+type CacheStatsProvider interface {
+	CacheStats() models.CacheStats
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// CodeCounter interface for the monotonically increasing IDs of the sequential codes.
+// The IDs are unique, gaps are allowed.
+type CodeCounter interface {
+	NextCodeID(ctx context.Context) (uint64, error)
+}
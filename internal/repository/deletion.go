@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// DeletionStorage interface for the journal of the queued deletions of the user's links.
+//
+// The deletions are appended before they are acknowledged to the user and acked once
+// flushed to the Storage, so the pending ones survive a restart.
+type DeletionStorage interface {
+	AppendDeletions(ctx context.Context, messages []models.Message) error
+	AckDeletions(ctx context.Context, messages []models.Message) error
+	PendingDeletions(ctx context.Context) ([]models.Message, error)
+}
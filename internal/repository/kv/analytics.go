@@ -0,0 +1,115 @@
+package kv
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Analytics - structure describing the KV click storage.
+//
+// The clicks on a short link are kept in its own nested bucket, keyed by the click time
+// and a sequence number, so the range [from, to) is read with a single cursor seek.
+type Analytics struct {
+	db *bolt.DB
+}
+
+// Analytics returns the click storage sharing the database of the KV.
+func (s *KV) Analytics() *Analytics {
+	return &Analytics{db: s.db}
+}
+
+// SaveClicks let's save the clicks models.Click.
+func (a *Analytics) SaveClicks(_ context.Context, clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	return a.db.Update(func(tx *bolt.Tx) error {
+		for _, click := range clicks {
+			bucket, err := tx.Bucket(clicksBucket).CreateBucketIfNotExists([]byte(click.Code))
+			if err != nil {
+				return err
+			}
+
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(click)
+			if err != nil {
+				return err
+			}
+
+			if err = bucket.Put(clickKey(click.ClickedAt, seq), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ClicksTotal the total number of clicks on the short link.
+func (a *Analytics) ClicksTotal(_ context.Context, code string) (int, error) {
+	count := 0
+
+	err := a.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(clicksBucket).Bucket([]byte(code)); bucket != nil {
+			count = bucket.Stats().KeyN
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// ClicksSeries the number of clicks on the short link grouped by interval in the range [from, to).
+func (a *Analytics) ClicksSeries(_ context.Context, code string, interval models.StatsInterval, from, to time.Time) ([]models.ClickBucket, error) {
+	buckets := make([]models.ClickBucket, 0)
+
+	err := a.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(clicksBucket).Bucket([]byte(code))
+		if bucket == nil {
+			return nil
+		}
+
+		end := clickKey(to, 0)
+
+		// ключи упорядочены по времени, поэтому интервалы идут подряд
+		c := bucket.Cursor()
+		for k, _ := c.Seek(clickKey(from, 0)); k != nil && string(k) < string(end); k, _ = c.Next() {
+			t := interval.Truncate(clickTime(k))
+
+			if n := len(buckets); n > 0 && buckets[n-1].Time.Equal(t) {
+				buckets[n-1].Clicks++
+				continue
+			}
+			buckets = append(buckets, models.ClickBucket{Time: t, Clicks: 1})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// clickKey the big-endian click time in nanoseconds followed by the sequence number.
+func clickKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(t.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// clickTime the click time encoded in the key by clickKey.
+func clickTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8]))).UTC()
+}
@@ -0,0 +1,30 @@
+package kv
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// CodeCounter - structure describing the KV counter of the code IDs.
+type CodeCounter struct {
+	db *bolt.DB
+}
+
+// CodeCounter returns the code ID counter sharing the database of the KV.
+func (s *KV) CodeCounter() *CodeCounter {
+	return &CodeCounter{db: s.db}
+}
+
+// NextCodeID the next value of the sequence of the codeIDsBucket.
+func (c *CodeCounter) NextCodeID(_ context.Context) (uint64, error) {
+	var id uint64
+
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		id, err = tx.Bucket(codeIDsBucket).NextSequence()
+		return err
+	})
+
+	return id, err
+}
@@ -0,0 +1,610 @@
+// Package kv implements the storage of short links on the embedded transactional key-value store bbolt.
+package kv
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// openTimeout how long to wait for the file lock held by another process.
+const openTimeout = time.Second
+
+// Buckets of the store.
+var (
+	// linksBucket code -> models.ShortLink in JSON.
+	linksBucket = []byte("links")
+	// idsBucket UUID -> code.
+	idsBucket = []byte("ids")
+	// urlsBucket original URL -> code.
+	urlsBucket = []byte("urls")
+	// usersBucket user ID -> nested bucket with the codes of the user keyed by userKey.
+	usersBucket = []byte("users")
+	// clicksBucket code -> nested bucket with the clicks on the short link.
+	clicksBucket = []byte("clicks")
+	// codeIDsBucket its sequence is the counter of the sequential codes.
+	codeIDsBucket = []byte("code_ids")
+)
+
+// KV - structure describing the KV.
+type KV struct {
+	db *bolt.DB
+}
+
+// NewKV - constructor a new instance of KV.
+func NewKV(cfg config.KV) (*KV, error) {
+	db, err := bolt.Open(cfg.KVStoragePath, 0666, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{linksBucket, idsBucket, urlsBucket, usersBucket, clicksBucket, codeIDsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &KV{
+		db: db,
+	}, nil
+}
+
+// GetByCode we get a model models.ShortLink of a short link by code.
+func (s *KV) GetByCode(_ context.Context, code string) (*models.ShortLink, error) {
+	var shortLink *models.ShortLink
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		shortLink, err = getLink(tx, []byte(code))
+		return err
+	})
+
+	return shortLink, err
+}
+
+// GetByID we get a model models.ShortLink of a short link by id.
+func (s *KV) GetByID(_ context.Context, id string) (*models.ShortLink, error) {
+	return s.lookup(idsBucket, id)
+}
+
+// GetByOriginalURL we will get the model with a short link models.ShortLink to the original URL.
+func (s *KV) GetByOriginalURL(_ context.Context, originalURL string) (*models.ShortLink, error) {
+	return s.lookup(urlsBucket, originalURL)
+}
+
+// ShortLinksByUserID we will get a page of the user's short link models.ShortLink.
+func (s *KV) ShortLinksByUserID(_ context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	after, err := query.After()
+	if err != nil {
+		return nil, err
+	}
+
+	shortLinks := make([]models.ShortLink, 0)
+
+	err = s.db.View(func(tx *bolt.Tx) error {
+		codes := tx.Bucket(usersBucket).Bucket([]byte(query.UserID))
+		if codes == nil {
+			return nil
+		}
+
+		// ключи индекса упорядочены по времени создания и коду
+		c := codes.Cursor()
+		first, next := c.First, c.Next
+		if query.Sort == models.SortCreatedAtDesc {
+			first, next = c.Last, c.Prev
+		}
+
+		k, code := first()
+		if after != nil {
+			k, code = c.Seek(userKey(after.CreatedAt, after.Code))
+			if query.Sort == models.SortCreatedAtDesc {
+				if k == nil {
+					k, code = c.Last()
+				} else {
+					k, code = c.Prev()
+				}
+			}
+		}
+
+		// на одну ссылку больше лимита, чтобы узнать о следующей странице
+		for ; k != nil && len(shortLinks) <= query.Limit; k, code = next() {
+			link, err := getLink(tx, code)
+			if err != nil {
+				return err
+			}
+
+			if query.IsAfter(*link, after) && query.Matches(*link) {
+				shortLinks = append(shortLinks, *link)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return query.NewPage(shortLinks), nil
+}
+
+// Save let's save the model of the short link models.ShortLink.
+func (s *KV) Save(_ context.Context, model models.ShortLink) error {
+	model.DeletedFlag = false
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = time.Now().UTC()
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return insertLink(tx, model)
+	})
+}
+
+// InsertBatch group insertion of short link models []models.ShortLink.
+func (s *KV) InsertBatch(_ context.Context, shortLinks []models.ShortLink) error {
+	now := time.Now().UTC()
+
+	// пачка вставляется в одной транзакции: либо все ссылки, либо ни одной
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, link := range shortLinks {
+			link.DeletedFlag = false
+			if link.CreatedAt.IsZero() {
+				link.CreatedAt = now
+			}
+			if err := insertLink(tx, link); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateBatch group update of short link models []models.ShortLink.
+func (s *KV) UpdateBatch(_ context.Context, shortLinks []models.ShortLink) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, link := range shortLinks {
+			// ссылка ищется по id, как и в Postgres
+			code := tx.Bucket(idsBucket).Get([]byte(link.UUID))
+			if code == nil {
+				continue
+			}
+
+			old, err := getLink(tx, code)
+			if err != nil {
+				return err
+			}
+
+			if owner := tx.Bucket(urlsBucket).Get([]byte(link.OriginalURL)); owner != nil && string(owner) != old.Code {
+				return repository.ErrConflict
+			}
+
+			link.Code = old.Code
+			link.CreatedAt = old.CreatedAt
+			if err = unindexLink(tx, *old); err != nil {
+				return err
+			}
+			if err = putLink(tx, link); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteFlagBatch group delete of the user's short links, returns the codes that were deleted.
+func (s *KV) DeleteFlagBatch(_ context.Context, codes []string, userID string) ([]string, error) {
+	deleted := make([]string, 0, len(codes))
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		deleted = deleted[:0]
+
+		for _, code := range codes {
+			// чужие и несуществующие ссылки пропускаются
+			link, err := getUserLink(tx, code, userID)
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			link.DeletedFlag = true
+			if err = putLink(tx, *link); err != nil {
+				return err
+			}
+			deleted = append(deleted, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// ClaimShortLinks moves the links of the user fromUserID to toUserID, returns the codes of the moved links.
+func (s *KV) ClaimShortLinks(_ context.Context, fromUserID, toUserID string) ([]string, error) {
+	claimed := make([]string, 0)
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		claimed = claimed[:0]
+
+		codes := tx.Bucket(usersBucket).Bucket([]byte(fromUserID))
+		if codes == nil {
+			return nil
+		}
+
+		// бакет пользователя меняется при переносе, поэтому коды собираются заранее
+		err := codes.ForEach(func(_, code []byte) error {
+			claimed = append(claimed, string(code))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, code := range claimed {
+			old, err := getLink(tx, []byte(code))
+			if err != nil {
+				return err
+			}
+
+			link := *old
+			link.UserID = toUserID
+			if err = unindexLink(tx, *old); err != nil {
+				return err
+			}
+			if err = putLink(tx, link); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// UpdateOriginalURL changes the original URL of the user's short link.
+func (s *KV) UpdateOriginalURL(_ context.Context, code, userID, originalURL string) (*models.ShortLink, error) {
+	var shortLink *models.ShortLink
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		old, err := getUserLink(tx, code, userID)
+		if err != nil {
+			return err
+		}
+
+		if other := tx.Bucket(urlsBucket).Get([]byte(originalURL)); other != nil && string(other) != code {
+			return repository.ErrConflict
+		}
+
+		link := *old
+		link.OriginalURL = originalURL
+		if err = unindexLink(tx, *old); err != nil {
+			return err
+		}
+		if err = putLink(tx, link); err != nil {
+			return err
+		}
+
+		shortLink = &link
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shortLink, nil
+}
+
+// Restore cancels the soft delete of the user's short link.
+func (s *KV) Restore(_ context.Context, code, userID string) (*models.ShortLink, error) {
+	var shortLink *models.ShortLink
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		shortLink, err = getUserLink(tx, code, userID)
+		if err != nil {
+			return err
+		}
+
+		shortLink.DeletedFlag = false
+		return putLink(tx, *shortLink)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shortLink, nil
+}
+
+// DeleteExpired soft-deletes the short links whose lifetime is over at the moment now.
+func (s *KV) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	count := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		expired := make([]models.ShortLink, 0)
+
+		err := tx.Bucket(linksBucket).ForEach(func(_, v []byte) error {
+			link := models.ShortLink{}
+			if err := json.Unmarshal(v, &link); err != nil {
+				return err
+			}
+			if !link.DeletedFlag && link.IsExpired(now) {
+				expired = append(expired, link)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// бакет нельзя менять во время обхода ForEach
+		for _, link := range expired {
+			link.DeletedFlag = true
+			if err = putLink(tx, link); err != nil {
+				return err
+			}
+		}
+
+		count = len(expired)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RecentShortLinks the page of the links of all users, or of query.UserID when it is set.
+func (s *KV) RecentShortLinks(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	if len(query.UserID) > 0 {
+		return s.ShortLinksByUserID(ctx, query)
+	}
+
+	shortLinks := make([]models.ShortLink, 0)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(linksBucket).ForEach(func(_, v []byte) error {
+			link := models.ShortLink{}
+			if err := json.Unmarshal(v, &link); err != nil {
+				return err
+			}
+			shortLinks = append(shortLinks, link)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Page(shortLinks)
+}
+
+// DisableShortLink disables the link with the reason.
+func (s *KV) DisableShortLink(_ context.Context, code, reason string, legal bool) (*models.ShortLink, error) {
+	return s.updateLink(code, func(link *models.ShortLink) {
+		link.DisabledReason, link.DisabledLegal = reason, legal
+	})
+}
+
+// EnableShortLink cancels the disabling of the link.
+func (s *KV) EnableShortLink(_ context.Context, code string) (*models.ShortLink, error) {
+	return s.updateLink(code, func(link *models.ShortLink) {
+		link.DisabledReason, link.DisabledLegal = "", false
+	})
+}
+
+// UrlsStats number of abbreviated URLs in the service.
+func (s *KV) UrlsStats(_ context.Context) (int, error) {
+	count := 0
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(linksBucket).Stats().KeyN
+		return nil
+	})
+
+	return count, err
+}
+
+// UsersStats number of users in the service.
+func (s *KV) UsersStats(_ context.Context) (int, error) {
+	count := 0
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(_, _ []byte) error {
+			count++
+			return nil
+		})
+	})
+
+	return count, err
+}
+
+// Ping service check.
+func (s *KV) Ping(_ context.Context) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return nil
+	})
+}
+
+// Close closing the service.
+func (s *KV) Close() error {
+	return s.db.Close()
+}
+
+// lookup finds the link by the code stored in the index bucket.
+func (s *KV) lookup(index []byte, key string) (*models.ShortLink, error) {
+	var shortLink *models.ShortLink
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		code := tx.Bucket(index).Get([]byte(key))
+		if code == nil {
+			return repository.ErrNotFound
+		}
+
+		var err error
+		shortLink, err = getLink(tx, code)
+		return err
+	})
+
+	return shortLink, err
+}
+
+// updateLink changes the link by code, the index entries stay the same.
+func (s *KV) updateLink(code string, change func(link *models.ShortLink)) (*models.ShortLink, error) {
+	var shortLink *models.ShortLink
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		shortLink, err = getLink(tx, []byte(code))
+		if err != nil {
+			return err
+		}
+
+		change(shortLink)
+		return putLink(tx, *shortLink)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shortLink, nil
+}
+
+// getLink reads the link by code.
+func getLink(tx *bolt.Tx, code []byte) (*models.ShortLink, error) {
+	v := tx.Bucket(linksBucket).Get(code)
+	if v == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	// значение действительно только внутри транзакции, Unmarshal его копирует
+	shortLink := models.ShortLink{}
+	if err := json.Unmarshal(v, &shortLink); err != nil {
+		return nil, err
+	}
+
+	return &shortLink, nil
+}
+
+// getUserLink reads the link by code, a link of another user is not found.
+func getUserLink(tx *bolt.Tx, code, userID string) (*models.ShortLink, error) {
+	shortLink, err := getLink(tx, []byte(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if shortLink.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+
+	return shortLink, nil
+}
+
+// insertLink stores a new link, failing if its original URL or code is taken.
+func insertLink(tx *bolt.Tx, link models.ShortLink) error {
+	if tx.Bucket(urlsBucket).Get([]byte(link.OriginalURL)) != nil {
+		return repository.ErrConflict
+	}
+
+	if tx.Bucket(linksBucket).Get([]byte(link.Code)) != nil {
+		return repository.ErrCodeConflict
+	}
+
+	return putLink(tx, link)
+}
+
+// putLink writes the link and its index entries.
+func putLink(tx *bolt.Tx, link models.ShortLink) error {
+	value, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+
+	code := []byte(link.Code)
+
+	if err = tx.Bucket(linksBucket).Put(code, value); err != nil {
+		return err
+	}
+	if err = tx.Bucket(idsBucket).Put([]byte(link.UUID), code); err != nil {
+		return err
+	}
+	if err = tx.Bucket(urlsBucket).Put([]byte(link.OriginalURL), code); err != nil {
+		return err
+	}
+
+	// у бакета не бывает пустого имени, ссылки без пользователя в индекс не попадают
+	if len(link.UserID) == 0 {
+		return nil
+	}
+
+	codes, err := tx.Bucket(usersBucket).CreateBucketIfNotExists([]byte(link.UserID))
+	if err != nil {
+		return err
+	}
+
+	return codes.Put(userKey(link.CreatedAt, link.Code), code)
+}
+
+// unindexLink removes the index entries of the link.
+func unindexLink(tx *bolt.Tx, link models.ShortLink) error {
+	if err := tx.Bucket(idsBucket).Delete([]byte(link.UUID)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(urlsBucket).Delete([]byte(link.OriginalURL)); err != nil {
+		return err
+	}
+
+	if len(link.UserID) == 0 {
+		return nil
+	}
+
+	users := tx.Bucket(usersBucket)
+	codes := users.Bucket([]byte(link.UserID))
+	if codes == nil {
+		return nil
+	}
+
+	if err := codes.Delete(userKey(link.CreatedAt, link.Code)); err != nil {
+		return err
+	}
+
+	// у пользователя не осталось ссылок
+	if k, _ := codes.Cursor().First(); k == nil {
+		return users.DeleteBucket([]byte(link.UserID))
+	}
+
+	return nil
+}
+
+// userKey the key of the link in the user's bucket: the big-endian creation time in nanoseconds and the code.
+func userKey(createdAt time.Time, code string) []byte {
+	key := make([]byte, 8, 8+len(code))
+
+	// время до 1970 года не представимо, такие ссылки идут первыми
+	if createdAt.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint64(key, uint64(createdAt.UnixNano()))
+	}
+
+	return append(key, code...)
+}
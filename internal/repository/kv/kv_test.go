@@ -0,0 +1,365 @@
+package kv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createdAt the fixed creation time, so the stored links compare equal.
+var createdAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// userLinks the first page of the user's links in the default order.
+func userLinks(ctx context.Context, s *KV, userID string, limit int) ([]models.ShortLink, error) {
+	query := models.NewShortLinksQuery(userID)
+	query.Limit = limit
+
+	page, err := s.ShortLinksByUserID(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Links, nil
+}
+
+func TestKV_Storage(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.KV{KVStoragePath: filepath.Join(t.TempDir(), "short-url.db")}
+
+	s, err := NewKV(cfg)
+	require.NoError(t, err)
+
+	link := models.ShortLink{UUID: uuid.New().String(), UserID: "user", Code: "code", OriginalURL: "http://yandex.ru", CreatedAt: createdAt}
+	require.NoError(t, s.Save(ctx, link))
+
+	assert.ErrorIs(t, s.Save(ctx, models.ShortLink{UUID: uuid.New().String(), Code: "other", OriginalURL: link.OriginalURL}), repository.ErrConflict)
+	assert.ErrorIs(t, s.Save(ctx, models.ShortLink{UUID: uuid.New().String(), Code: link.Code, OriginalURL: "http://ya.ru"}), repository.ErrCodeConflict)
+
+	// пачка с занятым кодом не вставляется целиком
+	err = s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "user", Code: "a", OriginalURL: "http://a.ru"},
+		{UUID: uuid.New().String(), UserID: "user", Code: link.Code, OriginalURL: "http://b.ru"},
+	})
+	assert.ErrorIs(t, err, repository.ErrCodeConflict)
+	_, err = s.GetByCode(ctx, "a")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	got, err := s.GetByID(ctx, link.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	got, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	// обновление переносит ссылку в индексах
+	updated := link
+	updated.UserID = "another"
+	updated.OriginalURL = "http://practicum.yandex.ru"
+	require.NoError(t, s.UpdateBatch(ctx, []models.ShortLink{updated}))
+
+	_, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	links, err := userLinks(ctx, s, "user", 100)
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	users, err := s.UsersStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, users)
+
+	expiresAt := time.Now().Add(-time.Minute).UTC()
+	expired := models.ShortLink{UUID: uuid.New().String(), UserID: "another", Code: "expired", OriginalURL: "http://expired.ru", ExpiresAt: &expiresAt}
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{expired}))
+
+	count, err := s.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// чужие и несуществующие ссылки не удаляются
+	deleted, err := s.DeleteFlagBatch(ctx, []string{link.Code, "missing"}, "user")
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	deleted, err = s.DeleteFlagBatch(ctx, []string{link.Code, "missing"}, "another")
+	require.NoError(t, err)
+	assert.Equal(t, []string{link.Code}, deleted)
+	require.NoError(t, s.Close())
+
+	// данные и индексы переживают перезапуск
+	s, err = NewKV(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	links, err = userLinks(ctx, s, "another", 100)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	for _, l := range links {
+		assert.True(t, l.DeletedFlag)
+	}
+
+	links, err = userLinks(ctx, s, "another", 1)
+	require.NoError(t, err)
+	assert.Len(t, links, 1)
+
+	urls, err := s.UrlsStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, urls)
+}
+
+func TestAnalytics_ClicksSeries(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	s, err := NewKV(config.KV{KVStoragePath: filepath.Join(t.TempDir(), "short-url.db")})
+	require.NoError(t, err)
+	defer s.Close()
+
+	a := s.Analytics()
+	err = a.SaveClicks(ctx, []models.Click{
+		{Code: "4rSPg8ap", ClickedAt: base.Add(5 * time.Minute)},
+		{Code: "4rSPg8ap", ClickedAt: base.Add(50 * time.Minute)},
+		{Code: "4rSPg8ap", ClickedAt: base.Add(time.Hour + time.Minute)},
+		{Code: "4rSPg8ap", ClickedAt: base.Add(26 * time.Hour)},
+		{Code: "other", ClickedAt: base},
+	})
+	require.NoError(t, err)
+
+	total, err := a.ClicksTotal(ctx, "4rSPg8ap")
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+
+	hours, err := a.ClicksSeries(ctx, "4rSPg8ap", models.StatsIntervalHour, base, base.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []models.ClickBucket{
+		{Time: base, Clicks: 2},
+		{Time: base.Add(time.Hour), Clicks: 1},
+	}, hours)
+
+	day := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	days, err := a.ClicksSeries(ctx, "4rSPg8ap", models.StatsIntervalDay, day, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []models.ClickBucket{
+		{Time: day, Clicks: 3},
+		{Time: day.Add(24 * time.Hour), Clicks: 1},
+	}, days)
+}
+
+func TestKV_UpdateOriginalURL(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewKV(config.KV{KVStoragePath: filepath.Join(t.TempDir(), "short-url.db")})
+	require.NoError(t, err)
+	defer s.Close()
+
+	link := models.ShortLink{UUID: uuid.New().String(), UserID: "user", Code: "code", OriginalURL: "http://yandex.ru", CreatedAt: createdAt}
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		link,
+		{UUID: uuid.New().String(), UserID: "user", Code: "other", OriginalURL: "http://ya.ru"},
+	}))
+
+	_, err = s.UpdateOriginalURL(ctx, link.Code, "another", "http://go.dev")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = s.UpdateOriginalURL(ctx, link.Code, link.UserID, "http://ya.ru")
+	assert.ErrorIs(t, err, repository.ErrConflict)
+
+	updated, err := s.UpdateOriginalURL(ctx, link.Code, link.UserID, "http://go.dev")
+	require.NoError(t, err)
+	assert.Equal(t, "http://go.dev", updated.OriginalURL)
+
+	_, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = s.DeleteFlagBatch(ctx, []string{link.Code}, link.UserID)
+	require.NoError(t, err)
+
+	_, err = s.Restore(ctx, link.Code, "another")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	restored, err := s.Restore(ctx, link.Code, link.UserID)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedFlag)
+
+	got, err := s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+	assert.Equal(t, restored, got)
+}
+
+func TestKV_ClaimShortLinks(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewKV(config.KV{KVStoragePath: filepath.Join(t.TempDir(), "short-url.db")})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "anonymous", Code: "first", OriginalURL: "http://yandex.ru", CreatedAt: createdAt},
+		{UUID: uuid.New().String(), UserID: "anonymous", Code: "second", OriginalURL: "http://ya.ru", CreatedAt: createdAt},
+		{UUID: uuid.New().String(), UserID: "account", Code: "third", OriginalURL: "http://go.dev", CreatedAt: createdAt},
+	}))
+
+	claimed, err := s.ClaimShortLinks(ctx, "anonymous", "account")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"first", "second"}, claimed)
+
+	links, err := userLinks(ctx, s, "account", 100)
+	require.NoError(t, err)
+	assert.Len(t, links, 3)
+
+	links, err = userLinks(ctx, s, "anonymous", 100)
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	claimed, err = s.ClaimShortLinks(ctx, "anonymous", "account")
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestKV_ShortLinksByUserID(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewKV(config.KV{KVStoragePath: filepath.Join(t.TempDir(), "short-url.db")})
+	require.NoError(t, err)
+	defer s.Close()
+
+	// у b и c одинаковое время создания, порядок между ними задаёт код
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "user", Code: "c", OriginalURL: "http://ya.ru/c", CreatedAt: createdAt.Add(time.Second)},
+		{UUID: uuid.New().String(), UserID: "user", Code: "a", OriginalURL: "http://yandex.ru/a", CreatedAt: createdAt},
+		{UUID: uuid.New().String(), UserID: "user", Code: "d", OriginalURL: "http://yandex.ru/d", CreatedAt: createdAt.Add(2 * time.Second)},
+		{UUID: uuid.New().String(), UserID: "user", Code: "b", OriginalURL: "http://yandex.ru/b", CreatedAt: createdAt.Add(time.Second)},
+		{UUID: uuid.New().String(), UserID: "another", Code: "e", OriginalURL: "http://yandex.ru/e", CreatedAt: createdAt},
+	}))
+
+	tests := []struct {
+		name   string
+		sort   models.ShortLinkSort
+		search string
+		limit  int
+		want   []string
+	}{
+		{
+			name:  "oldest first",
+			sort:  models.SortCreatedAt,
+			limit: 3,
+			want:  []string{"a", "b", "c", "d"},
+		},
+		{
+			name:  "newest first",
+			sort:  models.SortCreatedAtDesc,
+			limit: 1,
+			want:  []string{"d", "c", "b", "a"},
+		},
+		{
+			name:   "search",
+			sort:   models.SortCreatedAtDesc,
+			search: "YANDEX",
+			limit:  2,
+			want:   []string{"d", "b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := models.NewShortLinksQuery("user")
+			query.Sort = tt.sort
+			query.Search = tt.search
+			query.Limit = tt.limit
+
+			codes := make([]string, 0, len(tt.want))
+			for {
+				page, err := s.ShortLinksByUserID(ctx, query)
+				require.NoError(t, err)
+				assert.LessOrEqual(t, len(page.Links), tt.limit)
+
+				for _, link := range page.Links {
+					codes = append(codes, link.Code)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+
+	query := models.NewShortLinksQuery("user")
+	query.Cursor = "garbage"
+	_, err = s.ShortLinksByUserID(ctx, query)
+	assert.ErrorIs(t, err, models.ErrCursorInvalid)
+}
+
+func TestKV_CodeCounter(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "short-url.db")
+
+	s, err := NewKV(config.KV{KVStoragePath: path})
+	require.NoError(t, err)
+
+	first, err := s.CodeCounter().NextCodeID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// счётчик продолжается после перезапуска
+	s, err = NewKV(config.KV{KVStoragePath: path})
+	require.NoError(t, err)
+	defer s.Close()
+
+	second, err := s.CodeCounter().NextCodeID(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, second, first)
+}
+
+func TestKV_Moderation(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewKV(config.KV{KVStoragePath: filepath.Join(t.TempDir(), "short-url.db")})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "user", Code: "a", OriginalURL: "http://yandex.ru/a", CreatedAt: createdAt},
+		{UUID: uuid.New().String(), UserID: "another", Code: "b", OriginalURL: "http://yandex.ru/b", CreatedAt: createdAt.Add(time.Second)},
+	}))
+
+	query := models.NewShortLinksQuery("")
+	query.Sort = models.SortCreatedAtDesc
+	page, err := s.RecentShortLinks(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, page.Links, 2)
+	assert.Equal(t, "b", page.Links[0].Code)
+
+	query.UserID = "user"
+	page, err = s.RecentShortLinks(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, page.Links, 1)
+	assert.Equal(t, "a", page.Links[0].Code)
+
+	disabled, err := s.DisableShortLink(ctx, "a", "phishing", true)
+	require.NoError(t, err)
+	assert.True(t, disabled.IsDisabled())
+
+	got, err := s.GetByOriginalURL(ctx, "http://yandex.ru/a")
+	require.NoError(t, err)
+	assert.Equal(t, "phishing", got.DisabledReason)
+	assert.True(t, got.DisabledLegal)
+
+	enabled, err := s.EnableShortLink(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, enabled.IsDisabled())
+	assert.False(t, enabled.DisabledLegal)
+
+	_, err = s.DisableShortLink(ctx, "missing", "spam", false)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
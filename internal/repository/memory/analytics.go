@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// clicksRetention how long the hourly click counters are kept, the total is kept for good.
+const clicksRetention = 90 * 24 * time.Hour
+
+// Analytics - structure describing the in-memory click storage.
+//
+// The clicks are not stored one by one: every short link keeps the total and the counters
+// by hour, the counters older than clicksRetention from the latest click are dropped.
+type Analytics struct {
+	mu     sync.RWMutex
+	clicks map[string]*linkClicks
+}
+
+// linkClicks the click counters of a short link.
+type linkClicks struct {
+	total  int
+	hours  map[time.Time]int
+	latest time.Time
+}
+
+// NewAnalytics - constructor a new instance of Analytics.
+func NewAnalytics() *Analytics {
+	return &Analytics{
+		clicks: make(map[string]*linkClicks),
+	}
+}
+
+// SaveClicks let's save the clicks models.Click.
+func (a *Analytics) SaveClicks(_ context.Context, clicks []models.Click) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, click := range clicks {
+		counters, ok := a.clicks[click.Code]
+		if !ok {
+			counters = &linkClicks{hours: make(map[time.Time]int)}
+			a.clicks[click.Code] = counters
+		}
+
+		hour := models.StatsIntervalHour.Truncate(click.ClickedAt)
+		counters.total++
+		counters.hours[hour]++
+
+		// устаревшие часы отбрасываются только с началом нового часа
+		if hour.After(counters.latest) {
+			counters.latest = hour
+			counters.prune()
+		}
+	}
+
+	return nil
+}
+
+// ClicksTotal the total number of clicks on the short link.
+func (a *Analytics) ClicksTotal(_ context.Context, code string) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	counters, ok := a.clicks[code]
+	if !ok {
+		return 0, nil
+	}
+
+	return counters.total, nil
+}
+
+// ClicksSeries the number of clicks on the short link grouped by interval in the range [from, to).
+//
+// The clicks are counted by hour, so the range is widened to the beginning of the hour of from.
+func (a *Analytics) ClicksSeries(_ context.Context, code string, interval models.StatsInterval, from, to time.Time) ([]models.ClickBucket, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	counters, ok := a.clicks[code]
+	if !ok {
+		return []models.ClickBucket{}, nil
+	}
+
+	from = models.StatsIntervalHour.Truncate(from)
+
+	counts := make(map[time.Time]int)
+	for hour, count := range counters.hours {
+		if hour.Before(from) || !hour.Before(to) {
+			continue
+		}
+		counts[interval.Truncate(hour)] += count
+	}
+
+	buckets := make([]models.ClickBucket, 0, len(counts))
+	for t, count := range counts {
+		buckets = append(buckets, models.ClickBucket{Time: t, Clicks: count})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Time.Before(buckets[j].Time)
+	})
+
+	return buckets, nil
+}
+
+// prune drops the hourly counters older than clicksRetention, the caller holds the write lock.
+func (c *linkClicks) prune() {
+	oldest := c.latest.Add(-clicksRetention)
+	for hour := range c.hours {
+		if hour.Before(oldest) {
+			delete(c.hours, hour)
+		}
+	}
+}
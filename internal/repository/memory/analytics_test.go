@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalytics_ClicksSeries(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	a := NewAnalytics()
+	err := a.SaveClicks(ctx, []models.Click{
+		{Code: "4rSPg8ap", ClickedAt: base.Add(5 * time.Minute)},
+		{Code: "4rSPg8ap", ClickedAt: base.Add(50 * time.Minute)},
+		{Code: "4rSPg8ap", ClickedAt: base.Add(time.Hour + time.Minute)},
+		{Code: "4rSPg8ap", ClickedAt: base.Add(26 * time.Hour)},
+		{Code: "other", ClickedAt: base},
+	})
+	require.NoError(t, err)
+
+	total, err := a.ClicksTotal(ctx, "4rSPg8ap")
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+
+	hours, err := a.ClicksSeries(ctx, "4rSPg8ap", models.StatsIntervalHour, base, base.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []models.ClickBucket{
+		{Time: base, Clicks: 2},
+		{Time: base.Add(time.Hour), Clicks: 1},
+	}, hours)
+
+	day := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	days, err := a.ClicksSeries(ctx, "4rSPg8ap", models.StatsIntervalDay, day, day.Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []models.ClickBucket{
+		{Time: day, Clicks: 3},
+		{Time: day.Add(24 * time.Hour), Clicks: 1},
+	}, days)
+}
+
+func TestAnalytics_Retention(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	a := NewAnalytics()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, a.SaveClicks(ctx, []models.Click{
+			{Code: "4rSPg8ap", ClickedAt: base.Add(time.Duration(i) * time.Minute)},
+		}))
+	}
+	require.NoError(t, a.SaveClicks(ctx, []models.Click{
+		{Code: "4rSPg8ap", ClickedAt: base.Add(clicksRetention + time.Hour)},
+	}))
+
+	// клики хранятся счётчиками по часам
+	assert.Len(t, a.clicks["4rSPg8ap"].hours, 1)
+
+	// общее число кликов не теряется вместе со старыми часами
+	total, err := a.ClicksTotal(ctx, "4rSPg8ap")
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+
+	hours, err := a.ClicksSeries(ctx, "4rSPg8ap", models.StatsIntervalHour, base, base.Add(2*clicksRetention))
+	require.NoError(t, err)
+	assert.Equal(t, []models.ClickBucket{
+		{Time: base.Add(clicksRetention + time.Hour), Clicks: 1},
+	}, hours)
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// apiKeyRecord the line of the API keys file, unlike models.APIKey it keeps the owner and the hash.
+type apiKeyRecord struct {
+	ID         string               `json:"id"`
+	UserID     string               `json:"user_id"`
+	Name       string               `json:"name"`
+	Prefix     string               `json:"prefix"`
+	Hash       string               `json:"hash"`
+	Scopes     []models.APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time            `json:"created_at"`
+	LastUsedAt *time.Time           `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time           `json:"revoked_at,omitempty"`
+}
+
+// APIKeys - structure describing the in-memory storage of the API keys.
+//
+// With a file path every created and revoked key is appended to the file as a line,
+// so the keys survive the restart of the file and KV backends. The last use of the key
+// is written only along with these changes.
+type APIKeys struct {
+	filePath string
+	mu       sync.Mutex
+	keys     map[string]models.APIKey
+}
+
+// NewAPIKeys - constructor a new instance of APIKeys reading the keys at filePath,
+// the keys are kept only in memory when filePath is empty.
+func NewAPIKeys(filePath string) (*APIKeys, error) {
+	a := &APIKeys{
+		filePath: filePath,
+		keys:     make(map[string]models.APIKey),
+	}
+
+	if len(filePath) == 0 {
+		return a, nil
+	}
+
+	err := readLines(filePath, func(line []byte) error {
+		record := apiKeyRecord{}
+		// недописанная при сбое строка пропускается
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil
+		}
+		// более поздняя строка ключа заменяет предыдущую
+		a.keys[record.ID] = models.APIKey(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// SaveAPIKey stores the API key.
+func (a *APIKeys) SaveAPIKey(_ context.Context, key models.APIKey) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.write(key)
+}
+
+// APIKeysByUserID the keys of the user in the order of creation.
+func (a *APIKeys) APIKeysByUserID(_ context.Context, userID string) ([]models.APIKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	keys := make([]models.APIKey, 0)
+	for _, key := range a.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	return keys, nil
+}
+
+// GetAPIKeyByHash the API key by the hash of the key.
+func (a *APIKeys) GetAPIKeyByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, key := range a.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+
+	return nil, repository.ErrNotFound
+}
+
+// RevokeAPIKey revokes the active key of the user.
+func (a *APIKeys) RevokeAPIKey(_ context.Context, id, userID string, at time.Time) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key, ok := a.keys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return false, nil
+	}
+
+	key.RevokedAt = &at
+	if err := a.write(key); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// TouchAPIKey remembers the last use of the key.
+func (a *APIKeys) TouchAPIKey(_ context.Context, id string, at time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// последнее использование не пишется в файл, чтобы не дописывать строку на каждый запрос
+	if key, ok := a.keys[id]; ok {
+		key.LastUsedAt = &at
+		a.keys[id] = key
+	}
+
+	return nil
+}
+
+// write appends the key to the file and stores it, the caller holds the lock.
+func (a *APIKeys) write(key models.APIKey) error {
+	if len(a.filePath) > 0 {
+		if err := appendLine(a.filePath, apiKeyRecord(key)); err != nil {
+			return err
+		}
+	}
+
+	a.keys[key.ID] = key
+
+	return nil
+}
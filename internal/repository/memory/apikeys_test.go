@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "apikeys")
+
+	a, err := NewAPIKeys(path)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := models.APIKey{ID: "key", UserID: "user", Name: "ci", Prefix: "sk_abc", Hash: "hash", Scopes: []models.APIKeyScope{models.ScopeRead}, CreatedAt: createdAt}
+	other := models.APIKey{ID: "other", UserID: "user", Name: "deploy", Prefix: "sk_def", Hash: "other-hash", Scopes: []models.APIKeyScope{models.ScopeRead}, CreatedAt: createdAt.Add(time.Hour)}
+	require.NoError(t, a.SaveAPIKey(ctx, key))
+	require.NoError(t, a.SaveAPIKey(ctx, other))
+
+	revokedAt := createdAt.Add(2 * time.Hour)
+	ok, err := a.RevokeAPIKey(ctx, other.ID, other.UserID, revokedAt)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// чужой ключ не отзывается
+	ok, err = a.RevokeAPIKey(ctx, key.ID, "another", revokedAt)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// ключи вместе с хешем и отзывом восстанавливаются из файла
+	restored, err := NewAPIKeys(path)
+	require.NoError(t, err)
+
+	got, err := restored.GetAPIKeyByHash(ctx, key.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, key, *got)
+
+	keys, err := restored.APIKeysByUserID(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, key.ID, keys[0].ID)
+	require.NotNil(t, keys[1].RevokedAt)
+	assert.True(t, revokedAt.Equal(*keys[1].RevokedAt))
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// counterBlock how many IDs are reserved by one write of the counter file.
+const counterBlock = 1000
+
+// CodeCounter - structure describing the counter of the code IDs persisted in a file.
+//
+// The IDs are reserved in blocks: the file keeps the end of the reserved block, so it is
+// written once per counterBlock IDs. After the restart the unused rest of the block is skipped.
+type CodeCounter struct {
+	filePath string
+	mu       sync.Mutex
+	next     uint64
+	limit    uint64
+}
+
+// NewCodeCounter - constructor a new instance of CodeCounter reading the counter at filePath,
+// the counter is kept only in memory when filePath is empty.
+func NewCodeCounter(filePath string) (*CodeCounter, error) {
+	c := &CodeCounter{filePath: filePath}
+
+	if len(filePath) == 0 {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.next, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	c.limit = c.next
+
+	return c, nil
+}
+
+// NextCodeID the next ID of the counter.
+func (c *CodeCounter) NextCodeID(_ context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next >= c.limit {
+		if err := c.reserve(c.next + counterBlock); err != nil {
+			return 0, err
+		}
+	}
+
+	id := c.next
+	c.next++
+
+	return id, nil
+}
+
+// reserve writes the end of the new block, the caller holds the lock.
+func (c *CodeCounter) reserve(limit uint64) error {
+	if len(c.filePath) > 0 {
+		// пишем во временный файл и атомарно подменяем им старый
+		tmpPath := c.filePath + ".tmp"
+		file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+
+		_, err = file.WriteString(strconv.FormatUint(limit, 10) + "\n")
+		if err == nil {
+			err = file.Sync()
+		}
+		if errClose := file.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = os.Rename(tmpPath, c.filePath); err != nil {
+			return err
+		}
+	}
+
+	c.limit = limit
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeCounter(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "counter")
+
+	c, err := NewCodeCounter(path)
+	require.NoError(t, err)
+
+	for want := uint64(0); want < 3; want++ {
+		id, err := c.NextCodeID(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, id)
+	}
+
+	// после перезапуска остаток зарезервированного блока пропускается
+	c, err = NewCodeCounter(path)
+	require.NoError(t, err)
+
+	id, err := c.NextCodeID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(counterBlock), id)
+}
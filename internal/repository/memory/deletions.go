@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// Deletion journal operations.
+const (
+	opQueue = "queue"
+	opAck   = "ack"
+)
+
+// deletionRecord one queued or flushed deletion in the journal.
+type deletionRecord struct {
+	Op      string         `json:"op"`
+	Message models.Message `json:"message"`
+}
+
+// Deletions - structure describing the file journal of the queued deletions.
+//
+// Every queued and every flushed deletion is appended as a line and synced to the disk,
+// the file is truncated once no deletion is pending.
+type Deletions struct {
+	filePath string
+	mu       sync.Mutex
+	file     *os.File
+	pending  map[models.Message]struct{}
+}
+
+// NewDeletions - constructor a new instance of Deletions reading the journal at filePath.
+func NewDeletions(filePath string) (*Deletions, error) {
+	d := &Deletions{
+		filePath: filePath,
+		pending:  make(map[models.Message]struct{}),
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			record := deletionRecord{}
+			// недописанная при сбое строка пропускается
+			if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				continue
+			}
+
+			if record.Op == opAck {
+				delete(d.pending, record.Message)
+			} else {
+				d.pending[record.Message] = struct{}{}
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// AppendDeletions writes the queued deletions to the journal.
+func (d *Deletions) AppendDeletions(_ context.Context, messages []models.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.write(opQueue, messages); err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		d.pending[message] = struct{}{}
+	}
+
+	return nil
+}
+
+// AckDeletions marks the deletions as flushed to the storage.
+func (d *Deletions) AckDeletions(_ context.Context, messages []models.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, message := range messages {
+		delete(d.pending, message)
+	}
+
+	// всё сброшено в хранилище, журнал начинается заново
+	if len(d.pending) == 0 {
+		return d.truncate()
+	}
+
+	return d.write(opAck, messages)
+}
+
+// PendingDeletions the deletions that are queued but not flushed yet.
+func (d *Deletions) PendingDeletions(_ context.Context) ([]models.Message, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	messages := make([]models.Message, 0, len(d.pending))
+	for message := range d.pending {
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// Close closes the journal file.
+func (d *Deletions) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+
+	err := d.file.Close()
+	d.file = nil
+
+	return err
+}
+
+func (d *Deletions) write(op string, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, message := range messages {
+		writeData, err := json.Marshal(deletionRecord{Op: op, Message: message})
+		if err != nil {
+			return err
+		}
+		buf.Write(append(writeData, '\n'))
+	}
+
+	if d.file == nil {
+		file, err := os.OpenFile(d.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		if err != nil {
+			return err
+		}
+		d.file = file
+	}
+
+	if _, err := d.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	return d.file.Sync()
+}
+
+func (d *Deletions) truncate() error {
+	if d.file == nil {
+		err := os.Truncate(d.filePath, 0)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// файл открыт на дозапись, поэтому смещение сбрасывать не нужно
+	return d.file.Truncate(0)
+}
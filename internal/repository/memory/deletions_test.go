@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "short-url-db.json.deletions")
+
+	first := models.Message{JobID: "job", UserID: "user", Code: "a"}
+	second := models.Message{JobID: "job", UserID: "user", Code: "b"}
+
+	d, err := NewDeletions(path)
+	require.NoError(t, err)
+
+	require.NoError(t, d.AppendDeletions(ctx, []models.Message{first, second}))
+	require.NoError(t, d.AckDeletions(ctx, []models.Message{first}))
+	require.NoError(t, d.Close())
+
+	// неподтверждённое удаление переживает перезапуск
+	d, err = NewDeletions(path)
+	require.NoError(t, err)
+	defer d.Close()
+
+	pending, err := d.PendingDeletions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Message{second}, pending)
+
+	// когда очередь пуста, журнал обнуляется
+	require.NoError(t, d.AckDeletions(ctx, []models.Message{second}))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	require.NoError(t, d.AppendDeletions(ctx, []models.Message{first}))
+	pending, err = d.PendingDeletions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Message{first}, pending)
+}
@@ -2,89 +2,350 @@ package memory
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"sync"
 
 	"github.com/Orendev/shortener/internal/logger"
 	"github.com/Orendev/shortener/internal/models"
+	"go.uber.org/zap"
 )
 
+// SyncPolicy defines when the appended log records are flushed to the disk.
+type SyncPolicy string
+
+// Supported fsync policies.
+const (
+	// SyncAlways fsync after every appended record.
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsync periodically in the background.
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leave flushing to the operating system.
+	SyncNever SyncPolicy = "never"
+)
+
+// ErrSyncPolicyUnknown the fsync policy is not one of always, interval or never.
+var ErrSyncPolicyUnknown = errors.New("unknown fsync policy, expected always, interval or never")
+
+// walSuffix the suffix of the write-ahead log file next to the snapshot file.
+const walSuffix = ".wal"
+
+// Write-ahead log operations.
+const (
+	opInsert = "insert"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// walRecord one mutation of the storage in the write-ahead log.
+type walRecord struct {
+	Op   string            `json:"op"`
+	Link *models.ShortLink `json:"link,omitempty"`
+	Code string            `json:"code,omitempty"`
+}
+
 // File - structure describing the File.
+//
+// The data is kept in two files: the snapshot with one models.ShortLink per line
+// and the append-only write-ahead log with one walRecord per mutation made after the snapshot.
 type File struct {
-	filePath string
+	filePath   string
+	syncPolicy SyncPolicy
+	mu         sync.Mutex
+	wal        *os.File
+	dirty      bool
 }
 
 // NewFile - constructor for the File.
 func NewFile(filePath string) *File {
 	return &File{
-		filePath: filePath,
+		filePath:   filePath,
+		syncPolicy: SyncAlways,
+	}
+}
+
+// Validate checks that the fsync policy is supported.
+func (p SyncPolicy) Validate() error {
+	switch p {
+	case SyncAlways, SyncInterval, SyncNever:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrSyncPolicyUnknown, p)
+}
+
+// SetSyncPolicy sets when the appended records are flushed to the disk.
+func (f *File) SetSyncPolicy(policy SyncPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
 	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.syncPolicy = policy
+
+	return nil
 }
 
-// Save saves data in a file.
+// Save writes the full snapshot of the data and resets the write-ahead log.
 func (f *File) Save(models map[string]models.ShortLink) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	file, err := os.OpenFile(f.filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	// пишем снимок во временный файл и атомарно подменяем им старый
+	tmpPath := f.filePath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.Log.Sugar().Errorf("error while closing file: %s", err)
-		}
-	}()
 
+	w := bufio.NewWriter(file)
 	for _, model := range models {
 		// serializing the structure in JSON format
 		writeData, err := json.Marshal(model)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+		_, err = w.Write(append(writeData, '\n'))
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+
+	if err = w.Flush(); err == nil {
+		err = file.Sync()
+	}
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmpPath, f.filePath); err != nil {
+		return err
+	}
+
+	// все записи журнала вошли в снимок
+	if f.wal != nil {
+		if err = f.wal.Truncate(0); err != nil {
+			return err
+		}
+		_, err = f.wal.Seek(0, io.SeekStart)
+		f.dirty = false
+		return err
+	}
+
+	err = os.Truncate(f.walPath(), 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// Append writes the records to the end of the write-ahead log.
+func (f *File) Append(records ...walRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, record := range records {
+		writeData, err := json.Marshal(record)
 		if err != nil {
 			return err
 		}
-		_, err = file.Write(append(writeData, '\n'))
+		buf.Write(append(writeData, '\n'))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.wal == nil {
+		wal, err := os.OpenFile(f.walPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 		if err != nil {
 			return err
 		}
+		f.wal = wal
+	}
+
+	// все записи одной мутации уходят одним вызовом write
+	_, err := f.wal.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if f.syncPolicy == SyncAlways {
+		return f.wal.Sync()
 	}
 
+	f.dirty = true
+
 	return nil
 }
 
+// Sync flushes the appended records to the disk.
+func (f *File) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.wal == nil || !f.dirty {
+		return nil
+	}
+
+	f.dirty = false
+
+	return f.wal.Sync()
+}
+
+// Close flushes and closes the write-ahead log.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.wal == nil {
+		return nil
+	}
+
+	err := f.wal.Sync()
+	if errClose := f.wal.Close(); err == nil {
+		err = errClose
+	}
+	f.wal = nil
+
+	return err
+}
+
 // Remove delete the file.
 func (f *File) Remove() error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	err := os.Remove(f.walPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
 	return os.Remove(f.filePath)
 }
 
-// Data read the data from the file.
+// Data read the snapshot and replay the write-ahead log on top of it.
 func (f *File) Data() (map[string]models.ShortLink, error) {
-	file, err := os.OpenFile(f.filePath, os.O_RDONLY|os.O_CREATE, 0666)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data := make(map[string]models.ShortLink)
+
+	err := readLines(f.filePath, func(line []byte) error {
+		model := models.ShortLink{}
+		if err := json.Unmarshal(line, &model); err != nil {
+			return err
+		}
+		data[model.Code] = model
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	err = readLines(f.walPath(), func(line []byte) error {
+		record := walRecord{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		return record.apply(data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (f *File) walPath() string {
+	return f.filePath + walSuffix
+}
+
+// apply replays the record on the data.
+func (r walRecord) apply(data map[string]models.ShortLink) error {
+	switch r.Op {
+	case opInsert, opUpdate:
+		if r.Link == nil {
+			return fmt.Errorf("wal record %q without link", r.Op)
+		}
+		data[r.Link.Code] = *r.Link
+	case opDelete:
+		if link, ok := data[r.Code]; ok {
+			link.DeletedFlag = true
+			data[r.Code] = link
+		}
+	default:
+		return fmt.Errorf("unknown wal record %q", r.Op)
+	}
+
+	return nil
+}
+
+// readLines calls fn for every line of the file, creating the file if it does not exist.
+// A corrupt last line is the trace of an interrupted write: it is cut off instead of failing.
+func readLines(path string, fn func(line []byte) error) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+
 	defer func() {
 		if err = file.Close(); err != nil {
 			logger.Log.Sugar().Errorf("error when closing a file while reading: %s", err)
 		}
 	}()
 
-	scan := bufio.NewScanner(file)
-	data := make(map[string]models.ShortLink)
+	reader := bufio.NewReader(file)
+	var offset int64
+	var lineNum int
 
 	for {
-		if !scan.Scan() {
-			break
+		line, errRead := reader.ReadBytes('\n')
+		if errRead != nil && !errors.Is(errRead, io.EOF) {
+			return errRead
 		}
-		model := models.ShortLink{}
+		if len(line) == 0 && errors.Is(errRead, io.EOF) {
+			return nil
+		}
+		lineNum++
 
-		err = json.Unmarshal(scan.Bytes(), &model)
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if errLine := fn(trimmed); errLine != nil {
+				// повреждённая строка допустима только в самом конце файла
+				rest, errRest := io.ReadAll(reader)
+				if errRest != nil {
+					return errRest
+				}
+				if len(bytes.TrimSpace(rest)) > 0 {
+					return fmt.Errorf("%s:%d: %w", path, lineNum, errLine)
+				}
 
-		if err != nil {
-			return nil, err
+				logger.Log.Warn("truncating corrupt tail of the file",
+					zap.String("file", path), zap.Int("line", lineNum), zap.Error(errLine))
+
+				return file.Truncate(offset)
+			}
 		}
 
-		data[model.Code] = model
+		offset += int64(len(line))
 
+		if errors.Is(errRead, io.EOF) {
+			// последняя строка без перевода строки склеилась бы со следующей дописанной записью
+			_, err = file.WriteAt([]byte{'\n'}, offset)
+			return err
+		}
 	}
-
-	return data, nil
 }
@@ -1,12 +1,18 @@
 package memory
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Orendev/shortener/internal/config"
 	"github.com/Orendev/shortener/internal/models"
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -168,3 +174,92 @@ func TestFile_Data(t *testing.T) {
 		})
 	}
 }
+
+func TestMemory_WriteAheadLog(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "short-url-db.json")
+
+	s, err := NewMemory(config.File{FileStoragePath: filePath, SyncPolicy: string(SyncAlways)})
+	require.NoError(t, err)
+
+	first := models.ShortLink{UUID: uuid.New().String(), Code: "first", OriginalURL: "http://yandex.ru", CreatedAt: createdAt}
+	second := models.ShortLink{UUID: uuid.New().String(), Code: "second", OriginalURL: "http://ya.ru", CreatedAt: createdAt}
+
+	require.NoError(t, s.Save(ctx, first))
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{second}))
+	second.OriginalURL = "http://practicum.yandex.ru"
+	require.NoError(t, s.UpdateBatch(ctx, []models.ShortLink{second}))
+	_, err = s.DeleteFlagBatch(ctx, []string{"first"}, "")
+	require.NoError(t, err)
+
+	// каждая мутация дописана в журнал, снимок ещё пуст
+	wal, err := os.ReadFile(filePath + walSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, 4, strings.Count(string(wal), "\n"))
+
+	replayed, err := NewFile(filePath).Data()
+	require.NoError(t, err)
+
+	first.DeletedFlag = true
+	assert.Equal(t, map[string]models.ShortLink{"first": first, "second": second}, replayed)
+
+	// сжатие переносит журнал в снимок
+	require.NoError(t, s.Compact())
+	wal, err = os.ReadFile(filePath + walSuffix)
+	require.NoError(t, err)
+	assert.Empty(t, wal)
+
+	compacted, err := NewFile(filePath).Data()
+	require.NoError(t, err)
+	assert.Equal(t, replayed, compacted)
+
+	require.NoError(t, s.Close())
+}
+
+func TestFile_DataCorruptTail(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "short-url-db.json")
+
+	link, err := json.Marshal(walRecord{Op: opInsert, Link: &models.ShortLink{Code: "4rSPg8ap", OriginalURL: "http://yandex.ru"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		wal     string
+		wantErr bool
+		wantWAL string
+	}{
+		{
+			name:    "interrupted last record is cut off",
+			wal:     string(link) + "\n" + `{"op":"insert","link":{"co`,
+			wantWAL: string(link) + "\n",
+		},
+		{
+			name:    "missing trailing newline is restored",
+			wal:     string(link),
+			wantWAL: string(link) + "\n",
+		},
+		{
+			name:    "corrupt record in the middle fails",
+			wal:     "garbage\n" + string(link) + "\n",
+			wantErr: true,
+			wantWAL: "garbage\n" + string(link) + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(filePath+walSuffix, []byte(tt.wal), 0666))
+
+			data, err := NewFile(filePath).Data()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Contains(t, data, "4rSPg8ap")
+			}
+
+			wal, err := os.ReadFile(filePath + walSuffix)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWAL, string(wal))
+		})
+	}
+}
@@ -2,35 +2,79 @@ package memory
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/logger"
 	"github.com/Orendev/shortener/internal/models"
 	"github.com/Orendev/shortener/internal/repository"
+	"go.uber.org/zap"
 )
 
+// syncInterval how often the write-ahead log is flushed with the SyncInterval policy.
+const syncInterval = time.Second
+
 // Memory - structure describing the Memory.
+//
+// Memory is safe for concurrent use. Besides the links by code it maintains
+// the indexes by UUID, original URL and user ID, so every lookup is a map access.
 type Memory struct {
-	data map[string]models.ShortLink
-	file *File
+	mu            sync.RWMutex
+	data          map[string]models.ShortLink
+	byUUID        map[string]string
+	byOriginalURL map[string]string
+	byUserID      map[string]map[string]struct{}
+	file          *File
+	done          chan struct{}
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+	closeErr      error
 }
 
 // NewMemory - constructor a new instance of Memory.
-func NewMemory(filePath string) (*Memory, error) {
+//
+// The data is restored from the snapshot and the write-ahead log, after that the log
+// is compacted into the snapshot every cfg.CompactInterval in the background.
+func NewMemory(cfg config.File) (*Memory, error) {
+
+	file := NewFile(cfg.FileStoragePath)
 
-	file := NewFile(filePath)
+	if len(cfg.SyncPolicy) > 0 {
+		if err := file.SetSyncPolicy(SyncPolicy(cfg.SyncPolicy)); err != nil {
+			return nil, err
+		}
+	}
 
 	data, err := file.Data()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Memory{
-		data: data,
-		file: file,
-	}, nil
+	s := &Memory{
+		data:          make(map[string]models.ShortLink, len(data)),
+		byUUID:        make(map[string]string, len(data)),
+		byOriginalURL: make(map[string]string, len(data)),
+		byUserID:      make(map[string]map[string]struct{}),
+		file:          file,
+		done:          make(chan struct{}),
+	}
+
+	for _, link := range data {
+		s.put(link)
+	}
+
+	s.wg.Add(1)
+	go s.background(cfg.CompactInterval, SyncPolicy(cfg.SyncPolicy) == SyncInterval)
+
+	return s, nil
 }
 
 // GetByCode we get a model models.ShortLink of a short link by code.
 func (s *Memory) GetByCode(_ context.Context, code string) (*models.ShortLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	shortLink, ok := s.data[code]
 	if !ok {
 		return nil, repository.ErrNotFound
@@ -40,137 +84,333 @@ func (s *Memory) GetByCode(_ context.Context, code string) (*models.ShortLink, e
 
 // GetByID we get a model models.ShortLink of a short link by id.
 func (s *Memory) GetByID(_ context.Context, id string) (*models.ShortLink, error) {
-	var shortLink models.ShortLink
-	ok := false
-
-	for _, link := range s.data {
-		if link.UUID == id {
-			shortLink = link
-			ok = true
-			break
-		}
-	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if !ok {
-		return nil, repository.ErrNotFound
-	}
-
-	return &shortLink, nil
+	return s.lookup(s.byUUID, id)
 }
 
-// ShortLinksByUserID we will get a list of the user's short link models.ShortLink.
-func (s *Memory) ShortLinksByUserID(_ context.Context, userID string, limit int) ([]models.ShortLink, error) {
-	shortLinks := make([]models.ShortLink, 0, limit)
+// ShortLinksByUserID we will get a page of the user's short link models.ShortLink.
+func (s *Memory) ShortLinksByUserID(_ context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	for _, link := range s.data {
-		if link.UserID == userID {
-			shortLinks = append(shortLinks, link)
-		}
+	codes := s.byUserID[query.UserID]
+	shortLinks := make([]models.ShortLink, 0, len(codes))
+
+	for code := range codes {
+		shortLinks = append(shortLinks, s.data[code])
 	}
 
-	return shortLinks, nil
+	return query.Page(shortLinks)
 }
 
 // GetByOriginalURL we will get the model with a short link models.ShortLink to the original URL.
 func (s *Memory) GetByOriginalURL(_ context.Context, originalURL string) (*models.ShortLink, error) {
-	var shortLink models.ShortLink
-	ok := false
-
-	for _, link := range s.data {
-
-		if link.OriginalURL == originalURL {
-			shortLink = link
-			ok = true
-			break
-		}
-	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if !ok {
-		return nil, repository.ErrNotFound
-	}
-
-	return &shortLink, nil
+	return s.lookup(s.byOriginalURL, originalURL)
 }
 
 // Save let's save the model of the short link models.ShortLink.
 func (s *Memory) Save(_ context.Context, model models.ShortLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	for _, link := range s.data {
-		if link.OriginalURL == model.OriginalURL {
-			return repository.ErrConflict
-		}
+	if _, ok := s.byOriginalURL[model.OriginalURL]; ok {
+		return repository.ErrConflict
+	}
+
+	if _, ok := s.data[model.Code]; ok {
+		return repository.ErrCodeConflict
 	}
+
 	model.DeletedFlag = false
-	s.data[model.Code] = model
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = time.Now().UTC()
+	}
 
-	err := s.file.Save(s.data)
+	// сначала журнал, затем память: не записанное в журнал изменение не должно стать видимым
+	err := s.file.Append(walRecord{Op: opInsert, Link: &model})
 	if err != nil {
 		return err
 	}
 
+	s.put(model)
+
 	return nil
 }
 
 // InsertBatch group insertion of short link models []models.ShortLink.
 func (s *Memory) InsertBatch(_ context.Context, shortLinks []models.ShortLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	// индексы проверяются до записи в журнал, как и уникальные ключи в Postgres и KV
+	codes := make(map[string]struct{}, len(shortLinks))
+	uuids := make(map[string]struct{}, len(shortLinks))
+	originalURLs := make(map[string]struct{}, len(shortLinks))
 	for _, link := range shortLinks {
+		if _, ok := s.byOriginalURL[link.OriginalURL]; ok {
+			return repository.ErrConflict
+		}
+		if _, ok := originalURLs[link.OriginalURL]; ok {
+			return repository.ErrConflict
+		}
+		if _, ok := s.byUUID[link.UUID]; ok {
+			return repository.ErrConflict
+		}
+		if _, ok := uuids[link.UUID]; ok {
+			return repository.ErrConflict
+		}
+		if _, ok := s.data[link.Code]; ok {
+			return repository.ErrCodeConflict
+		}
+		if _, ok := codes[link.Code]; ok {
+			return repository.ErrCodeConflict
+		}
+
+		codes[link.Code] = struct{}{}
+		uuids[link.UUID] = struct{}{}
+		originalURLs[link.OriginalURL] = struct{}{}
+	}
+
+	now := time.Now().UTC()
+
+	records := make([]walRecord, 0, len(shortLinks))
+	for i := range shortLinks {
+		link := shortLinks[i]
 		link.DeletedFlag = false
-		s.data[link.Code] = link
+		if link.CreatedAt.IsZero() {
+			link.CreatedAt = now
+		}
+		records = append(records, walRecord{Op: opInsert, Link: &link})
 	}
-	err := s.file.Save(s.data)
+
+	err := s.file.Append(records...)
 	if err != nil {
 		return err
 	}
 
+	for _, record := range records {
+		s.put(*record.Link)
+	}
+
 	return nil
 }
 
 // UpdateBatch group update of short link models []models.ShortLink.
 func (s *Memory) UpdateBatch(_ context.Context, shortLinks []models.ShortLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// исходный URL не может перейти к другой ссылке, ни существующей, ни из этой же пачки
+	originalURLs := make(map[string]string, len(shortLinks))
 	for _, link := range shortLinks {
-		s.data[link.Code] = link
+		if code, ok := s.byOriginalURL[link.OriginalURL]; ok && code != link.Code {
+			return repository.ErrConflict
+		}
+		if code, ok := originalURLs[link.OriginalURL]; ok && code != link.Code {
+			return repository.ErrConflict
+		}
+		if code, ok := s.byUUID[link.UUID]; ok && code != link.Code {
+			return repository.ErrConflict
+		}
+
+		originalURLs[link.OriginalURL] = link.Code
+	}
+
+	records := make([]walRecord, 0, len(shortLinks))
+	for i := range shortLinks {
+		link := shortLinks[i]
+		// время создания не меняется при обновлении
+		if old, ok := s.data[link.Code]; ok && link.CreatedAt.IsZero() {
+			link.CreatedAt = old.CreatedAt
+		}
+		records = append(records, walRecord{Op: opUpdate, Link: &link})
 	}
-	err := s.file.Save(s.data)
+
+	err := s.file.Append(records...)
 	if err != nil {
 		return err
 	}
+
+	for _, record := range records {
+		s.put(*record.Link)
+	}
+
 	return nil
 }
 
-// DeleteFlagBatch group delete of short link models []models.ShortLink.
-func (s *Memory) DeleteFlagBatch(ctx context.Context, codes []string, _ string) error {
+// DeleteFlagBatch group delete of the user's short links, returns the codes that were deleted.
+func (s *Memory) DeleteFlagBatch(_ context.Context, codes []string, userID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := make([]string, 0, len(codes))
+	records := make([]walRecord, 0, len(codes))
 	for _, code := range codes {
-		model, err := s.GetByCode(ctx, code)
-		if err != nil {
-			continue
+		// чужие и несуществующие ссылки пропускаются
+		if link, ok := s.data[code]; ok && link.UserID == userID {
+			deleted = append(deleted, code)
+			records = append(records, walRecord{Op: opDelete, Code: code})
 		}
-		model.DeletedFlag = true
-		s.data[code] = *model
 	}
-	err := s.file.Save(s.data)
+
+	if err := s.applyDeletes(records); err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// ClaimShortLinks moves the links of the user fromUserID to toUserID, returns the codes of the moved links.
+func (s *Memory) ClaimShortLinks(_ context.Context, fromUserID, toUserID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := s.byUserID[fromUserID]
+	claimed := make([]string, 0, len(codes))
+	records := make([]walRecord, 0, len(codes))
+	for code := range codes {
+		link := s.data[code]
+		link.UserID = toUserID
+		claimed = append(claimed, code)
+		records = append(records, walRecord{Op: opUpdate, Link: &link})
+	}
+
+	if len(records) == 0 {
+		return claimed, nil
+	}
+
+	err := s.file.Append(records...)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
 
+	for _, record := range records {
+		s.put(*record.Link)
+	}
+
+	return claimed, nil
 }
 
-// UrlsStats number of abbreviated URLs in the service.
-func (s Memory) UrlsStats(_ context.Context) (int, error) {
-	return len(s.data), nil
+// UpdateOriginalURL changes the original URL of the user's short link.
+func (s *Memory) UpdateOriginalURL(_ context.Context, code, userID, originalURL string) (*models.ShortLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shortLink, ok := s.data[code]
+	if !ok || shortLink.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+
+	if other, ok := s.byOriginalURL[originalURL]; ok && other != code {
+		return nil, repository.ErrConflict
+	}
+
+	shortLink.OriginalURL = originalURL
+
+	return s.update(shortLink)
 }
 
-// UsersStats number of users in the service.
-func (s Memory) UsersStats(_ context.Context) (int, error) {
+// Restore cancels the soft delete of the user's short link.
+func (s *Memory) Restore(_ context.Context, code, userID string) (*models.ShortLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shortLink, ok := s.data[code]
+	if !ok || shortLink.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+
+	shortLink.DeletedFlag = false
+
+	return s.update(shortLink)
+}
+
+// DeleteExpired soft-deletes the short links whose lifetime is over at the moment now.
+func (s *Memory) DeleteExpired(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]walRecord, 0)
+	for code, link := range s.data {
+		if link.DeletedFlag || !link.IsExpired(now) {
+			continue
+		}
+		records = append(records, walRecord{Op: opDelete, Code: code})
+	}
+
+	err := s.applyDeletes(records)
+	if err != nil {
+		return 0, err
+	}
 
-	shortLinks := make(map[string]models.ShortLink)
+	return len(records), nil
+}
+
+// RecentShortLinks the page of the links of all users, or of query.UserID when it is set.
+func (s *Memory) RecentShortLinks(_ context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
+	shortLinks := make([]models.ShortLink, 0, len(s.data))
 	for _, link := range s.data {
-		shortLinks[link.UserID] = link
+		if len(query.UserID) > 0 && link.UserID != query.UserID {
+			continue
+		}
+		shortLinks = append(shortLinks, link)
+	}
+
+	return query.Page(shortLinks)
+}
+
+// DisableShortLink disables the link with the reason.
+func (s *Memory) DisableShortLink(_ context.Context, code, reason string, legal bool) (*models.ShortLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shortLink, ok := s.data[code]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	shortLink.DisabledReason, shortLink.DisabledLegal = reason, legal
+
+	return s.update(shortLink)
+}
+
+// EnableShortLink cancels the disabling of the link.
+func (s *Memory) EnableShortLink(_ context.Context, code string) (*models.ShortLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shortLink, ok := s.data[code]
+	if !ok {
+		return nil, repository.ErrNotFound
 	}
 
-	return len(shortLinks), nil
+	shortLink.DisabledReason, shortLink.DisabledLegal = "", false
+
+	return s.update(shortLink)
+}
+
+// UrlsStats number of abbreviated URLs in the service.
+func (s *Memory) UrlsStats(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.data), nil
+}
+
+// UsersStats number of users in the service.
+func (s *Memory) UsersStats(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.byUserID), nil
 }
 
 // Ping service check.
@@ -178,7 +418,141 @@ func (s *Memory) Ping(_ context.Context) error {
 	return nil
 }
 
-// Close closing the service.
+// Compact writes the current data into the snapshot and resets the write-ahead log.
+func (s *Memory) Compact() error {
+	// блокировка на чтение не пускает мутации между снимком и очисткой журнала
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.file.Save(s.data)
+}
+
+// Close closing the service, the repeated calls return the result of the first one.
 func (s *Memory) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+
+		s.closeErr = s.Compact()
+		if errClose := s.file.Close(); s.closeErr == nil {
+			s.closeErr = errClose
+		}
+	})
+
+	return s.closeErr
+}
+
+// lookup finds the link by the code stored in the index, the caller holds the lock.
+func (s *Memory) lookup(index map[string]string, key string) (*models.ShortLink, error) {
+	code, ok := index[key]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	shortLink := s.data[code]
+
+	return &shortLink, nil
+}
+
+// put stores the link and keeps the indexes in step, the caller holds the write lock.
+func (s *Memory) put(link models.ShortLink) {
+	if old, ok := s.data[link.Code]; ok {
+		s.unindex(old)
+	}
+
+	s.data[link.Code] = link
+
+	s.byUUID[link.UUID] = link.Code
+	s.byOriginalURL[link.OriginalURL] = link.Code
+
+	codes, ok := s.byUserID[link.UserID]
+	if !ok {
+		codes = make(map[string]struct{})
+		s.byUserID[link.UserID] = codes
+	}
+	codes[link.Code] = struct{}{}
+}
+
+// unindex removes the link from the indexes, the caller holds the write lock.
+func (s *Memory) unindex(link models.ShortLink) {
+	if s.byUUID[link.UUID] == link.Code {
+		delete(s.byUUID, link.UUID)
+	}
+
+	if s.byOriginalURL[link.OriginalURL] == link.Code {
+		delete(s.byOriginalURL, link.OriginalURL)
+	}
+
+	if codes, ok := s.byUserID[link.UserID]; ok {
+		delete(codes, link.Code)
+		if len(codes) == 0 {
+			delete(s.byUserID, link.UserID)
+		}
+	}
+}
+
+// update logs and stores the changed link, the caller holds the write lock.
+func (s *Memory) update(shortLink models.ShortLink) (*models.ShortLink, error) {
+	err := s.file.Append(walRecord{Op: opUpdate, Link: &shortLink})
+	if err != nil {
+		return nil, err
+	}
+
+	s.put(shortLink)
+
+	return &shortLink, nil
+}
+
+// applyDeletes logs the delete records and marks the links as deleted, the caller holds the write lock.
+func (s *Memory) applyDeletes(records []walRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	err := s.file.Append(records...)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err = record.apply(s.data); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// background compacts the write-ahead log and flushes it with the SyncInterval policy until Close.
+func (s *Memory) background(compactInterval time.Duration, periodicSync bool) {
+	defer s.wg.Done()
+
+	var compactC, syncC <-chan time.Time
+
+	if compactInterval > 0 {
+		compactTicker := time.NewTicker(compactInterval)
+		defer compactTicker.Stop()
+		compactC = compactTicker.C
+	}
+
+	if periodicSync {
+		syncTicker := time.NewTicker(syncInterval)
+		defer syncTicker.Stop()
+		syncC = syncTicker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-compactC:
+			if err := s.Compact(); err != nil {
+				logger.Log.Error("cannot compact the storage file", zap.Error(err))
+			}
+		case <-syncC:
+			if err := s.file.Sync(); err != nil {
+				logger.Log.Error("cannot sync the storage file", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemory(t *testing.T) *Memory {
+	t.Helper()
+
+	s, err := NewMemory(config.File{
+		FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json"),
+		SyncPolicy:      string(SyncNever),
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+
+	return s
+}
+
+// createdAt the fixed creation time, so the stored links compare equal.
+var createdAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// userLinks the first page of the user's links in the default order.
+func userLinks(ctx context.Context, s *Memory, userID string, limit int) ([]models.ShortLink, error) {
+	query := models.NewShortLinksQuery(userID)
+	query.Limit = limit
+
+	page, err := s.ShortLinksByUserID(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Links, nil
+}
+
+func TestMemory_Indexes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestMemory(t)
+
+	link := models.ShortLink{UUID: uuid.New().String(), UserID: "user", Code: "code", OriginalURL: "http://yandex.ru", CreatedAt: createdAt}
+	require.NoError(t, s.Save(ctx, link))
+
+	got, err := s.GetByID(ctx, link.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	got, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	assert.ErrorIs(t, s.Save(ctx, models.ShortLink{UUID: uuid.New().String(), Code: "other", OriginalURL: link.OriginalURL}), repository.ErrConflict)
+
+	// обновление переносит ссылку в индексах
+	updated := link
+	updated.UserID = "another"
+	updated.OriginalURL = "http://ya.ru"
+	require.NoError(t, s.UpdateBatch(ctx, []models.ShortLink{updated}))
+
+	_, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	got, err = s.GetByOriginalURL(ctx, updated.OriginalURL)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *got)
+
+	links, err := userLinks(ctx, s, "user", 100)
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	links, err = userLinks(ctx, s, "another", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ShortLink{updated}, links)
+
+	// удалить ссылку может только её владелец
+	deleted, err := s.DeleteFlagBatch(ctx, []string{link.Code, "missing"}, "user")
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	got, err = s.GetByCode(ctx, link.Code)
+	require.NoError(t, err)
+	assert.False(t, got.DeletedFlag)
+
+	users, err := s.UsersStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, users)
+
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "another", Code: "a", OriginalURL: "http://a.ru"},
+		{UUID: uuid.New().String(), UserID: "another", Code: "b", OriginalURL: "http://b.ru"},
+	}))
+
+	links, err = userLinks(ctx, s, "another", 2)
+	require.NoError(t, err)
+	assert.Len(t, links, 2)
+}
+
+func TestMemory_BatchConflict(t *testing.T) {
+	ctx := context.Background()
+	s := newTestMemory(t)
+
+	link := models.ShortLink{UUID: uuid.New().String(), UserID: "user", Code: "code", OriginalURL: "http://yandex.ru", CreatedAt: createdAt}
+	require.NoError(t, s.Save(ctx, link))
+
+	tests := []struct {
+		name  string
+		links []models.ShortLink
+		want  error
+	}{
+		{
+			name:  "taken original URL",
+			links: []models.ShortLink{{UUID: uuid.New().String(), Code: "a", OriginalURL: link.OriginalURL}},
+			want:  repository.ErrConflict,
+		},
+		{
+			name:  "taken UUID",
+			links: []models.ShortLink{{UUID: link.UUID, Code: "a", OriginalURL: "http://a.ru"}},
+			want:  repository.ErrConflict,
+		},
+		{
+			name:  "taken code",
+			links: []models.ShortLink{{UUID: uuid.New().String(), Code: link.Code, OriginalURL: "http://a.ru"}},
+			want:  repository.ErrCodeConflict,
+		},
+		{
+			name: "original URL repeated in the batch",
+			links: []models.ShortLink{
+				{UUID: uuid.New().String(), Code: "a", OriginalURL: "http://a.ru"},
+				{UUID: uuid.New().String(), Code: "b", OriginalURL: "http://a.ru"},
+			},
+			want: repository.ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, s.InsertBatch(ctx, tt.links), tt.want)
+		})
+	}
+
+	// обновление не переносит чужой исходный URL
+	other := models.ShortLink{UUID: uuid.New().String(), UserID: "user", Code: "other", OriginalURL: "http://go.dev", CreatedAt: createdAt}
+	require.NoError(t, s.Save(ctx, other))
+
+	moved := other
+	moved.OriginalURL = link.OriginalURL
+	assert.ErrorIs(t, s.UpdateBatch(ctx, []models.ShortLink{moved}), repository.ErrConflict)
+
+	// индексы не изменились
+	got, err := s.GetByOriginalURL(ctx, link.OriginalURL)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	got, err = s.GetByCode(ctx, other.Code)
+	require.NoError(t, err)
+	assert.Equal(t, other, *got)
+
+	_, err = s.GetByCode(ctx, "a")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestMemory_Restore(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")}
+
+	s, err := NewMemory(cfg)
+	require.NoError(t, err)
+
+	link := models.ShortLink{UUID: uuid.New().String(), UserID: "user", Code: "code", OriginalURL: "http://yandex.ru", CreatedAt: createdAt}
+	require.NoError(t, s.Save(ctx, link))
+	require.NoError(t, s.Close())
+
+	// индексы восстанавливаются вместе с данными
+	s, err = NewMemory(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	got, err := s.GetByID(ctx, link.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, link, *got)
+
+	links, err := userLinks(ctx, s, link.UserID, 100)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ShortLink{link}, links)
+}
+
+func TestMemory_ClaimShortLinks(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")}
+
+	s, err := NewMemory(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "anonymous", Code: "first", OriginalURL: "http://yandex.ru", CreatedAt: createdAt},
+		{UUID: uuid.New().String(), UserID: "account", Code: "second", OriginalURL: "http://go.dev", CreatedAt: createdAt},
+	}))
+
+	claimed, err := s.ClaimShortLinks(ctx, "anonymous", "account")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, claimed)
+	require.NoError(t, s.Close())
+
+	// перенос записан в журнал и переживает перезапуск
+	s, err = NewMemory(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	links, err := userLinks(ctx, s, "account", 100)
+	require.NoError(t, err)
+	assert.Len(t, links, 2)
+
+	links, err = userLinks(ctx, s, "anonymous", 100)
+	require.NoError(t, err)
+	assert.Empty(t, links)
+}
+
+func TestMemory_DisableShortLink(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.File{FileStoragePath: filepath.Join(t.TempDir(), "short-url-db.json")}
+
+	s, err := NewMemory(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, s.InsertBatch(ctx, []models.ShortLink{
+		{UUID: uuid.New().String(), UserID: "user", Code: "first", OriginalURL: "http://yandex.ru", CreatedAt: createdAt},
+		{UUID: uuid.New().String(), UserID: "another", Code: "second", OriginalURL: "http://go.dev", CreatedAt: createdAt.Add(time.Second)},
+	}))
+
+	_, err = s.DisableShortLink(ctx, "first", "phishing", true)
+	require.NoError(t, err)
+	_, err = s.EnableShortLink(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	require.NoError(t, s.Close())
+
+	// отключение записано в журнал и переживает перезапуск
+	s, err = NewMemory(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	got, err := s.GetByCode(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, "phishing", got.DisabledReason)
+	assert.True(t, got.DisabledLegal)
+
+	query := models.NewShortLinksQuery("")
+	query.Sort = models.SortCreatedAtDesc
+	page, err := s.RecentShortLinks(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, page.Links, 2)
+	assert.Equal(t, "second", page.Links[0].Code)
+}
+
+// TestMemory_Concurrent is meant to be run with the race detector: go test -race.
+func TestMemory_Concurrent(t *testing.T) {
+	const (
+		workers = 16
+		perUser = 50
+	)
+
+	ctx := context.Background()
+	s := newTestMemory(t)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			userID := fmt.Sprintf("user-%d", w)
+			codes := make([]string, 0, perUser)
+
+			for i := 0; i < perUser; i++ {
+				link := models.ShortLink{
+					UUID:        uuid.New().String(),
+					UserID:      userID,
+					Code:        fmt.Sprintf("%d-%d", w, i),
+					OriginalURL: fmt.Sprintf("http://example.com/%d/%d", w, i),
+				}
+
+				if i%2 == 0 {
+					assert.NoError(t, s.Save(ctx, link))
+				} else {
+					assert.NoError(t, s.InsertBatch(ctx, []models.ShortLink{link}))
+				}
+				codes = append(codes, link.Code)
+
+				_, err := s.GetByCode(ctx, link.Code)
+				assert.NoError(t, err)
+				_, err = s.GetByID(ctx, link.UUID)
+				assert.NoError(t, err)
+				_, err = s.GetByOriginalURL(ctx, link.OriginalURL)
+				assert.NoError(t, err)
+				_, err = userLinks(ctx, s, userID, 10)
+				assert.NoError(t, err)
+				_, err = s.UsersStats(ctx)
+				assert.NoError(t, err)
+
+				if i%10 == 9 {
+					link.OriginalURL += "/moved"
+					assert.NoError(t, s.UpdateBatch(ctx, []models.ShortLink{link}))
+					deleted, err := s.DeleteFlagBatch(ctx, codes[len(codes)-2:], userID)
+					assert.NoError(t, err)
+					assert.Len(t, deleted, 2)
+				}
+			}
+		}(w)
+	}
+
+	// сжатие идёт параллельно с мутациями
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, s.Compact())
+		}
+	}()
+
+	wg.Wait()
+
+	urls, err := s.UrlsStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, workers*perUser, urls)
+
+	users, err := s.UsersStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, workers, users)
+
+	for w := 0; w < workers; w++ {
+		links, err := userLinks(ctx, s, fmt.Sprintf("user-%d", w), models.MaxPageLimit)
+		require.NoError(t, err)
+		assert.Len(t, links, perUser)
+	}
+}
+
+func TestMemory_CloseTwice(t *testing.T) {
+	s := newTestMemory(t)
+
+	// повторное закрытие не паникует, очистка теста закрывает хранилище ещё раз
+	require.NoError(t, s.Close())
+	assert.NoError(t, s.Close())
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// banRecord the line of the bans file, the lifted ban is a record with Lifted set.
+type banRecord struct {
+	models.UserBan
+	Lifted bool `json:"lifted,omitempty"`
+}
+
+// Bans - structure describing the in-memory storage of the banned users.
+//
+// With a file path every ban and unban is appended to the file as a line,
+// so the bans survive the restart of the file and KV backends.
+type Bans struct {
+	filePath string
+	mu       sync.Mutex
+	bans     map[string]models.UserBan
+}
+
+// NewBans - constructor a new instance of Bans reading the bans at filePath,
+// the bans are kept only in memory when filePath is empty.
+func NewBans(filePath string) (*Bans, error) {
+	b := &Bans{
+		filePath: filePath,
+		bans:     make(map[string]models.UserBan),
+	}
+
+	if len(filePath) == 0 {
+		return b, nil
+	}
+
+	err := readLines(filePath, func(line []byte) error {
+		record := banRecord{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		b.apply(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// BanUser stores the ban.
+func (b *Bans) BanUser(_ context.Context, ban models.UserBan) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.write(banRecord{UserBan: ban})
+}
+
+// UnbanUser lifts the ban of the user.
+func (b *Bans) UnbanUser(_ context.Context, userID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.bans[userID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	return b.write(banRecord{UserBan: models.UserBan{UserID: userID}, Lifted: true})
+}
+
+// GetBan the ban of the user.
+func (b *Bans) GetBan(_ context.Context, userID string) (*models.UserBan, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban, ok := b.bans[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &ban, nil
+}
+
+// write appends the record to the file and applies it, the caller holds the lock.
+func (b *Bans) write(record banRecord) error {
+	if len(b.filePath) > 0 {
+		if err := appendLine(b.filePath, record); err != nil {
+			return err
+		}
+	}
+
+	b.apply(record)
+
+	return nil
+}
+
+func (b *Bans) apply(record banRecord) {
+	if record.Lifted {
+		delete(b.bans, record.UserID)
+		return
+	}
+	b.bans[record.UserID] = record.UserBan
+}
+
+// Audit - structure describing the in-memory audit trail of the moderators' actions.
+//
+// With a file path every entry is appended to the file as a line.
+type Audit struct {
+	filePath string
+	mu       sync.Mutex
+	entries  []models.AuditEntry
+}
+
+// NewAudit - constructor a new instance of Audit reading the entries at filePath,
+// the entries are kept only in memory when filePath is empty.
+func NewAudit(filePath string) (*Audit, error) {
+	a := &Audit{filePath: filePath}
+
+	if len(filePath) == 0 {
+		return a, nil
+	}
+
+	err := readLines(filePath, func(line []byte) error {
+		entry := models.AuditEntry{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		a.entries = append(a.entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// AppendAudit stores the entry.
+func (a *Audit) AppendAudit(_ context.Context, entry models.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.filePath) > 0 {
+		if err := appendLine(a.filePath, entry); err != nil {
+			return err
+		}
+	}
+
+	a.entries = append(a.entries, entry)
+
+	return nil
+}
+
+// AuditEntries the newest entries first.
+func (a *Audit) AuditEntries(_ context.Context, limit int) ([]models.AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := make([]models.AuditEntry, 0, limit)
+	for i := len(a.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, a.entries[i])
+	}
+
+	return entries, nil
+}
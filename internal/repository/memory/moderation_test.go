@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBans(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bans")
+
+	b, err := NewBans(path)
+	require.NoError(t, err)
+
+	ban := models.UserBan{UserID: "spammer", Reason: "phishing", BannedBy: "admin", BannedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, b.BanUser(ctx, ban))
+	require.NoError(t, b.BanUser(ctx, models.UserBan{UserID: "other", Reason: "spam"}))
+	require.NoError(t, b.UnbanUser(ctx, "other"))
+	assert.ErrorIs(t, b.UnbanUser(ctx, "other"), repository.ErrNotFound)
+
+	// баны восстанавливаются из файла
+	restored, err := NewBans(path)
+	require.NoError(t, err)
+
+	got, err := restored.GetBan(ctx, ban.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, ban, *got)
+
+	_, err = restored.GetBan(ctx, "other")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit")
+
+	a, err := NewAudit(path)
+	require.NoError(t, err)
+
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, a.AppendAudit(ctx, models.AuditEntry{
+			ID: id, AdminID: "admin", Action: models.AuditLinkDisabled, Target: "code" + id, CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		}))
+	}
+
+	restored, err := NewAudit(path)
+	require.NoError(t, err)
+
+	entries, err := restored.AuditEntries(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "3", entries[0].ID)
+	assert.Equal(t, "2", entries[1].ID)
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// Sessions - structure describing the in-memory storage of the refresh tokens.
+type Sessions struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+}
+
+// NewSessions - constructor a new instance of Sessions.
+func NewSessions() *Sessions {
+	return &Sessions{
+		tokens: make(map[string]models.RefreshToken),
+	}
+}
+
+// SaveRefreshToken stores the refresh token, dropping the expired ones.
+func (s *Sessions) SaveRefreshToken(_ context.Context, token models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, old := range s.tokens {
+		if !token.CreatedAt.Before(old.ExpiresAt) {
+			delete(s.tokens, hash)
+		}
+	}
+
+	s.tokens[token.Hash] = token
+
+	return nil
+}
+
+// GetRefreshToken the refresh token by the hash.
+func (s *Sessions) GetRefreshToken(_ context.Context, hash string) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[hash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes the refresh token unless it is already revoked.
+func (s *Sessions) RevokeRefreshToken(_ context.Context, hash string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[hash]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+
+	token.RevokedAt = &at
+	s.tokens[hash] = token
+
+	return true, nil
+}
+
+// RevokeUserRefreshTokens revokes all the refresh tokens of the user.
+func (s *Sessions) RevokeUserRefreshTokens(_ context.Context, userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, token := range s.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			s.tokens[hash] = token
+		}
+	}
+
+	return nil
+}
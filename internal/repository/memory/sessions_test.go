@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	s := NewSessions()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, hash := range []string{"a", "b"} {
+		require.NoError(t, s.SaveRefreshToken(ctx, models.RefreshToken{Hash: hash, UserID: "user", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	}
+
+	ok, err := s.RevokeRefreshToken(ctx, "a", now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// отозванный токен повторно не отзывается
+	ok, err = s.RevokeRefreshToken(ctx, "a", now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.RevokeUserRefreshTokens(ctx, "user", now))
+	token, err := s.GetRefreshToken(ctx, "b")
+	require.NoError(t, err)
+	assert.False(t, token.Active(now))
+
+	// истёкшие токены удаляются при сохранении новых
+	later := now.Add(2 * time.Hour)
+	require.NoError(t, s.SaveRefreshToken(ctx, models.RefreshToken{Hash: "c", UserID: "user", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}))
+
+	_, err = s.GetRefreshToken(ctx, "a")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+)
+
+// userRecord the line of the accounts file, unlike models.User it keeps the password hash.
+type userRecord struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Users - structure describing the in-memory storage of the registered accounts.
+//
+// With a file path every created account is appended to the file as a line,
+// so the accounts survive the restart of the file and KV backends.
+type Users struct {
+	filePath string
+	mu       sync.Mutex
+	byID     map[string]models.User
+	byEmail  map[string]string
+}
+
+// NewUsers - constructor a new instance of Users reading the accounts at filePath,
+// the accounts are kept only in memory when filePath is empty.
+func NewUsers(filePath string) (*Users, error) {
+	u := &Users{
+		filePath: filePath,
+		byID:     make(map[string]models.User),
+		byEmail:  make(map[string]string),
+	}
+
+	if len(filePath) == 0 {
+		return u, nil
+	}
+
+	err := readLines(filePath, func(line []byte) error {
+		record := userRecord{}
+		// недописанная при сбое строка пропускается
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil
+		}
+		u.put(models.User(record))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// CreateUser stores the account.
+func (u *Users) CreateUser(_ context.Context, user models.User) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.byID[user.ID]; ok {
+		return repository.ErrConflict
+	}
+	if _, ok := u.byEmail[user.Email]; ok {
+		return repository.ErrConflict
+	}
+
+	if err := u.write(user); err != nil {
+		return err
+	}
+
+	u.put(user)
+
+	return nil
+}
+
+// GetUserByEmail the account by the email.
+func (u *Users) GetUserByEmail(_ context.Context, email string) (*models.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	id, ok := u.byEmail[email]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	user := u.byID[id]
+
+	return &user, nil
+}
+
+// GetUserByID the account by the ID.
+func (u *Users) GetUserByID(_ context.Context, id string) (*models.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, ok := u.byID[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (u *Users) put(user models.User) {
+	u.byID[user.ID] = user
+	u.byEmail[user.Email] = user.ID
+}
+
+// write appends the account to the file.
+func (u *Users) write(user models.User) error {
+	if len(u.filePath) == 0 {
+		return nil
+	}
+
+	return appendLine(u.filePath, userRecord(user))
+}
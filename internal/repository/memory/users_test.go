@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsers(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "users")
+
+	u, err := NewUsers(path)
+	require.NoError(t, err)
+
+	user := models.User{ID: "user", Email: "user@example.com", PasswordHash: "hash", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, u.CreateUser(ctx, user))
+
+	// занятые адрес и идентификатор
+	assert.ErrorIs(t, u.CreateUser(ctx, models.User{ID: "other", Email: user.Email}), repository.ErrConflict)
+	assert.ErrorIs(t, u.CreateUser(ctx, models.User{ID: user.ID, Email: "other@example.com"}), repository.ErrConflict)
+
+	_, err = u.GetUserByID(ctx, "anonymous")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// учётные записи вместе с хешем пароля восстанавливаются из файла
+	restored, err := NewUsers(path)
+	require.NoError(t, err)
+
+	got, err := restored.GetUserByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, user, *got)
+
+	got, err = restored.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, *got)
+}
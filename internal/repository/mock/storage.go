@@ -7,6 +7,7 @@ package mockStore
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Orendev/shortener/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,21 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// ClaimShortLinks mocks base method.
+func (m *MockStorage) ClaimShortLinks(ctx context.Context, fromUserID, toUserID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimShortLinks", ctx, fromUserID, toUserID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimShortLinks indicates an expected call of ClaimShortLinks.
+func (mr *MockStorageMockRecorder) ClaimShortLinks(ctx, fromUserID, toUserID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimShortLinks", reflect.TypeOf((*MockStorage)(nil).ClaimShortLinks), ctx, fromUserID, toUserID)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -49,12 +65,28 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
+// DeleteExpired mocks base method.
+func (m *MockStorage) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockStorageMockRecorder) DeleteExpired(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockStorage)(nil).DeleteExpired), ctx, now)
+}
+
 // DeleteFlagBatch mocks base method.
-func (m *MockStorage) DeleteFlagBatch(ctx context.Context, codes []string, userID string) error {
+func (m *MockStorage) DeleteFlagBatch(ctx context.Context, codes []string, userID string) ([]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteFlagBatch", ctx, codes, userID)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteFlagBatch indicates an expected call of DeleteFlagBatch.
@@ -63,6 +95,36 @@ func (mr *MockStorageMockRecorder) DeleteFlagBatch(ctx, codes, userID interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFlagBatch", reflect.TypeOf((*MockStorage)(nil).DeleteFlagBatch), ctx, codes, userID)
 }
 
+// DisableShortLink mocks base method.
+func (m *MockStorage) DisableShortLink(ctx context.Context, code, reason string, legal bool) (*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableShortLink", ctx, code, reason, legal)
+	ret0, _ := ret[0].(*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableShortLink indicates an expected call of DisableShortLink.
+func (mr *MockStorageMockRecorder) DisableShortLink(ctx, code, reason, legal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableShortLink", reflect.TypeOf((*MockStorage)(nil).DisableShortLink), ctx, code, reason, legal)
+}
+
+// EnableShortLink mocks base method.
+func (m *MockStorage) EnableShortLink(ctx context.Context, code string) (*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableShortLink", ctx, code)
+	ret0, _ := ret[0].(*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableShortLink indicates an expected call of EnableShortLink.
+func (mr *MockStorageMockRecorder) EnableShortLink(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableShortLink", reflect.TypeOf((*MockStorage)(nil).EnableShortLink), ctx, code)
+}
+
 // GetByCode mocks base method.
 func (m *MockStorage) GetByCode(ctx context.Context, code string) (*models.ShortLink, error) {
 	m.ctrl.T.Helper()
@@ -136,6 +198,36 @@ func (mr *MockStorageMockRecorder) Ping(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), ctx)
 }
 
+// RecentShortLinks mocks base method.
+func (m *MockStorage) RecentShortLinks(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecentShortLinks", ctx, query)
+	ret0, _ := ret[0].(*models.ShortLinksPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecentShortLinks indicates an expected call of RecentShortLinks.
+func (mr *MockStorageMockRecorder) RecentShortLinks(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecentShortLinks", reflect.TypeOf((*MockStorage)(nil).RecentShortLinks), ctx, query)
+}
+
+// Restore mocks base method.
+func (m *MockStorage) Restore(ctx context.Context, code, userID string) (*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, code, userID)
+	ret0, _ := ret[0].(*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockStorageMockRecorder) Restore(ctx, code, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockStorage)(nil).Restore), ctx, code, userID)
+}
+
 // Save mocks base method.
 func (m *MockStorage) Save(ctx context.Context, model models.ShortLink) error {
 	m.ctrl.T.Helper()
@@ -151,18 +243,18 @@ func (mr *MockStorageMockRecorder) Save(ctx, model interface{}) *gomock.Call {
 }
 
 // ShortLinksByUserID mocks base method.
-func (m *MockStorage) ShortLinksByUserID(ctx context.Context, userID string, limit int) ([]models.ShortLink, error) {
+func (m *MockStorage) ShortLinksByUserID(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ShortLinksByUserID", ctx, userID, limit)
-	ret0, _ := ret[0].([]models.ShortLink)
+	ret := m.ctrl.Call(m, "ShortLinksByUserID", ctx, query)
+	ret0, _ := ret[0].(*models.ShortLinksPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ShortLinksByUserID indicates an expected call of ShortLinksByUserID.
-func (mr *MockStorageMockRecorder) ShortLinksByUserID(ctx, userID, limit interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) ShortLinksByUserID(ctx, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShortLinksByUserID", reflect.TypeOf((*MockStorage)(nil).ShortLinksByUserID), ctx, userID, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShortLinksByUserID", reflect.TypeOf((*MockStorage)(nil).ShortLinksByUserID), ctx, query)
 }
 
 // UpdateBatch mocks base method.
@@ -179,6 +271,21 @@ func (mr *MockStorageMockRecorder) UpdateBatch(ctx, models interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBatch", reflect.TypeOf((*MockStorage)(nil).UpdateBatch), ctx, models)
 }
 
+// UpdateOriginalURL mocks base method.
+func (m *MockStorage) UpdateOriginalURL(ctx context.Context, code, userID, originalURL string) (*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOriginalURL", ctx, code, userID, originalURL)
+	ret0, _ := ret[0].(*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOriginalURL indicates an expected call of UpdateOriginalURL.
+func (mr *MockStorageMockRecorder) UpdateOriginalURL(ctx, code, userID, originalURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOriginalURL", reflect.TypeOf((*MockStorage)(nil).UpdateOriginalURL), ctx, code, userID, originalURL)
+}
+
 // UrlsStats mocks base method.
 func (m *MockStorage) UrlsStats(ctx context.Context) (int, error) {
 	m.ctrl.T.Helper()
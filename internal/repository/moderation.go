@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// BanStore interface for the storage of the banned users.
+type BanStore interface {
+	// BanUser stores the ban, the ban of the banned user is replaced.
+	BanUser(ctx context.Context, ban models.UserBan) error
+	// UnbanUser returns ErrNotFound when the user is not banned.
+	UnbanUser(ctx context.Context, userID string) error
+	// GetBan returns ErrNotFound when the user is not banned.
+	GetBan(ctx context.Context, userID string) (*models.UserBan, error)
+}
+
+// AuditStore interface for the audit trail of the moderators' actions.
+type AuditStore interface {
+	AppendAudit(ctx context.Context, entry models.AuditEntry) error
+	// AuditEntries the newest entries first.
+	AuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error)
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Analytics - structure describing the Postgres click storage.
+type Analytics struct {
+	pool *pgxpool.Pool
+}
+
+// Analytics returns the click storage sharing the connection pool of the Postgres.
+func (s *Postgres) Analytics() *Analytics {
+	return &Analytics{pool: s.pool}
+}
+
+// SaveClicks let's save the clicks models.Click.
+func (a *Analytics) SaveClicks(ctx context.Context, clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	_, err := a.pool.CopyFrom(ctx,
+		pgx.Identifier{"link_clicks"},
+		[]string{"code", "clicked_at", "referrer", "user_agent", "ip"},
+		pgx.CopyFromSlice(len(clicks), func(i int) ([]any, error) {
+			c := clicks[i]
+			return []any{c.Code, c.ClickedAt, c.Referrer, c.UserAgent, c.IP}, nil
+		}),
+	)
+
+	return err
+}
+
+// ClicksTotal the total number of clicks on the short link.
+func (a *Analytics) ClicksTotal(ctx context.Context, code string) (int, error) {
+	var count int
+
+	err := a.pool.QueryRow(ctx, `SELECT count(*) FROM link_clicks WHERE code = $1`, code).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ClicksSeries the number of clicks on the short link grouped by interval in the range [from, to).
+func (a *Analytics) ClicksSeries(ctx context.Context, code string, interval models.StatsInterval, from, to time.Time) ([]models.ClickBucket, error) {
+	buckets := make([]models.ClickBucket, 0)
+
+	rows, err := a.pool.Query(ctx,
+		`SELECT date_trunc($2, clicked_at AT TIME ZONE 'UTC') AS bucket, count(*)
+			FROM link_clicks
+			WHERE code = $1 AND clicked_at >= $3 AND clicked_at < $4
+			GROUP BY bucket
+			ORDER BY bucket`,
+		code, string(interval), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// обязательно закрываем перед возвратом функции
+	defer rows.Close()
+
+	// пробегаем по всем записям
+	for rows.Next() {
+		var b models.ClickBucket
+		err = rows.Scan(&b.Time, &b.Clicks)
+		if err != nil {
+			return nil, err
+		}
+		// date_trunc возвращает timestamp без часового пояса в UTC
+		b.Time = time.Date(b.Time.Year(), b.Time.Month(), b.Time.Day(), b.Time.Hour(), 0, 0, 0, time.UTC)
+		buckets = append(buckets, b)
+	}
+
+	// проверяем на ошибки
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apiKeyColumns the columns of models.APIKey.
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at`
+
+// APIKeys - structure describing the Postgres storage of the API keys.
+type APIKeys struct {
+	pool *pgxpool.Pool
+}
+
+// APIKeys returns the API key storage sharing the connection pool of the Postgres.
+func (s *Postgres) APIKeys() *APIKeys {
+	return &APIKeys{pool: s.pool}
+}
+
+// SaveAPIKey stores the API key.
+func (a *APIKeys) SaveAPIKey(ctx context.Context, key models.APIKey) error {
+	_, err := a.pool.Exec(ctx,
+		`INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.Scopes, key.CreatedAt)
+
+	return err
+}
+
+// APIKeysByUserID the keys of the user in the order of creation.
+func (a *APIKeys) APIKeysByUserID(ctx context.Context, userID string) ([]models.APIKey, error) {
+	rows, err := a.pool.Query(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.APIKey])
+}
+
+// GetAPIKeyByHash the API key by the hash of the key.
+func (a *APIKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	rows, err := a.pool.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.APIKey])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// RevokeAPIKey revokes the active key of the user.
+func (a *APIKeys) RevokeAPIKey(ctx context.Context, id, userID string, at time.Time) (bool, error) {
+	tag, err := a.pool.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID, at)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// TouchAPIKey remembers the last use of the key.
+func (a *APIKeys) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	_, err := a.pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+
+	return err
+}
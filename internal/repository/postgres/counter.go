@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CodeCounter - structure describing the Postgres sequence of the code IDs.
+type CodeCounter struct {
+	pool *pgxpool.Pool
+}
+
+// CodeCounter returns the code ID sequence sharing the connection pool of the Postgres.
+func (s *Postgres) CodeCounter() *CodeCounter {
+	return &CodeCounter{pool: s.pool}
+}
+
+// NextCodeID the next ID of the sequence, shared by all the instances of the service.
+func (c *CodeCounter) NextCodeID(ctx context.Context) (uint64, error) {
+	var id int64
+	err := c.pool.QueryRow(ctx, `SELECT nextval('short_link_code_seq')`).Scan(&id)
+
+	return uint64(id), err
+}
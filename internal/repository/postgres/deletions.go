@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Deletions - structure describing the Postgres journal of the queued deletions.
+type Deletions struct {
+	pool *pgxpool.Pool
+}
+
+// Deletions returns the deletion journal sharing the connection pool of the Postgres.
+func (s *Postgres) Deletions() *Deletions {
+	return &Deletions{pool: s.pool}
+}
+
+// AppendDeletions writes the queued deletions to the journal.
+func (d *Deletions) AppendDeletions(ctx context.Context, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	_, err := d.pool.CopyFrom(ctx,
+		pgx.Identifier{"delete_queue"},
+		[]string{"job_id", "user_id", "code"},
+		pgx.CopyFromSlice(len(messages), func(i int) ([]any, error) {
+			m := messages[i]
+			return []any{m.JobID, m.UserID, m.Code}, nil
+		}),
+	)
+
+	return err
+}
+
+// AckDeletions removes the deletions flushed to the storage from the journal.
+func (d *Deletions) AckDeletions(ctx context.Context, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	jobIDs := make([]string, 0, len(messages))
+	codes := make([]string, 0, len(messages))
+	for _, m := range messages {
+		jobIDs = append(jobIDs, m.JobID)
+		codes = append(codes, m.Code)
+	}
+
+	_, err := d.pool.Exec(ctx,
+		`DELETE FROM delete_queue q USING unnest($1::text[], $2::text[]) AS a(job_id, code)
+			WHERE q.job_id = a.job_id AND q.code = a.code`,
+		jobIDs, codes)
+
+	return err
+}
+
+// PendingDeletions the deletions that are queued but not flushed yet, in the order of queuing.
+func (d *Deletions) PendingDeletions(ctx context.Context) ([]models.Message, error) {
+	rows, err := d.pool.Query(ctx, `SELECT job_id, user_id, code FROM delete_queue ORDER BY queued_at`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Message])
+}
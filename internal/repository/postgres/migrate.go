@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID the key of the advisory lock held while migrating, so that replicas starting at once wait for each other.
+const migrationLockID int64 = 7320571946
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Errors when migrating the schema.
+var (
+	// ErrSchemaTooNew the database was migrated by a newer version of the service.
+	ErrSchemaTooNew = errors.New("database schema version is newer than the service supports")
+
+	// ErrMigrationIncomplete a migration has no up or down script.
+	ErrMigrationIncomplete = errors.New("migration must have both up and down scripts")
+)
+
+// Migration one versioned step of the schema.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus the migration and whether it was applied to the database.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations in order.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator - constructor a new instance of Migrator with the embedded migrations.
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Migrator returns the schema migrator using the connection pool of the Postgres.
+func (s *Postgres) Migrator() (*Migrator, error) {
+	return NewMigrator(s.pool)
+}
+
+// Latest the version of the newest known migration.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies at most steps pending migrations, all of them if steps is not positive.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = m.checkKnown(versions); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			err = execMigration(ctx, conn, migration.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+
+			logger.Log.Info("migration applied", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back at most steps of the latest applied migrations, one if steps is not positive.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	if steps <= 0 {
+		steps = 1
+	}
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = m.checkKnown(versions); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+
+			err = execMigration(ctx, conn, migration.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+
+			logger.Log.Info("migration reverted", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists the known migrations with the moment each was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := versions[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return m.checkKnown(versions)
+	})
+
+	return statuses, err
+}
+
+// CheckVersion refuses to work with a database migrated by a newer version of the service.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.checkKnown(versions)
+	})
+}
+
+func (m *Migrator) checkKnown(versions map[int64]time.Time) error {
+	latest := m.Latest()
+	for version := range versions {
+		if version > latest {
+			return fmt.Errorf("%w: database %d, service %d", ErrSchemaTooNew, version, latest)
+		}
+	}
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// снимаем блокировку даже если контекст уже отменён
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+		if err != nil {
+			logger.Log.Error("cannot release the migration lock", zap.Int64("lock_id", migrationLockID), zap.Error(err))
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+	    version BIGINT NOT NULL PRIMARY KEY,
+	    name TEXT NOT NULL,
+	    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	    )`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	versions := make(map[int64]time.Time)
+
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+
+	// обязательно закрываем перед возвратом функции
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// execMigration runs the migration script and the schema_migrations bookkeeping in one transaction.
+func execMigration(ctx context.Context, conn *pgxpool.Conn, script, bookkeeping string, args ...any) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		// скрипт может состоять из нескольких команд, поэтому выполняем его простым протоколом
+		if _, err := tx.Exec(ctx, script, pgx.QueryExecModeSimpleProtocol); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, bookkeeping, args...)
+		return err
+	})
+}
+
+// loadMigrations reads the up and down scripts from the directory and orders them by version.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		script, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: %d_%s", ErrMigrationIncomplete, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
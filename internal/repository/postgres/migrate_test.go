@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		wantErr  error
+	}{
+		{
+			name: "ordered by version",
+			fsys: fstest.MapFS{
+				"migrations/0002_second.up.sql":   {Data: []byte("SELECT 2")},
+				"migrations/0002_second.down.sql": {Data: []byte("SELECT -2")},
+				"migrations/0001_first.up.sql":    {Data: []byte("SELECT 1")},
+				"migrations/0001_first.down.sql":  {Data: []byte("SELECT -1")},
+				"migrations/README.md":            {Data: []byte("ignored")},
+			},
+			versions: []int64{1, 2},
+		},
+		{
+			name: "missing down script",
+			fsys: fstest.MapFS{
+				"migrations/0001_first.up.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: ErrMigrationIncomplete,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.fsys, "migrations")
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "loadMigrations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			versions := make([]int64, 0, len(migrations))
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			assert.Equal(t, tt.versions, versions)
+		})
+	}
+}
+
+func TestNewMigrator_embedded(t *testing.T) {
+	m, err := NewMigrator(nil)
+	require.NoError(t, err)
+
+	// версии встроенных миграций идут подряд, начиная с 1
+	for i, migration := range m.migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migration %s", migration.Name)
+	}
+	assert.Equal(t, int64(len(m.migrations)), m.Latest())
+}
+
+func TestMigrator_checkKnown(t *testing.T) {
+	m, err := NewMigrator(nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, m.checkKnown(map[int64]time.Time{1: time.Now()}))
+
+	err = m.checkKnown(map[int64]time.Time{m.Latest() + 1: time.Now()})
+	assert.True(t, errors.Is(err, ErrSchemaTooNew))
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS short_links (
+    id UUID NOT NULL primary key,
+    user_id UUID NOT NULL,
+    code VARCHAR(255) NOT NULL UNIQUE,
+    short_url TEXT NOT NULL UNIQUE,
+    original_url TEXT NOT NULL UNIQUE,
+    is_deleted BOOL DEFAULT false
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS short_links_expires_at_idx;
+
+ALTER TABLE short_links DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,3 @@
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS short_links_expires_at_idx ON short_links (expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS link_clicks (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(255) NOT NULL,
+    clicked_at TIMESTAMPTZ NOT NULL,
+    referrer TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS link_clicks_code_clicked_at_idx ON link_clicks (code, clicked_at);
@@ -0,0 +1,3 @@
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS short_links_user_id_created_at_idx ON short_links (user_id, created_at, code);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS delete_queue (
+    job_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    code VARCHAR(255) NOT NULL,
+    queued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (job_id, code)
+);
@@ -0,0 +1 @@
+DROP SEQUENCE IF EXISTS short_link_code_seq;
@@ -0,0 +1 @@
+CREATE SEQUENCE IF NOT EXISTS short_link_code_seq AS BIGINT MINVALUE 0 START WITH 0;
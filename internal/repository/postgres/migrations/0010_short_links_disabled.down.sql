@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS short_links_created_at_idx;
+
+ALTER TABLE short_links DROP COLUMN IF EXISTS disabled_legal;
+ALTER TABLE short_links DROP COLUMN IF EXISTS disabled_reason;
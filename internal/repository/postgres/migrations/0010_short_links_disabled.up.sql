@@ -0,0 +1,4 @@
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS disabled_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS disabled_legal BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS short_links_created_at_idx ON short_links (created_at, code);
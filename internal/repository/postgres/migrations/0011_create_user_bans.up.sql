@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS user_bans (
+    user_id VARCHAR(255) PRIMARY KEY,
+    reason TEXT NOT NULL,
+    banned_by VARCHAR(255) NOT NULL,
+    banned_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
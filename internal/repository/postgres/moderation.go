@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// banColumns the columns of models.UserBan.
+const banColumns = `user_id, reason, banned_by, banned_at`
+
+// auditColumns the columns of models.AuditEntry.
+const auditColumns = `id, admin_id, action, target, reason, created_at`
+
+// Bans - structure describing the Postgres storage of the banned users.
+type Bans struct {
+	pool *pgxpool.Pool
+}
+
+// Bans returns the ban storage sharing the connection pool of the Postgres.
+func (s *Postgres) Bans() *Bans {
+	return &Bans{pool: s.pool}
+}
+
+// BanUser stores the ban, the previous ban of the user is replaced.
+func (b *Bans) BanUser(ctx context.Context, ban models.UserBan) error {
+	_, err := b.pool.Exec(ctx,
+		`INSERT INTO user_bans (`+banColumns+`) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET reason = EXCLUDED.reason, banned_by = EXCLUDED.banned_by, banned_at = EXCLUDED.banned_at`,
+		ban.UserID, ban.Reason, ban.BannedBy, ban.BannedAt)
+
+	return err
+}
+
+// UnbanUser lifts the ban of the user.
+func (b *Bans) UnbanUser(ctx context.Context, userID string) error {
+	tag, err := b.pool.Exec(ctx, `DELETE FROM user_bans WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetBan the ban of the user.
+func (b *Bans) GetBan(ctx context.Context, userID string) (*models.UserBan, error) {
+	rows, err := b.pool.Query(ctx, `SELECT `+banColumns+` FROM user_bans WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ban, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.UserBan])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+// Audit - structure describing the Postgres audit trail of the moderators' actions.
+type Audit struct {
+	pool *pgxpool.Pool
+}
+
+// Audit returns the audit trail sharing the connection pool of the Postgres.
+func (s *Postgres) Audit() *Audit {
+	return &Audit{pool: s.pool}
+}
+
+// AppendAudit stores the entry.
+func (a *Audit) AppendAudit(ctx context.Context, entry models.AuditEntry) error {
+	_, err := a.pool.Exec(ctx,
+		`INSERT INTO audit_log (`+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		entry.ID, entry.AdminID, entry.Action, entry.Target, entry.Reason, entry.CreatedAt)
+
+	return err
+}
+
+// AuditEntries the newest entries first.
+func (a *Audit) AuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	rows, err := a.pool.Query(ctx,
+		`SELECT `+auditColumns+` FROM audit_log ORDER BY created_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.AuditEntry])
+}
@@ -2,129 +2,163 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
-	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/config"
 	"github.com/Orendev/shortener/internal/models"
 	"github.com/Orendev/shortener/internal/repository"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"go.uber.org/zap"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// codeUniqueConstraint the name of the unique constraint on the short_links.code column.
+const codeUniqueConstraint = "short_links_code_key"
+
+// shortLinkColumns the columns of short_links in the order they are scanned by scanShortLink.
+const shortLinkColumns = `id, user_id, code, short_url, original_url, is_deleted, expires_at, created_at, disabled_reason, disabled_legal`
+
+// shortLinksByUserIDQuery the keyset page of the user's short links, formatted with the comparison and the order.
+const shortLinksByUserIDQuery = `SELECT ` + shortLinkColumns + ` FROM short_links
+	WHERE user_id = $1
+	  AND ($2::boolean OR (created_at, code) %s ($3::timestamptz, $4::text))
+	  AND original_url ILIKE $5
+	ORDER BY created_at %[2]s, code %[2]s
+	LIMIT $6`
+
+// recentShortLinksQuery the keyset page of the short links of all users, formatted like shortLinksByUserIDQuery.
+const recentShortLinksQuery = `SELECT ` + shortLinkColumns + ` FROM short_links
+	WHERE ($1::boolean OR (created_at, code) %s ($2::timestamptz, $3::text))
+	  AND original_url ILIKE $4
+	ORDER BY created_at %[2]s, code %[2]s
+	LIMIT $5`
+
+// likeEscaper escapes the wildcards of the LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 // Postgres - structure describing the Postgres.
 type Postgres struct {
-	db *sql.DB
+	pool *pgxpool.Pool
 }
 
 // NewPostgres - constructor a new instance of Postgres.
-func NewPostgres(dsn string) (*Postgres, error) {
+func NewPostgres(ctx context.Context, cfg config.Database) (*Postgres, error) {
+
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseDSN)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := sql.Open("pgx", dsn)
+	// подготовленные выражения кешируются на соединении и переиспользуются между запросами
+	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
 
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Postgres{
-		db: db,
+		pool: pool,
 	}, nil
 }
 
 // GetByCode we get a model models.ShortLink of a short link by code.
 func (s *Postgres) GetByCode(ctx context.Context, code string) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`SELECT `+shortLinkColumns+` FROM short_links WHERE code = $1 LIMIT 1`, code)
 
-	model := models.ShortLink{}
-
-	// делаем запрос
-	sqlStatement := `SELECT id, user_id, code, short_url, original_url, is_deleted FROM short_links WHERE code = $1 LIMIT 1`
-	row := s.db.QueryRowContext(ctx,
-		sqlStatement, code)
-
-	// разбираем результат
-	err := row.Scan(&model.UUID, &model.UserID, &model.Code, &model.ShortURL, &model.OriginalURL, &model.DeletedFlag)
-	if err != nil {
-		return nil, err
-	}
-
-	return &model, nil
+	return scanShortLink(row)
 }
 
 // GetByID we get a model models.ShortLink of a short link by id.
 func (s *Postgres) GetByID(ctx context.Context, id string) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`SELECT `+shortLinkColumns+` FROM short_links WHERE id = $1 LIMIT 1`, id)
 
-	model := models.ShortLink{}
-
-	stmt, err := s.db.PrepareContext(ctx,
-		`SELECT id, user_id, code, short_url, original_url, is_deleted  FROM short_links WHERE id = $1 LIMIT 1`)
+	return scanShortLink(row)
+}
 
+// ShortLinksByUserID we will get a page of the user's short link models.ShortLink.
+func (s *Postgres) ShortLinksByUserID(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	after, err := query.After()
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
-
-	// делаем запрос
-	row := stmt.QueryRowContext(ctx, id)
+	sqlStatement := fmt.Sprintf(shortLinksByUserIDQuery, ">", "ASC")
+	if query.Sort == models.SortCreatedAtDesc {
+		sqlStatement = fmt.Sprintf(shortLinksByUserIDQuery, "<", "DESC")
+	}
 
-	// разбираем результат
-	err = row.Scan(&model.UUID, &model.UserID, &model.Code, &model.ShortURL, &model.OriginalURL, &model.DeletedFlag)
-	if err != nil {
-		return nil, err
+	cursor := models.PageCursor{}
+	if after != nil {
+		cursor = *after
 	}
 
-	return &model, nil
+	// делаем запрос, на одну ссылку больше лимита, чтобы узнать о следующей странице
+	return s.queryPage(ctx, query, sqlStatement,
+		query.UserID, after == nil, cursor.CreatedAt, cursor.Code, "%"+likeEscaper.Replace(query.Search)+"%", query.Limit+1)
 }
 
-// ShortLinksByUserID we will get a list of the user's short link models.ShortLink.
-func (s *Postgres) ShortLinksByUserID(ctx context.Context, userID string, limit int) ([]models.ShortLink, error) {
-	shortLinks := make([]models.ShortLink, 0, limit)
-
-	stmt, err := s.db.PrepareContext(ctx,
-		`SELECT id, user_id, code, short_url, original_url, is_deleted  FROM short_links WHERE user_id = $1 LIMIT $2`)
+// RecentShortLinks the page of the links of all users, or of query.UserID when it is set.
+func (s *Postgres) RecentShortLinks(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error) {
+	if len(query.UserID) > 0 {
+		return s.ShortLinksByUserID(ctx, query)
+	}
 
+	after, err := query.After()
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+	sqlStatement := fmt.Sprintf(recentShortLinksQuery, ">", "ASC")
+	if query.Sort == models.SortCreatedAtDesc {
+		sqlStatement = fmt.Sprintf(recentShortLinksQuery, "<", "DESC")
+	}
 
-	// делаем запрос
-	rows, err := stmt.QueryContext(ctx, userID, limit)
+	cursor := models.PageCursor{}
+	if after != nil {
+		cursor = *after
+	}
+
+	return s.queryPage(ctx, query, sqlStatement,
+		after == nil, cursor.CreatedAt, cursor.Code, "%"+likeEscaper.Replace(query.Search)+"%", query.Limit+1)
+}
+
+// queryPage reads the page of the short links selected by the keyset query.
+func (s *Postgres) queryPage(ctx context.Context, query models.ShortLinksQuery, sqlStatement string, args ...any) (*models.ShortLinksPage, error) {
+	rows, err := s.pool.Query(ctx, sqlStatement, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	// обязательно закрываем перед возвратом функции
-	defer func() {
-		err = rows.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+	defer rows.Close()
+
+	shortLinks := make([]models.ShortLink, 0, query.Limit+1)
 
 	// пробегаем по всем записям
 	for rows.Next() {
-		var m models.ShortLink
-		err = rows.Scan(&m.UUID, &m.UserID, &m.Code, &m.ShortURL, &m.OriginalURL, &m.DeletedFlag)
+		m, err := scanShortLink(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		shortLinks = append(shortLinks, m)
+		shortLinks = append(shortLinks, *m)
 	}
 
 	// проверяем на ошибки
@@ -133,278 +167,228 @@ func (s *Postgres) ShortLinksByUserID(ctx context.Context, userID string, limit
 		return nil, err
 	}
 
-	return shortLinks, nil
+	return query.NewPage(shortLinks), nil
 }
 
 // UrlsStats number of abbreviated URLs in the service.
 func (s *Postgres) UrlsStats(ctx context.Context) (int, error) {
+	var count int
 
-	stmt, err := s.db.PrepareContext(ctx,
-		`SELECT count(*)  FROM short_links`)
-
+	// разбираем результат
+	err := s.pool.QueryRow(ctx, `SELECT count(*) FROM short_links`).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+	return count, nil
+}
 
-	row := stmt.QueryRowContext(ctx)
+// UsersStats number of users in the service.
+func (s *Postgres) UsersStats(ctx context.Context) (int, error) {
 	var count int
+
 	// разбираем результат
-	err = row.Scan(&count)
+	err := s.pool.QueryRow(ctx, `SELECT count(DISTINCT user_id) FROM short_links`).Scan(&count)
 	if err != nil {
-		return count, err
+		return 0, err
 	}
 
 	return count, nil
 }
 
-// UsersStats number of users in the service.
-func (s *Postgres) UsersStats(ctx context.Context) (int, error) {
+// GetByOriginalURL we will get the model with a short link models.ShortLink to the original URL.
+func (s *Postgres) GetByOriginalURL(ctx context.Context, originalURL string) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`SELECT `+shortLinkColumns+` FROM short_links WHERE original_url = $1 LIMIT 1`, originalURL)
 
-	stmt, err := s.db.PrepareContext(ctx,
-		`SELECT user_id, count(distinct('user_id'))  FROM short_links  GROUP BY user_id`)
+	return scanShortLink(row)
+}
 
-	if err != nil {
-		return 0, err
-	}
+// Save let's save the model of the short link models.ShortLink.
+func (s *Postgres) Save(ctx context.Context, model models.ShortLink) error {
+	sqlStatement := `
+	INSERT INTO short_links (id, user_id, code, short_url, original_url, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+	_, err := s.pool.Exec(
+		ctx,
+		sqlStatement, model.UUID, model.UserID, model.Code, model.ShortURL, model.OriginalURL, model.ExpiresAt, createdAt(model, time.Now()),
+	)
 
-	// делаем запрос
-	rows, err := stmt.QueryContext(ctx)
-	if err != nil {
-		return 0, err
+	return conflictError(err)
+}
+
+// InsertBatch group insertion of short link models []models.ShortLink.
+func (s *Postgres) InsertBatch(ctx context.Context, shortLinks []models.ShortLink) error {
+	if len(shortLinks) == 0 {
+		return nil
 	}
 
-	// обязательно закрываем перед возвратом функции
-	defer func() {
-		err = rows.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+	now := time.Now()
 
-	var count int
-	var userID string
-	sum := 0
+	// COPY вставляет всю пачку одной командой: либо все строки, либо ни одной
+	_, err := s.pool.CopyFrom(ctx,
+		pgx.Identifier{"short_links"},
+		[]string{"id", "user_id", "code", "short_url", "original_url", "expires_at", "created_at"},
+		pgx.CopyFromSlice(len(shortLinks), func(i int) ([]any, error) {
+			sl := shortLinks[i]
+			return []any{sl.UUID, sl.UserID, sl.Code, sl.ShortURL, sl.OriginalURL, sl.ExpiresAt, createdAt(sl, now)}, nil
+		}),
+	)
 
-	// пробегаем по всем записям
-	for rows.Next() {
-		err = rows.Scan(&userID, &count)
-		if err != nil {
-			return count, err
-		}
-		sum += count
+	return conflictError(err)
+}
+
+// UpdateBatch group update of short link models []models.ShortLink.
+func (s *Postgres) UpdateBatch(ctx context.Context, shortLinks []models.ShortLink) error {
+	if len(shortLinks) == 0 {
+		return nil
 	}
 
-	// проверяем на ошибки
-	err = rows.Err()
-	if err != nil {
-		return sum, err
+	batch := &pgx.Batch{}
+	for _, sl := range shortLinks {
+		batch.Queue(`UPDATE short_links SET original_url = $1, is_deleted=$2, expires_at=$3 WHERE id = $4`,
+			sl.OriginalURL, sl.DeletedFlag, sl.ExpiresAt, sl.UUID)
 	}
 
-	return sum, nil
+	// пачка выполняется в одной транзакции
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		return conflictError(tx.SendBatch(ctx, batch).Close())
+	})
 }
 
-// GetByOriginalURL we will get the model with a short link models.ShortLink to the original URL.
-func (s *Postgres) GetByOriginalURL(ctx context.Context, originalURL string) (*models.ShortLink, error) {
-
-	model := models.ShortLink{}
-
-	stmt, err := s.db.PrepareContext(ctx,
-		`SELECT id, user_id, code, short_url, original_url, is_deleted  FROM short_links WHERE original_url = $1 LIMIT 1`)
-
+// DeleteFlagBatch group delete of the user's short links, returns the codes that were deleted.
+func (s *Postgres) DeleteFlagBatch(ctx context.Context, codes []string, userID string) ([]string, error) {
+	rows, err := s.pool.Query(ctx,
+		`UPDATE short_links SET is_deleted=true WHERE code = ANY($1) AND user_id = $2 RETURNING code`,
+		codes, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
-
-	// делаем запрос
-	row := stmt.QueryRowContext(ctx, originalURL)
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
 
-	// разбираем результат
-	err = row.Scan(&model.UUID, &model.UserID, &model.Code, &model.ShortURL, &model.OriginalURL, &model.DeletedFlag)
+// ClaimShortLinks moves the links of the user fromUserID to toUserID, returns the codes of the moved links.
+func (s *Postgres) ClaimShortLinks(ctx context.Context, fromUserID, toUserID string) ([]string, error) {
+	rows, err := s.pool.Query(ctx,
+		`UPDATE short_links SET user_id = $2 WHERE user_id = $1 RETURNING code`,
+		fromUserID, toUserID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &model, nil
+	return pgx.CollectRows(rows, pgx.RowTo[string])
 }
 
-// Save let's save the model of the short link models.ShortLink.
-func (s *Postgres) Save(ctx context.Context, model models.ShortLink) error {
-	sqlStatement := `
-	INSERT INTO short_links (id, user_id, code, short_url, original_url)
-	VALUES ($1, $2, $3, $4, $5)
-	`
+// UpdateOriginalURL changes the original URL of the user's short link.
+func (s *Postgres) UpdateOriginalURL(ctx context.Context, code, userID, originalURL string) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`UPDATE short_links SET original_url = $1 WHERE code = $2 AND user_id = $3 RETURNING `+shortLinkColumns,
+		originalURL, code, userID)
 
-	_, err := s.db.ExecContext(
-		ctx,
-		sqlStatement, model.UUID, model.UserID, model.Code, model.ShortURL, model.OriginalURL,
-	)
+	shortLink, err := scanShortLink(row)
 
-	if err != nil {
-		var pgErr *pgconn.PgError
+	return shortLink, conflictError(err)
+}
 
-		if errors.As(err, &pgErr) && pgerrcode.UniqueViolation == pgErr.Code {
-			err = repository.ErrConflict
-		}
-		return err
-	}
+// Restore cancels the soft delete of the user's short link.
+func (s *Postgres) Restore(ctx context.Context, code, userID string) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`UPDATE short_links SET is_deleted = false WHERE code = $1 AND user_id = $2 RETURNING `+shortLinkColumns,
+		code, userID)
 
-	return nil
+	return scanShortLink(row)
 }
 
-// InsertBatch group insertion of short link models []models.ShortLink.
-func (s *Postgres) InsertBatch(ctx context.Context, shortLinks []models.ShortLink) error {
-	// начинаем транзакцию
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
+// DisableShortLink disables the link with the reason.
+func (s *Postgres) DisableShortLink(ctx context.Context, code, reason string, legal bool) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`UPDATE short_links SET disabled_reason = $1, disabled_legal = $2 WHERE code = $3 RETURNING `+shortLinkColumns,
+		reason, legal, code)
 
-	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO short_links (id, user_id, code, short_url, original_url)
-				VALUES($1, $2, $3, $4, $5)`)
-	if err != nil {
-		return err
-	}
+	return scanShortLink(row)
+}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+// EnableShortLink cancels the disabling of the link.
+func (s *Postgres) EnableShortLink(ctx context.Context, code string) (*models.ShortLink, error) {
+	row := s.pool.QueryRow(ctx,
+		`UPDATE short_links SET disabled_reason = '', disabled_legal = false WHERE code = $1 RETURNING `+shortLinkColumns,
+		code)
 
-	for _, sl := range shortLinks {
-		_, err = stmt.ExecContext(ctx, sl.UUID, sl.UserID, sl.Code, sl.ShortURL, sl.OriginalURL)
-		if err != nil {
-			// если ошибка, то откатываем изменения
-			errRollback := tx.Rollback()
-			if errRollback != nil {
-				return errRollback
-			}
-			return err
-		}
-	}
-	return tx.Commit()
+	return scanShortLink(row)
 }
 
-// UpdateBatch group update of short link models []models.ShortLink.
-func (s *Postgres) UpdateBatch(ctx context.Context, shortLinks []models.ShortLink) error {
-	// начинаем транзакцию
-	tx, err := s.db.Begin()
+// DeleteExpired soft-deletes the short links whose lifetime is over at the moment now.
+func (s *Postgres) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE short_links SET is_deleted=true WHERE is_deleted=false AND expires_at IS NOT NULL AND expires_at <= $1`, now)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	stmt, err := tx.PrepareContext(ctx,
-		`UPDATE short_links SET original_url = $1, is_deleted=$2 WHERE id = $3`)
-
-	if err != nil {
-		return err
-	}
+	return int(tag.RowsAffected()), nil
+}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+// Close closing the service.
+func (s *Postgres) Close() error {
+	s.pool.Close()
+	return nil
+}
 
-	for _, sl := range shortLinks {
-		_, err = stmt.ExecContext(ctx, sl.OriginalURL, sl.DeletedFlag, sl.UUID)
-		if err != nil {
-			// если ошибка, то откатываем изменения
-			errRollback := tx.Rollback()
-			if errRollback != nil {
-				return errRollback
-			}
-			return err
-		}
-	}
-	return tx.Commit()
+// Ping service check.
+func (s *Postgres) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
 }
 
-// DeleteFlagBatch group delete of short link models []models.ShortLink.
-func (s *Postgres) DeleteFlagBatch(ctx context.Context, codes []string, _ string) error {
-	// начинаем транзакцию
-	tx, err := s.db.Begin()
+// Bootstrap prepares the database for operation by applying the pending schema migrations.
+func (s *Postgres) Bootstrap(ctx context.Context) error {
+	migrator, err := s.Migrator()
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.PrepareContext(ctx,
-		`UPDATE short_links SET is_deleted=true WHERE code = ANY($1)`)
+	_, err = migrator.Up(ctx, 0)
 
-	if err != nil {
-		return err
-	}
+	return err
+}
 
-	defer func() {
-		err = stmt.Close()
-		if err != nil {
-			logger.Log.Error("error", zap.Error(err))
-		}
-	}()
+// scanShortLink reads the shortLinkColumns of a row into the model.
+func scanShortLink(row pgx.Row) (*models.ShortLink, error) {
+	model := models.ShortLink{}
 
-	_, err = stmt.ExecContext(ctx, "{"+strings.Join(codes, ",")+"}")
+	// разбираем результат
+	err := row.Scan(&model.UUID, &model.UserID, &model.Code, &model.ShortURL, &model.OriginalURL, &model.DeletedFlag, &model.ExpiresAt, &model.CreatedAt,
+		&model.DisabledReason, &model.DisabledLegal)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
 	if err != nil {
-		// если ошибка, то откатываем изменения
-		errRollback := tx.Rollback()
-		if errRollback != nil {
-			return errRollback
-		}
-		return err
+		return nil, err
 	}
-	return tx.Commit()
-}
 
-// Close closing the service.
-func (s *Postgres) Close() error {
-	return s.db.Close()
+	return &model, nil
 }
 
-// Ping service check.
-func (s *Postgres) Ping(ctx context.Context) error {
-	return s.db.PingContext(ctx)
+// createdAt the creation time of the new link, now if it is not set.
+func createdAt(model models.ShortLink, now time.Time) time.Time {
+	if model.CreatedAt.IsZero() {
+		return now
+	}
+	return model.CreatedAt
 }
 
-// Bootstrap prepares the database for operation by creating the necessary tables and indexes.
-func (s *Postgres) Bootstrap(ctx context.Context) error {
+// conflictError translates the unique violation into repository.ErrConflict or repository.ErrCodeConflict.
+func conflictError(err error) error {
+	var pgErr *pgconn.PgError
 
-	sqlStatement := `
-	CREATE TABLE IF NOT EXISTS short_links (
-	    id UUID NOT NULL primary key, 
-	    user_id UUID NOT NULL,
-	    code VARCHAR(255) NOT NULL UNIQUE,
-	    short_url TEXT NOT NULL UNIQUE, 
-	    original_url TEXT NOT NULL UNIQUE,
-	    is_deleted BOOL DEFAULT false
-	    )`
-
-	_, err := s.db.ExecContext(
-		ctx,
-		sqlStatement,
-	)
+	if errors.As(err, &pgErr) && pgerrcode.UniqueViolation == pgErr.Code {
+		if pgErr.ConstraintName == codeUniqueConstraint {
+			return repository.ErrCodeConflict
+		}
+		return repository.ErrConflict
+	}
 
 	return err
 }
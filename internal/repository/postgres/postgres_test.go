@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_conflictError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "original url conflict",
+			err:  &pgconn.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "short_links_original_url_key"},
+			want: repository.ErrConflict,
+		},
+		{
+			name: "code conflict",
+			err:  &pgconn.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: codeUniqueConstraint},
+			want: repository.ErrCodeConflict,
+		},
+		{
+			name: "other error",
+			err:  other,
+			want: other,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, conflictError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Sessions - structure describing the Postgres storage of the refresh tokens.
+type Sessions struct {
+	pool *pgxpool.Pool
+}
+
+// Sessions returns the refresh token storage sharing the connection pool of the Postgres.
+func (s *Postgres) Sessions() *Sessions {
+	return &Sessions{pool: s.pool}
+}
+
+// SaveRefreshToken stores the refresh token, dropping the expired ones.
+func (s *Sessions) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= $1`, token.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO refresh_tokens (token_hash, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)`,
+		token.Hash, token.UserID, token.CreatedAt, token.ExpiresAt)
+
+	return err
+}
+
+// GetRefreshToken the refresh token by the hash.
+func (s *Sessions) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT token_hash, user_id, created_at, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1`,
+		hash)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RefreshToken])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken revokes the refresh token unless it is already revoked.
+func (s *Sessions) RevokeRefreshToken(ctx context.Context, hash string, at time.Time) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $2 WHERE token_hash = $1 AND revoked_at IS NULL`,
+		hash, at)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// RevokeUserRefreshTokens revokes all the refresh tokens of the user.
+func (s *Sessions) RevokeUserRefreshTokens(ctx context.Context, userID string, at time.Time) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID, at)
+
+	return err
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// userColumns the columns of models.User.
+const userColumns = `id, email, password_hash, created_at`
+
+// Users - structure describing the Postgres storage of the registered accounts.
+type Users struct {
+	pool *pgxpool.Pool
+}
+
+// Users returns the account storage sharing the connection pool of the Postgres.
+func (s *Postgres) Users() *Users {
+	return &Users{pool: s.pool}
+}
+
+// CreateUser stores the account.
+func (u *Users) CreateUser(ctx context.Context, user models.User) error {
+	_, err := u.pool.Exec(ctx,
+		`INSERT INTO users (`+userColumns+`) VALUES ($1, $2, $3, $4)`,
+		user.ID, user.Email, user.PasswordHash, user.CreatedAt)
+
+	return conflictError(err)
+}
+
+// GetUserByEmail the account by the email.
+func (u *Users) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return u.get(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email)
+}
+
+// GetUserByID the account by the ID.
+func (u *Users) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return u.get(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+}
+
+func (u *Users) get(ctx context.Context, query string, arg string) (*models.User, error) {
+	rows, err := u.pool.Query(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.User])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// SessionStorage interface for the storage of the refresh tokens.
+type SessionStorage interface {
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	// GetRefreshToken returns ErrNotFound for the unknown hash.
+	GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RevokeRefreshToken reports false when the token was already revoked or is unknown.
+	RevokeRefreshToken(ctx context.Context, hash string, at time.Time) (bool, error)
+	RevokeUserRefreshTokens(ctx context.Context, userID string, at time.Time) error
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Orendev/shortener/internal/models"
 )
@@ -10,6 +11,9 @@ import (
 // ErrConflict indicates a data conflict in the storage.
 var ErrConflict = errors.New("data conflict")
 
+// ErrCodeConflict the short link code (alias) is already taken.
+var ErrCodeConflict = errors.New("code already taken")
+
 // ErrNotFound object not found.
 var ErrNotFound = errors.New("not found")
 
@@ -17,14 +21,25 @@ var ErrNotFound = errors.New("not found")
 type Storage interface {
 	GetByCode(ctx context.Context, code string) (*models.ShortLink, error)
 	GetByID(ctx context.Context, id string) (*models.ShortLink, error)
-	ShortLinksByUserID(ctx context.Context, userID string, limit int) ([]models.ShortLink, error)
+	ShortLinksByUserID(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error)
 	GetByOriginalURL(ctx context.Context, originalURL string) (*models.ShortLink, error)
 	UsersStats(ctx context.Context) (int, error)
 	UrlsStats(ctx context.Context) (int, error)
 	Save(ctx context.Context, model models.ShortLink) error
 	InsertBatch(ctx context.Context, models []models.ShortLink) error
 	UpdateBatch(ctx context.Context, models []models.ShortLink) error
-	DeleteFlagBatch(ctx context.Context, codes []string, userID string) error
+	DeleteFlagBatch(ctx context.Context, codes []string, userID string) ([]string, error)
+	UpdateOriginalURL(ctx context.Context, code, userID, originalURL string) (*models.ShortLink, error)
+	Restore(ctx context.Context, code, userID string) (*models.ShortLink, error)
+	// ClaimShortLinks moves the links of the user fromUserID to toUserID, returns the codes of the moved links.
+	ClaimShortLinks(ctx context.Context, fromUserID, toUserID string) ([]string, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+	// RecentShortLinks the page of the links of all users, or of query.UserID when it is set.
+	RecentShortLinks(ctx context.Context, query models.ShortLinksQuery) (*models.ShortLinksPage, error)
+	// DisableShortLink disables the link with the reason, returns ErrNotFound for the unknown code.
+	DisableShortLink(ctx context.Context, code, reason string, legal bool) (*models.ShortLink, error)
+	// EnableShortLink cancels the disabling of the link, returns ErrNotFound for the unknown code.
+	EnableShortLink(ctx context.Context, code string) (*models.ShortLink, error)
 	Ping(ctx context.Context) error
 	Close() error
 }
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Orendev/shortener/internal/models"
+)
+
+// UserStore interface for the storage of the registered accounts.
+type UserStore interface {
+	// CreateUser returns ErrConflict when the email or the ID is taken.
+	CreateUser(ctx context.Context, user models.User) error
+	// GetUserByEmail returns ErrNotFound for the unknown email.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetUserByID returns ErrNotFound for the anonymous user.
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+}
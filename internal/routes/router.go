@@ -1,37 +1,81 @@
 package routes
 
 import (
+	"github.com/Orendev/shortener/internal/auth"
 	"github.com/Orendev/shortener/internal/handlers/http"
 	middlewares "github.com/Orendev/shortener/internal/middlewares/http"
+	"github.com/Orendev/shortener/internal/models"
+	"github.com/Orendev/shortener/internal/moderation"
 	"github.com/Orendev/shortener/internal/repository"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 // Router api handlers
-func Router(repo repository.Storage, baseURL, trustedSubnet string) *chi.Mux {
+func Router(repo repository.Storage, baseURL, trustedSubnet, trustedProxy string, keys *auth.APIKeys, moderator *moderation.Moderator, opts ...http.Option) (*chi.Mux, error) {
 
-	h := http.NewHandler(repo, baseURL, trustedSubnet)
+	h, err := http.NewHandler(repo, baseURL, append(opts, http.WithAPIKeys(keys), http.WithModerator(moderator))...)
+	if err != nil {
+		return nil, err
+	}
 	router := chi.NewRouter()
 	router.Use(middlewares.Logger)
 	router.Use(middlewares.Gzip)
-	router.Use(middlewares.Auth)
+	router.Use(middlewares.TrustedSubnet(trustedSubnet, trustedProxy))
 
 	router.Mount("/debug", middleware.Profiler())
+	router.Get("/.well-known/jwks.json", h.GetJWKS)
 
-	router.Route("/api", func(r chi.Router) {
-		r.Get("/user/urls", h.GetAPIUserUrls)
-		r.Get("/internal/stats", h.GetAPIStats)
-		r.Post("/shorten", h.PostAPIShorten)
-		r.Post("/shorten/batch", h.PostAPIShortenBatch)
-		r.Delete("/user/urls", h.DeleteAPIUserUrls)
-	})
+	// сессия продлевается и закрывается по токену обновления, токен доступа к этому времени мог истечь
+	router.Post("/api/auth/refresh", h.PostAPIAuthRefresh)
+	router.Post("/api/auth/logout", h.PostAPIAuthLogout)
+
+	router.Group(func(router chi.Router) {
+		router.Use(middlewares.APIKey(keys))
+		router.Use(middlewares.Auth)
+
+		read := middlewares.RequireScope(models.ScopeRead)
+		create := middlewares.RequireScope(models.ScopeCreate)
+		remove := middlewares.RequireScope(models.ScopeDelete)
+		stats := middlewares.RequireScope(models.ScopeStats)
+
+		router.Route("/api", func(r chi.Router) {
+			// ключ API не управляет ключами и не открывает сессию своего пользователя
+			r.With(middlewares.RequireSession).Post("/auth/register", h.PostAPIAuthRegister)
+			r.With(middlewares.RequireSession).Post("/auth/login", h.PostAPIAuthLogin)
+			r.With(middlewares.RequireSession).Post("/user/keys", h.PostAPIUserKeys)
+			r.With(middlewares.RequireSession).Get("/user/keys", h.GetAPIUserKeys)
+			r.With(middlewares.RequireSession).Delete("/user/keys/{id}", h.DeleteAPIUserKey)
+
+			r.With(read).Get("/user/urls", h.GetAPIUserUrls)
+			r.With(read).Get("/user/urls/{code}", h.GetAPIUserURL)
+			r.With(create).Patch("/user/urls/{code}", h.PatchAPIUserURL)
+			r.With(create).Post("/user/urls/{code}/restore", h.PostAPIUserURLRestore)
+			r.With(stats).Get("/user/urls/{code}/stats", h.GetAPIUserURLStats)
+			r.With(stats).Get("/internal/stats", h.GetAPIStats)
+			r.With(create).Post("/shorten", h.PostAPIShorten)
+			r.With(create).Post("/shorten/batch", h.PostAPIShortenBatch)
+			r.With(remove).Delete("/user/urls", h.DeleteAPIUserUrls)
+			r.With(read).Get("/user/urls/delete-jobs/{id}", h.GetAPIDeleteJob)
+
+			// доступ из доверенной подсети проверяет TrustedSubnet, роль администратора — RequireAdmin
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(middlewares.RequireAdmin(moderator.IsAdmin))
+				r.Get("/links", h.GetAPIAdminLinks)
+				r.Post("/links/{code}/disable", h.PostAPIAdminLinkDisable)
+				r.Post("/links/{code}/enable", h.PostAPIAdminLinkEnable)
+				r.Post("/users/{id}/ban", h.PostAPIAdminUserBan)
+				r.Delete("/users/{id}/ban", h.DeleteAPIAdminUserBan)
+				r.Get("/audit", h.GetAPIAdminAudit)
+			})
+		})
 
-	router.Route("/", func(r chi.Router) {
-		r.Get("/{id}", h.GetShorten)
-		r.Get("/ping", h.GetPing)
-		r.Post("/", h.PostShorten)
+		router.Route("/", func(r chi.Router) {
+			r.Get("/{id}", h.GetShorten)
+			r.Get("/ping", h.GetPing)
+			r.With(create).Post("/", h.PostShorten)
+		})
 	})
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,54 @@
+// Package trusted declares the internal API available only from the trusted subnet.
+package trusted
+
+import (
+	"strings"
+
+	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
+	"github.com/Orendev/shortener/internal/utils"
+)
+
+// Endpoint the internal endpoint in the HTTP and gRPC servers.
+type Endpoint struct {
+	// Path the path of the HTTP route, the path ending with "/" covers all routes under it.
+	Path string
+	// FullMethod the full name of the gRPC method.
+	FullMethod string
+}
+
+// Internal the endpoints available only from the trusted subnet.
+var Internal = []Endpoint{
+	{Path: "/api/internal/stats", FullMethod: pb.ShortenerService_GetAPIStats_FullMethodName},
+	{Path: "/api/admin/"},
+}
+
+// IsInternalPath reports whether the HTTP path is internal.
+func IsInternalPath(path string) bool {
+	for _, endpoint := range Internal {
+		if len(endpoint.Path) == 0 {
+			continue
+		}
+		if endpoint.Path == path || strings.HasSuffix(endpoint.Path, "/") && strings.HasPrefix(path, endpoint.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInternalMethod reports whether the gRPC method is internal.
+func IsInternalMethod(fullMethod string) bool {
+	for _, endpoint := range Internal {
+		if len(endpoint.FullMethod) > 0 && endpoint.FullMethod == fullMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the ip is in the subnet, no ip is trusted when the subnet is not set.
+func Contains(subnet, ip string) (bool, error) {
+	if subnet == "" {
+		return false, nil
+	}
+	return utils.CidrRangeContains(subnet, ip)
+}
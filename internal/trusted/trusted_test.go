@@ -0,0 +1,47 @@
+package trusted
+
+import (
+	"testing"
+
+	pb "github.com/Orendev/shortener/internal/pkg/grpc/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsInternal(t *testing.T) {
+	assert.True(t, IsInternalPath("/api/internal/stats"))
+	assert.False(t, IsInternalPath("/api/user/urls"))
+	assert.True(t, IsInternalPath("/api/admin/links"))
+	assert.True(t, IsInternalPath("/api/admin/users/1/ban"))
+	assert.False(t, IsInternalPath("/api/administrator"))
+
+	assert.True(t, IsInternalMethod(pb.ShortenerService_GetAPIStats_FullMethodName))
+	assert.False(t, IsInternalMethod(pb.ShortenerService_Shorten_FullMethodName))
+	assert.False(t, IsInternalMethod(""))
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		subnet  string
+		ip      string
+		want    bool
+		wantErr bool
+	}{
+		{name: "in the subnet", subnet: "192.168.1.0/24", ip: "192.168.1.10", want: true},
+		{name: "out of the subnet", subnet: "192.168.1.0/24", ip: "10.0.0.1"},
+		{name: "the subnet is not set", ip: "192.168.1.10"},
+		{name: "invalid subnet", subnet: "192.168.1.0", ip: "192.168.1.10", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Contains(tt.subnet, tt.ip)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
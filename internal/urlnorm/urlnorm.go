@@ -0,0 +1,163 @@
+// Package urlnorm validates and normalizes the original URLs of the short links.
+package urlnorm
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/Orendev/shortener/internal/config"
+	"golang.org/x/net/idna"
+)
+
+// Error - structure describing the rejected URL, Code is the machine readable reason.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error the message of the rejection.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errors of the rejected URLs.
+var (
+	ErrRequired          = &Error{Code: "url_required", Message: "the URL is required"}
+	ErrMalformed         = &Error{Code: "url_malformed", Message: "the URL is malformed"}
+	ErrNotAbsolute       = &Error{Code: "url_not_absolute", Message: "the URL must be absolute"}
+	ErrSchemeNotAllowed  = &Error{Code: "url_scheme_not_allowed", Message: "the URL scheme must be http or https"}
+	ErrHostInvalid       = &Error{Code: "url_host_invalid", Message: "the URL host is invalid"}
+	ErrUserInfoForbidden = &Error{Code: "url_userinfo_not_allowed", Message: "the URL must not contain a user name or password"}
+)
+
+// defaultPorts the ports dropped from the URLs of the scheme.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// trackingParams the query parameters dropped with the utm_* ones.
+var trackingParams = map[string]struct{}{
+	"fbclid":  {},
+	"gclid":   {},
+	"dclid":   {},
+	"msclkid": {},
+	"yclid":   {},
+	"mc_cid":  {},
+	"mc_eid":  {},
+}
+
+// Normalizer - structure describing the normalization of the original URLs.
+//
+// The equal URLs are normalized to one form, so the dedup of the original URLs does not
+// depend on the case of the host, the default port or the Unicode spelling of the domain.
+type Normalizer struct {
+	stripTracking bool
+}
+
+// NewNormalizer constructor creates the Normalizer of the configuration.
+func NewNormalizer(cfg config.URLs) *Normalizer {
+	return &Normalizer{stripTracking: cfg.StripTracking}
+}
+
+// Normalize validates the URL and returns its normal form, the rejected URL returns *Error.
+func (n *Normalizer) Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "", ErrRequired
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	// url.Parse приводит схему к нижнему регистру
+	if len(u.Scheme) == 0 {
+		return "", ErrNotAbsolute
+	}
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return "", ErrSchemeNotAllowed
+	}
+	if len(u.Opaque) > 0 {
+		return "", ErrMalformed
+	}
+	if u.User != nil {
+		return "", ErrUserInfoForbidden
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+
+	u.Host = host
+	if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+	if len(port) > 0 {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	if len(u.Path) == 0 {
+		u.Path, u.RawPath = "/", ""
+	}
+
+	if n.stripTracking {
+		u.RawQuery = stripTracking(u.RawQuery)
+		u.ForceQuery = false
+	}
+
+	return u.String(), nil
+}
+
+// normalizeHost the IP address in the canonical form or the domain in lower case punycode.
+func normalizeHost(host string) (string, error) {
+	if len(host) == 0 {
+		return "", ErrHostInvalid
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	// точка в конце обозначает тот же домен
+	host = strings.TrimSuffix(host, ".")
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil || len(ascii) == 0 {
+		return "", ErrHostInvalid
+	}
+
+	return ascii, nil
+}
+
+// stripTracking drops the tracking parameters keeping the order of the rest of the query.
+func stripTracking(rawQuery string) string {
+	if len(rawQuery) == 0 {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		key = strings.ToLower(key)
+
+		if _, ok := trackingParams[key]; ok || strings.HasPrefix(key, "utm_") {
+			continue
+		}
+		kept = append(kept, param)
+	}
+
+	return strings.Join(kept, "&")
+}
@@ -0,0 +1,70 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		stripTracking bool
+		want          string
+		wantErr       error
+	}{
+		{name: "unchanged", raw: "https://practicum.yandex.ru/learn?id=1#top", want: "https://practicum.yandex.ru/learn?id=1#top"},
+		{name: "spaces", raw: " https://example.com/a\n", want: "https://example.com/a"},
+		{name: "host case", raw: "HTTPS://Example.COM/Path", want: "https://example.com/Path"},
+		{name: "empty path", raw: "http://example.com", want: "http://example.com/"},
+		{name: "trailing dot", raw: "http://example.com./", want: "http://example.com/"},
+		{name: "default http port", raw: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "default https port", raw: "https://example.com:443/a", want: "https://example.com/a"},
+		{name: "other port", raw: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "idn", raw: "https://Пример.рф/путь", want: "https://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C"},
+		{name: "punycode", raw: "https://xn--e1afmkfd.xn--p1ai/", want: "https://xn--e1afmkfd.xn--p1ai/"},
+		{name: "ipv4", raw: "http://127.0.0.1:80/", want: "http://127.0.0.1/"},
+		{name: "ipv6", raw: "http://[2001:DB8::1]:8080/", want: "http://[2001:db8::1]:8080/"},
+		{name: "ipv6 default port", raw: "https://[2001:db8::1]:443/", want: "https://[2001:db8::1]/"},
+		{name: "tracking kept", raw: "https://example.com/?utm_source=x&id=1", want: "https://example.com/?utm_source=x&id=1"},
+		{
+			name:          "tracking stripped",
+			raw:           "https://example.com/?UTM_Source=x&id=1&fbclid=abc&b=2&utm_medium",
+			stripTracking: true,
+			want:          "https://example.com/?id=1&b=2",
+		},
+		{name: "only tracking", raw: "https://example.com/a?utm_source=x", stripTracking: true, want: "https://example.com/a"},
+		{name: "empty", raw: "  ", wantErr: ErrRequired},
+		{name: "javascript", raw: "javascript:alert(1)", wantErr: ErrSchemeNotAllowed},
+		{name: "ftp", raw: "ftp://example.com/file", wantErr: ErrSchemeNotAllowed},
+		{name: "relative", raw: "/path/to", wantErr: ErrNotAbsolute},
+		{name: "no scheme", raw: "example.com", wantErr: ErrNotAbsolute},
+		{name: "protocol relative", raw: "//example.com/", wantErr: ErrNotAbsolute},
+		{name: "opaque", raw: "http:example.com", wantErr: ErrMalformed},
+		{name: "garbage", raw: "http://exa mple.com/%zz", wantErr: ErrMalformed},
+		{name: "no host", raw: "https:///path", wantErr: ErrHostInvalid},
+		{name: "invalid host", raw: "https://exa_mple.com/", wantErr: ErrHostInvalid},
+		{name: "userinfo", raw: "https://bank.example@evil.example/", wantErr: ErrUserInfoForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNormalizer(config.URLs{StripTracking: tt.stripTracking})
+
+			got, err := n.Normalize(tt.raw)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// нормальная форма не меняется при повторной нормализации
+			again, err := n.Normalize(got)
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
@@ -0,0 +1,463 @@
+// Package urlpolicy decides which original URLs may be shortened.
+package urlpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/Orendev/shortener/internal/logger"
+	"github.com/Orendev/shortener/internal/urlnorm"
+	"go.uber.org/zap"
+	"golang.org/x/net/idna"
+)
+
+// Limits of the resolution of the domains.
+const (
+	// resolveTimeout the longest lookup of the addresses of the domains of one check.
+	resolveTimeout = 2 * time.Second
+	// resolveWorkers the concurrent lookups of one check.
+	resolveWorkers = 16
+	// resolveCacheTTL how long the resolved domain is remembered.
+	resolveCacheTTL = time.Minute
+	// resolveCacheSize the most remembered domains, the full cache is cleared.
+	resolveCacheSize = 10000
+)
+
+// Errors of the rejected URLs, they are *urlnorm.Error so the responses carry the reason code.
+var (
+	ErrDomainDenied     = &urlnorm.Error{Code: "url_domain_denied", Message: "the URL domain is denied"}
+	ErrDomainNotAllowed = &urlnorm.Error{Code: "url_domain_not_allowed", Message: "the URL domain is not allowed"}
+	ErrPrivateAddress   = &urlnorm.Error{Code: "url_private_address", Message: "the URL must not point to a private, loopback or link-local address"}
+)
+
+// ErrRuleInvalid the domain of the policy file is invalid.
+var ErrRuleInvalid = errors.New("invalid domain rule")
+
+// Rules the format of the policy file.
+//
+// The domain matches the rule "example.com" exactly, the rule "*.example.com" matches
+// the domain itself and its subdomains. The denied domains are rejected; when the allow list is not empty,
+// only its domains are accepted.
+type Rules struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// domains the compiled domain rules.
+type domains struct {
+	exact    map[string]struct{}
+	suffixes []string
+}
+
+// Policy - structure describing the checks of the original URLs before they are saved.
+//
+// The rules are read from the policy file and re-read by Watch when the file changes,
+// the invalid file is logged and the previous rules stay in effect.
+type Policy struct {
+	path         string
+	interval     time.Duration
+	allowPrivate bool
+	resolve      bool
+	timeout      time.Duration
+	lookup       func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	mu      sync.RWMutex
+	allow   domains
+	deny    domains
+	modTime time.Time
+	size    int64
+
+	cacheMu sync.Mutex
+	cache   map[string]resolved
+}
+
+// resolved the remembered lookup of the domain.
+type resolved struct {
+	private bool
+	expires time.Time
+}
+
+// New constructor creates the Policy of the configuration, the rules file is loaded at once.
+func New(cfg config.URLPolicy) (*Policy, error) {
+	p := &Policy{
+		path:         cfg.File,
+		interval:     cfg.ReloadInterval,
+		allowPrivate: cfg.AllowPrivate,
+		resolve:      cfg.Resolve,
+		timeout:      resolveTimeout,
+		lookup:       net.DefaultResolver.LookupIPAddr,
+		cache:        make(map[string]resolved),
+	}
+
+	if len(p.path) > 0 {
+		if _, err := p.Reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Check decides whether the user may shorten the normalized URL, the rejection is logged.
+func (p *Policy) Check(ctx context.Context, userID, rawURL string) error {
+	_, err := p.CheckBatch(ctx, userID, []string{rawURL})
+	return err
+}
+
+// CheckBatch decides whether the user may shorten the normalized URLs, the index of the
+// first rejected URL is returned with the error. The domains of all the URLs are resolved
+// concurrently within one resolveTimeout.
+func (p *Policy) CheckBatch(ctx context.Context, userID string, rawURLs []string) (int, error) {
+	hosts := make([]string, len(rawURLs))
+	for i, rawURL := range rawURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			p.reject(userID, rawURL, urlnorm.ErrMalformed)
+			return i, urlnorm.ErrMalformed
+		}
+		hosts[i] = u.Hostname()
+	}
+
+	private := p.resolveAll(ctx, hosts)
+
+	for i, host := range hosts {
+		if err := p.check(host, private[host]); err != nil {
+			p.reject(userID, rawURLs[i], err)
+			return i, err
+		}
+	}
+
+	return -1, nil
+}
+
+// reject logs the rejected URL.
+func (p *Policy) reject(userID, rawURL string, err error) {
+	logger.Log.Warn("url rejected by the policy",
+		zap.String("user_id", userID), zap.String("url", rawURL), zap.Error(err))
+}
+
+// check applies the rules to the host, private reports whether the domain resolves to a private address.
+func (p *Policy) check(host string, private bool) error {
+	if !p.allowPrivate {
+		if err := checkAddress(host, private); err != nil {
+			return err
+		}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.deny.match(host) {
+		return ErrDomainDenied
+	}
+
+	if !p.allow.empty() && !p.allow.match(host) {
+		return ErrDomainNotAllowed
+	}
+
+	return nil
+}
+
+// checkAddress rejects the private, loopback and link-local hosts.
+func checkAddress(host string, private bool) error {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+
+	if ip := parseIP(host); ip != nil {
+		if isPrivate(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	if private {
+		return ErrPrivateAddress
+	}
+
+	return nil
+}
+
+// resolveAll reports the domains resolving to a private address when the policy is configured
+// to resolve them. The lookups run concurrently and share one deadline; the failed or
+// unfinished lookup is not a rejection.
+func (p *Policy) resolveAll(ctx context.Context, hosts []string) map[string]bool {
+	if !p.resolve || p.allowPrivate {
+		return nil
+	}
+
+	private := make(map[string]bool, len(hosts))
+	pending := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if _, ok := private[host]; ok || parseIP(host) != nil {
+			continue
+		}
+		// повторяющиеся и запомненные домены не разрешаются снова
+		hostPrivate, ok := p.cached(host)
+		private[host] = hostPrivate
+		if !ok {
+			pending = append(pending, host)
+		}
+	}
+
+	if len(pending) == 0 {
+		return private
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, resolveWorkers)
+
+	for _, host := range pending {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-ctx.Done():
+				return
+			}
+
+			hostPrivate, ok := p.lookupPrivate(ctx, host)
+			if !ok {
+				return
+			}
+
+			mu.Lock()
+			private[host] = hostPrivate
+			mu.Unlock()
+		}(host)
+	}
+
+	wg.Wait()
+
+	return private
+}
+
+// lookupPrivate resolves the domain and remembers whether it has a private address,
+// false when the lookup failed.
+func (p *Policy) lookupPrivate(ctx context.Context, host string) (bool, bool) {
+	addrs, err := p.lookup(ctx, host)
+	if err != nil {
+		logger.Log.Debug("cannot resolve the url host", zap.String("host", host), zap.Error(err))
+		return false, false
+	}
+
+	hostPrivate := false
+	for _, addr := range addrs {
+		if isPrivate(addr.IP) {
+			hostPrivate = true
+			break
+		}
+	}
+
+	p.remember(host, hostPrivate)
+
+	return hostPrivate, true
+}
+
+// cached the remembered lookup of the domain.
+func (p *Policy) cached(host string) (bool, bool) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	entry, ok := p.cache[host]
+	if !ok || time.Now().After(entry.expires) {
+		return false, false
+	}
+
+	return entry.private, true
+}
+
+// remember stores the lookup of the domain for resolveCacheTTL.
+func (p *Policy) remember(host string, private bool) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	if len(p.cache) >= resolveCacheSize {
+		p.cache = make(map[string]resolved)
+	}
+	p.cache[host] = resolved{private: private, expires: time.Now().Add(resolveCacheTTL)}
+}
+
+// Reload reads the policy file when it changed since the last read, true if the rules were replaced.
+func (p *Policy) Reload() (bool, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.RLock()
+	unchanged := info.ModTime().Equal(p.modTime) && info.Size() == p.size
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return false, err
+	}
+
+	var rules Rules
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return false, fmt.Errorf("%s: %w", p.path, err)
+	}
+
+	allow, err := compile(rules.Allow)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", p.path, err)
+	}
+	deny, err := compile(rules.Deny)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.allow, p.deny = allow, deny
+	p.modTime, p.size = info.ModTime(), info.Size()
+
+	return true, nil
+}
+
+// Watch re-reads the changed policy file every reload interval until the context is done.
+func (p *Policy) Watch(ctx context.Context) {
+	if len(p.path) == 0 || p.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := p.Reload()
+			if err != nil {
+				logger.Log.Error("cannot reload the url policy, the previous rules are kept", zap.Error(err))
+				continue
+			}
+			if reloaded {
+				logger.Log.Info("url policy reloaded", zap.String("file", p.path))
+			}
+		}
+	}
+}
+
+// compile the rules in the form of the normalized hosts.
+func compile(rules []string) (domains, error) {
+	d := domains{exact: make(map[string]struct{}, len(rules))}
+
+	for _, rule := range rules {
+		wildcard := strings.HasPrefix(rule, "*.")
+		domain := strings.TrimSuffix(strings.TrimPrefix(rule, "*."), ".")
+
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil || len(ascii) == 0 {
+			return domains{}, fmt.Errorf("%w: %q", ErrRuleInvalid, rule)
+		}
+
+		// маска покрывает и сам домен
+		d.exact[ascii] = struct{}{}
+		if wildcard {
+			d.suffixes = append(d.suffixes, "."+ascii)
+		}
+	}
+
+	return d, nil
+}
+
+func (d domains) empty() bool {
+	return len(d.exact) == 0 && len(d.suffixes) == 0
+}
+
+func (d domains) match(host string) bool {
+	if _, ok := d.exact[host]; ok {
+		return true
+	}
+
+	for _, suffix := range d.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPrivate reports whether the address is not reachable from the internet.
+func isPrivate(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// parseIP the address of the host, including the IPv4 forms the browsers accept
+// besides the dotted decimal one: 2130706433, 0x7f.1, 0177.0.0.1.
+func parseIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, ok := parseIPv4Part(part)
+		if !ok {
+			return nil
+		}
+		numbers[i] = n
+	}
+
+	// последняя часть занимает все оставшиеся байты адреса
+	last := len(numbers) - 1
+	var addr uint64
+	for i, n := range numbers[:last] {
+		if n > 255 {
+			return nil
+		}
+		addr |= n << (24 - 8*i)
+	}
+	if numbers[last] >= 1<<(8*(4-last)) {
+		return nil
+	}
+	addr |= numbers[last]
+
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr))
+}
+
+// parseIPv4Part the decimal, 0x hexadecimal or 0 octal part of the IPv4 address.
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case len(part) > 2 && (part[:2] == "0x" || part[:2] == "0X"):
+		part, base = part[2:], 16
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+
+	n, err := strconv.ParseUint(part, base, 32)
+	return n, err == nil
+}
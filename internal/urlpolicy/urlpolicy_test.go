@@ -0,0 +1,180 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Orendev/shortener/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRules(t *testing.T, path, rules string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+}
+
+func TestPolicy_Check(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writeRules(t, path, `{"deny":["phish.example","*.evil.example","*.пример.рф"]}`)
+
+	p, err := New(config.URLPolicy{File: path})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "allowed", url: "https://practicum.yandex.ru/"},
+		{name: "denied", url: "https://phish.example/login", wantErr: ErrDomainDenied},
+		{name: "subdomain of exact rule", url: "https://www.phish.example/"},
+		{name: "wildcard", url: "https://login.evil.example/", wantErr: ErrDomainDenied},
+		{name: "wildcard apex", url: "https://evil.example/", wantErr: ErrDomainDenied},
+		{name: "idn wildcard", url: "https://www.xn--e1afmkfd.xn--p1ai/", wantErr: ErrDomainDenied},
+		{name: "loopback", url: "http://127.0.0.1:8080/", wantErr: ErrPrivateAddress},
+		{name: "localhost", url: "http://localhost/", wantErr: ErrPrivateAddress},
+		{name: "private", url: "http://192.168.1.1/", wantErr: ErrPrivateAddress},
+		{name: "link-local", url: "http://169.254.169.254/latest/meta-data/", wantErr: ErrPrivateAddress},
+		{name: "ipv6 loopback", url: "http://[::1]/", wantErr: ErrPrivateAddress},
+		{name: "ipv4 mapped", url: "http://[::ffff:10.0.0.1]/", wantErr: ErrPrivateAddress},
+		{name: "decimal ip", url: "http://2130706433/", wantErr: ErrPrivateAddress},
+		{name: "hex ip", url: "http://0x7f.1/", wantErr: ErrPrivateAddress},
+		{name: "octal ip", url: "http://0177.0.0.1/", wantErr: ErrPrivateAddress},
+		{name: "public ip", url: "http://8.8.8.8/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(ctx, "user", tt.url)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicy_Allow(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writeRules(t, path, `{"allow":["yandex.ru","*.yandex.ru"],"deny":["mail.yandex.ru"]}`)
+
+	p, err := New(config.URLPolicy{File: path, AllowPrivate: true})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.Check(ctx, "user", "https://yandex.ru/"))
+	assert.NoError(t, p.Check(ctx, "user", "https://practicum.yandex.ru/"))
+	assert.ErrorIs(t, p.Check(ctx, "user", "https://mail.yandex.ru/"), ErrDomainDenied)
+	assert.ErrorIs(t, p.Check(ctx, "user", "https://go.dev/"), ErrDomainNotAllowed)
+	// частные адреса разрешены, но не входят в список
+	assert.ErrorIs(t, p.Check(ctx, "user", "http://127.0.0.1/"), ErrDomainNotAllowed)
+}
+
+func TestPolicy_Resolve(t *testing.T) {
+	p, err := New(config.URLPolicy{Resolve: true})
+	require.NoError(t, err)
+
+	p.lookup = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		if host == "internal.example" {
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.1.2.3")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	assert.ErrorIs(t, p.Check(context.Background(), "user", "https://internal.example/"), ErrPrivateAddress)
+	assert.NoError(t, p.Check(context.Background(), "user", "https://unknown.example/"))
+}
+
+func TestPolicy_CheckBatch(t *testing.T) {
+	p, err := New(config.URLPolicy{Resolve: true})
+	require.NoError(t, err)
+	p.timeout = 50 * time.Millisecond
+
+	var lookups int32
+	p.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		atomic.AddInt32(&lookups, 1)
+		switch host {
+		case "internal.example":
+			return []net.IPAddr{{IP: net.ParseIP("10.1.2.3")}}, nil
+		case "public.example":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		}
+		// недоступный DNS отвечает только по истечении срока
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	// медленные домены пачки разрешаются за одно общее время
+	urls := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		urls = append(urls, fmt.Sprintf("https://slow%d.example/", i))
+	}
+	start := time.Now()
+	i, err := p.CheckBatch(context.Background(), "user", urls)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, i)
+	assert.Less(t, time.Since(start), time.Second)
+
+	i, err = p.CheckBatch(context.Background(), "user", []string{"https://public.example/", "https://internal.example/"})
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+	assert.Equal(t, 1, i)
+
+	// разрешённые домены запоминаются
+	atomic.StoreInt32(&lookups, 0)
+	_, err = p.CheckBatch(context.Background(), "user", []string{"https://public.example/", "https://public.example/a", "https://internal.example/"})
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&lookups))
+}
+
+func TestPolicy_Reload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writeRules(t, path, `{"deny":["phish.example"]}`)
+
+	_, err := New(config.URLPolicy{File: filepath.Join(t.TempDir(), "missing.json")})
+	require.Error(t, err)
+
+	p, err := New(config.URLPolicy{File: path})
+	require.NoError(t, err)
+
+	reloaded, err := p.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeRules(t, path, `{"deny":["phish.example","scam.example"]}`)
+	reloaded, err = p.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.ErrorIs(t, p.Check(ctx, "user", "https://scam.example/"), ErrDomainDenied)
+
+	// ошибочный файл не заменяет действующие правила
+	writeRules(t, path, `{"deny":["bad domain"]}`)
+	_, err = p.Reload()
+	assert.ErrorIs(t, err, ErrRuleInvalid)
+	assert.ErrorIs(t, p.Check(ctx, "user", "https://scam.example/"), ErrDomainDenied)
+
+	writeRules(t, path, `{"deny":[`)
+	_, err = p.Reload()
+	assert.Error(t, err)
+	assert.ErrorIs(t, p.Check(ctx, "user", "https://scam.example/"), ErrDomainDenied)
+
+	// Watch подхватывает изменения файла
+	p.interval = 10 * time.Millisecond
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go p.Watch(watchCtx)
+
+	writeRules(t, path, `{"deny":["other.example"]}`)
+	assert.Eventually(t, func() bool {
+		return p.Check(ctx, "user", "https://scam.example/") == nil
+	}, time.Second, 10*time.Millisecond)
+}
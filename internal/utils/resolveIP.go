@@ -17,3 +17,17 @@ func ResolveIP(r *http.Request) (net.IP, error) {
 	}
 	return ip, nil
 }
+
+// ClientIP the IP address of the client: the X-Real-IP header if present, otherwise the remote address.
+func ClientIP(r *http.Request) string {
+	if ip, err := ResolveIP(r); err == nil {
+		return ip.String()
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}